@@ -20,6 +20,9 @@ import (
 	"os"
 	"slices"
 
+	cmdDeleteRepository "github.com/jlsalvador/simple-registry/internal/cmd/delete_repository"
+	cmdDu "github.com/jlsalvador/simple-registry/internal/cmd/du"
+	cmdFsck "github.com/jlsalvador/simple-registry/internal/cmd/fsck"
 	cmdGarbageCollect "github.com/jlsalvador/simple-registry/internal/cmd/garbage_collect"
 	cmdGenHash "github.com/jlsalvador/simple-registry/internal/cmd/generate_hash"
 	cmdServe "github.com/jlsalvador/simple-registry/internal/cmd/serve"
@@ -32,6 +35,9 @@ var cmds = []cliCmd.Cmd{
 	{Name: cmdGenHash.CmdName, Help: cmdGenHash.CmdHelp, Fn: cmdGenHash.CmdFn},
 	{Name: cmdServe.CmdName, Help: cmdServe.CmdHelp, Fn: cmdServe.CmdFn},
 	{Name: cmdGarbageCollect.CmdName, Help: cmdGarbageCollect.CmdHelp, Fn: cmdGarbageCollect.CmdFn},
+	{Name: cmdDeleteRepository.CmdName, Help: cmdDeleteRepository.CmdHelp, Fn: cmdDeleteRepository.CmdFn},
+	{Name: cmdDu.CmdName, Help: cmdDu.CmdHelp, Fn: cmdDu.CmdFn},
+	{Name: cmdFsck.CmdName, Help: cmdFsck.CmdHelp, Fn: cmdFsck.CmdFn},
 	{Name: cmdVersion.CmdName, Help: cmdVersion.CmdHelp, Fn: cmdVersion.CmdFn},
 }
 
@@ -0,0 +1,63 @@
+package deleterepository
+
+import (
+	"fmt"
+
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/storageops"
+	"github.com/jlsalvador/simple-registry/internal/version"
+	"github.com/jlsalvador/simple-registry/pkg/log"
+)
+
+const CmdName = "delete-repository"
+const CmdHelp = "Deletes a repository, and optionally its unreferenced blobs."
+
+func CmdFn() error {
+	flags, err := parseFlags()
+	if err != nil {
+		return err
+	}
+
+	opts := []config.Option{
+		config.WithAdminPwd([]byte("-")),
+	}
+
+	if flags.DataDir != "" {
+		opts = append(opts, config.WithDataDir(flags.DataDir))
+	}
+
+	if len(flags.CfgDir) > 0 {
+		opts = append(opts, config.WithCfgDirs(flags.CfgDir))
+	}
+
+	var cfg *config.Config
+	cfg, err = config.New(opts...)
+	if err != nil {
+		return err
+	}
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	deleted, err := storageops.DeleteRepository(*cfg, nil, flags.Repo, flags.GC)
+	if err != nil {
+		return err
+	}
+
+	for digest := range deleted {
+		log.Debug(
+			"service.name", version.AppName,
+			"service.version", version.AppVersion,
+			"event.dataset", "cmd.delete_repository",
+			"message", fmt.Sprintf("blob deleted: %s", digest),
+		).Print()
+	}
+	log.Info(
+		"service.name", version.AppName,
+		"service.version", version.AppVersion,
+		"event.dataset", "cmd.delete_repository",
+		"message", fmt.Sprintf("repository %s deleted, %d blobs deleted", flags.Repo, len(deleted)),
+	).Print()
+
+	return nil
+}
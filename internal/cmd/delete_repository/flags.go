@@ -0,0 +1,48 @@
+package deleterepository
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jlsalvador/simple-registry/internal/cmd"
+	cliFlag "github.com/jlsalvador/simple-registry/pkg/cli/flag"
+	"github.com/jlsalvador/simple-registry/pkg/common"
+)
+
+type Flags struct {
+	DataDir string
+	CfgDir  cliFlag.StringSlice
+
+	Repo string
+	GC   bool
+}
+
+func parseFlags() (flags Flags, err error) {
+	flagSet := flag.NewFlagSet("", flag.ExitOnError)
+
+	flagSet.StringVar(&flags.DataDir, "datadir", common.GetEnv(cmd.ENV_PREFIX+"DATADIR", "./data"), "Data directory")
+	flagSet.Var(&flags.CfgDir, "cfgdir", "Directory with YAML configuration files\nCould be specified multiple times")
+
+	flagSet.StringVar(&flags.Repo, "repo", "", "Required. The name of the repository to delete.")
+	flagSet.BoolVar(&flags.GC, "gc", common.GetBool(common.GetEnv(cmd.ENV_PREFIX+"DELETE_REPOSITORY_GC", "false")), "If set, the blobs only referenced by the repository will be garbage collected.")
+
+	if err = flagSet.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if flags.Repo == "" {
+		err = fmt.Errorf("missing repository name, use -repo")
+		return
+	}
+
+	if envVal, ok := os.LookupEnv(cmd.ENV_PREFIX + "CFGDIR"); len(flags.CfgDir) == 0 && ok {
+		dirs := strings.SplitSeq(envVal, ",")
+		for d := range dirs {
+			flags.CfgDir = append(flags.CfgDir, strings.TrimSpace(d))
+		}
+	}
+
+	return
+}
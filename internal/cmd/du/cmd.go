@@ -0,0 +1,102 @@
+package du
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/storageops"
+)
+
+const CmdName = "du"
+const CmdHelp = "Reports the storage used by each repository, and the largest blobs."
+
+func CmdFn() error {
+	flags, err := parseFlags()
+	if err != nil {
+		return err
+	}
+
+	opts := []config.Option{
+		config.WithAdminPwd([]byte("-")),
+	}
+
+	if flags.DataDir != "" {
+		opts = append(opts, config.WithDataDir(flags.DataDir))
+	}
+
+	if len(flags.CfgDir) > 0 {
+		opts = append(opts, config.WithCfgDirs(flags.CfgDir))
+	}
+
+	var cfg *config.Config
+	cfg, err = config.New(opts...)
+	if err != nil {
+		return err
+	}
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	report, err := storageops.Usage(cfg.Data, flags.Top)
+	if err != nil {
+		return err
+	}
+
+	if flags.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	return PrintReport(os.Stdout, report)
+}
+
+// HumanBytes formats n bytes with a binary unit, like "1.5 MiB".
+func HumanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// PrintReport writes the report as tables of repositories and largest blobs.
+func PrintReport(w io.Writer, report storageops.UsageReport) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "REPOSITORY\tMANIFESTS\tBLOBS\tSHARED\tLOGICAL\tPHYSICAL\tRECLAIMABLE")
+	for _, r := range report.Repositories {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\t%s\t%s\n",
+			r.Name, r.Manifests, r.Blobs, r.SharedBlobs,
+			HumanBytes(r.LogicalBytes), HumanBytes(r.PhysicalBytes), HumanBytes(r.ReclaimableBytes),
+		)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\nTotal: %s logical, %s physical, %s unreferenced, %d blobs (%d shared)\n",
+		HumanBytes(report.LogicalBytes), HumanBytes(report.PhysicalBytes),
+		HumanBytes(report.UnreferencedBytes), report.Blobs, report.SharedBlobs,
+	)
+
+	if len(report.LargestBlobs) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(tw, "DIGEST\tSIZE\tREPOSITORIES")
+	for _, b := range report.LargestBlobs {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", b.Digest, HumanBytes(b.Size), b.Repositories)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,40 @@
+package du_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jlsalvador/simple-registry/internal/cmd/du"
+	"github.com/jlsalvador/simple-registry/internal/storageops"
+)
+
+func TestPrintReport(t *testing.T) {
+	var buf bytes.Buffer
+	err := du.PrintReport(&buf, storageops.UsageReport{
+		Repositories: []storageops.RepositoryUsage{{Name: "a", Manifests: 1, Blobs: 2, LogicalBytes: 1536}},
+		LargestBlobs: []storageops.BlobUsage{{Digest: "sha256:abc", Size: 3 << 20, Repositories: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"REPOSITORY", "1.5 KiB", "sha256:abc", "3.0 MiB"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected output to contain %q, got %q", want, buf.String())
+		}
+	}
+}
+
+func TestHumanBytes(t *testing.T) {
+	for n, want := range map[int64]string{
+		0:       "0 B",
+		1023:    "1023 B",
+		1024:    "1.0 KiB",
+		5 << 30: "5.0 GiB",
+	} {
+		if got := du.HumanBytes(n); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+}
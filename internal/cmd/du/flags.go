@@ -0,0 +1,48 @@
+package du
+
+import (
+	"flag"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jlsalvador/simple-registry/internal/cmd"
+	cliFlag "github.com/jlsalvador/simple-registry/pkg/cli/flag"
+	"github.com/jlsalvador/simple-registry/pkg/common"
+)
+
+type Flags struct {
+	DataDir string
+	CfgDir  cliFlag.StringSlice
+
+	Top  int
+	JSON bool
+}
+
+func parseFlags() (flags Flags, err error) {
+	flagSet := flag.NewFlagSet("", flag.ExitOnError)
+
+	flagSet.StringVar(&flags.DataDir, "datadir", common.GetEnv(cmd.ENV_PREFIX+"DATADIR", "./data"), "Data directory")
+	flagSet.Var(&flags.CfgDir, "cfgdir", "Directory with YAML configuration files\nCould be specified multiple times")
+
+	top := flagSet.String("top", common.GetEnv(cmd.ENV_PREFIX+"DU_TOP", "10"), "The amount of largest blobs to report.")
+	flagSet.BoolVar(&flags.JSON, "json", common.GetBool(common.GetEnv(cmd.ENV_PREFIX+"DU_JSON", "false")), "If set, the report is printed as JSON.")
+
+	if err = flagSet.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	flags.Top, err = strconv.Atoi(*top)
+	if err != nil {
+		return
+	}
+
+	if envVal, ok := os.LookupEnv(cmd.ENV_PREFIX + "CFGDIR"); len(flags.CfgDir) == 0 && ok {
+		dirs := strings.SplitSeq(envVal, ",")
+		for d := range dirs {
+			flags.CfgDir = append(flags.CfgDir, strings.TrimSpace(d))
+		}
+	}
+
+	return
+}
@@ -0,0 +1,58 @@
+package fsck
+
+import (
+	"fmt"
+
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/data/filesystem"
+)
+
+const CmdName = "fsck"
+const CmdHelp = "Checks the integrity of a filesystem storage, and optionally repairs it."
+
+func CmdFn() error {
+	flags, err := parseFlags()
+	if err != nil {
+		return err
+	}
+
+	opts := []config.Option{
+		config.WithAdminPwd([]byte("-")),
+	}
+
+	if flags.DataDir != "" {
+		opts = append(opts, config.WithDataDir(flags.DataDir))
+	}
+
+	if len(flags.CfgDir) > 0 {
+		opts = append(opts, config.WithCfgDirs(flags.CfgDir))
+	}
+
+	var cfg *config.Config
+	cfg, err = config.New(opts...)
+	if err != nil {
+		return err
+	}
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	mode := filesystem.FsckModeCheck
+	switch {
+	case flags.Repair:
+		mode = filesystem.FsckModeRepair
+	case flags.Quarantine:
+		mode = filesystem.FsckModeQuarantine
+	}
+
+	res, err := Fsck(cfg.Data, mode)
+	if err != nil {
+		return err
+	}
+
+	if unresolved := res.Problems - res.Resolved; unresolved > 0 {
+		return fmt.Errorf("%w: %d unresolved", ErrProblemsFound, unresolved)
+	}
+
+	return nil
+}
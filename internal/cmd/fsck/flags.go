@@ -0,0 +1,48 @@
+package fsck
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jlsalvador/simple-registry/internal/cmd"
+	cliFlag "github.com/jlsalvador/simple-registry/pkg/cli/flag"
+	"github.com/jlsalvador/simple-registry/pkg/common"
+)
+
+type Flags struct {
+	DataDir string
+	CfgDir  cliFlag.StringSlice
+
+	Repair     bool
+	Quarantine bool
+}
+
+func parseFlags() (flags Flags, err error) {
+	flagSet := flag.NewFlagSet("", flag.ExitOnError)
+
+	flagSet.StringVar(&flags.DataDir, "datadir", common.GetEnv(cmd.ENV_PREFIX+"DATADIR", "./data"), "Data directory")
+	flagSet.Var(&flags.CfgDir, "cfgdir", "Directory with YAML configuration files\nCould be specified multiple times")
+
+	flagSet.BoolVar(&flags.Repair, "repair", common.GetBool(common.GetEnv(cmd.ENV_PREFIX+"FSCK_REPAIR", "false")), "If set, the broken entries will be removed.")
+	flagSet.BoolVar(&flags.Quarantine, "quarantine", common.GetBool(common.GetEnv(cmd.ENV_PREFIX+"FSCK_QUARANTINE", "false")), "If set, the broken entries will be moved under the \"_quarantine\" directory.")
+
+	if err = flagSet.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if flags.Repair && flags.Quarantine {
+		err = fmt.Errorf("-repair and -quarantine are mutually exclusive")
+		return
+	}
+
+	if envVal, ok := os.LookupEnv(cmd.ENV_PREFIX + "CFGDIR"); len(flags.CfgDir) == 0 && ok {
+		dirs := strings.SplitSeq(envVal, ",")
+		for d := range dirs {
+			flags.CfgDir = append(flags.CfgDir, strings.TrimSpace(d))
+		}
+	}
+
+	return
+}
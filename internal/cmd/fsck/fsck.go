@@ -0,0 +1,76 @@
+package fsck
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/internal/data/filesystem"
+	"github.com/jlsalvador/simple-registry/internal/gc"
+	"github.com/jlsalvador/simple-registry/internal/version"
+	"github.com/jlsalvador/simple-registry/pkg/log"
+)
+
+var (
+	ErrNotFilesystem = errors.New("fsck only supports filesystem data storages")
+	ErrProblemsFound = errors.New("storage problems found")
+)
+
+// Result counts the problems found, and those removed, quarantined or
+// rewritten.
+type Result struct {
+	Problems int
+	Resolved int
+}
+
+// Fsck checks the filesystem data storage under ds decorators, logging every
+// problem found as a warning and a summary at the end.
+func Fsck(ds data.DataStorage, mode filesystem.FsckMode) (res Result, err error) {
+	fs, ok := gc.Local(ds).(*filesystem.FilesystemDataStorage)
+	if !ok {
+		return res, ErrNotFilesystem
+	}
+
+	err = fs.Fsck(mode, func(p filesystem.FsckProblem) {
+		res.Problems++
+		if p.Action != filesystem.FsckActionNone {
+			res.Resolved++
+		}
+
+		entry := log.Warn(
+			"service.name", version.AppName,
+			"service.version", version.AppVersion,
+			"event.dataset", "cmd.fsck",
+			"fsck.kind", p.Kind,
+			"file.path", p.Path,
+			"message", p.Message,
+		)
+		if p.Repository != "" {
+			entry.With("fsck.repository", p.Repository)
+		}
+		if p.Digest != "" {
+			entry.With("fsck.digest", p.Digest)
+		}
+		if len(p.Missing) > 0 {
+			entry.With("fsck.missing", p.Missing)
+		}
+		if p.Action != filesystem.FsckActionNone {
+			entry.With("fsck.action", p.Action)
+		}
+		entry.Print()
+	})
+	if err != nil {
+		return res, err
+	}
+
+	log.Info(
+		"service.name", version.AppName,
+		"service.version", version.AppVersion,
+		"event.dataset", "cmd.fsck",
+		"fsck.problems", res.Problems,
+		"fsck.resolved", res.Resolved,
+		"message", fmt.Sprintf("fsck found %d problems, %d resolved", res.Problems, res.Resolved),
+	).Print()
+
+	return res, nil
+}
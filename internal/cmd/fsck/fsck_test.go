@@ -0,0 +1,147 @@
+package fsck_test
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jlsalvador/simple-registry/internal/cmd/fsck"
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/data/filesystem"
+	"github.com/jlsalvador/simple-registry/pkg/log"
+)
+
+func newConfig(t *testing.T, datadir string) *config.Config {
+	t.Helper()
+
+	cfg, err := config.New(
+		config.WithAdminName("test"),
+		config.WithAdminPwd([]byte("test")),
+		config.WithDataDir(datadir),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func captureLog(t *testing.T) (stdout, stderr *bytes.Buffer) {
+	t.Helper()
+
+	stdout, stderr = &bytes.Buffer{}, &bytes.Buffer{}
+	oldStdout, oldStderr := log.DefaultStdout, log.DefaultStderr
+	log.DefaultStdout, log.DefaultStderr = stdout, stderr
+	t.Cleanup(func() {
+		log.DefaultStdout, log.DefaultStderr = oldStdout, oldStderr
+	})
+	return stdout, stderr
+}
+
+func parseEntries(t *testing.T, b *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var entries []map[string]any
+	scanner := bufio.NewScanner(b)
+	for scanner.Scan() {
+		var entry map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("expected JSON entry, got %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestFsck(t *testing.T) {
+	datadir := t.TempDir()
+	cfg := newConfig(t, datadir)
+
+	blob := []byte("blob")
+	sum := sha256.Sum256(blob)
+	hash := hex.EncodeToString(sum[:])
+	digest := "sha256:" + hash
+
+	uuid, err := cfg.Data.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Data.BlobsUploadWrite("repo", uuid, bytes.NewReader(blob), -1); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Data.BlobsUploadCommit("repo", uuid, digest); err != nil {
+		t.Fatal(err)
+	}
+
+	blobPath := filepath.Join(datadir, "blobs", "sha256", hash[0:2], hash)
+	if err := os.WriteFile(blobPath, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr := captureLog(t)
+
+	res, err := fsck.Fsck(cfg.Data, filesystem.FsckModeCheck)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Problems != 2 || res.Resolved != 0 {
+		t.Errorf("expected 2 problems and none resolved, got %+v", res)
+	}
+
+	warns := parseEntries(t, stderr)
+	if len(warns) != 2 {
+		t.Fatalf("expected 2 warnings, got %d", len(warns))
+	}
+	if got := warns[0]["fsck.kind"]; got != filesystem.FsckBlobCorrupt {
+		t.Errorf("expected %s, got %v", filesystem.FsckBlobCorrupt, got)
+	}
+	if got := warns[0]["file.path"]; got != blobPath {
+		t.Errorf("expected %s, got %v", blobPath, got)
+	}
+	if got := warns[1]["fsck.repository"]; got != "repo" {
+		t.Errorf("expected repo, got %v", got)
+	}
+	for _, warn := range warns {
+		if got := warn[log.FieldLevel]; got != log.LevelWarn {
+			t.Errorf("expected %s, got %v", log.LevelWarn, got)
+		}
+		if got := warn["fsck.digest"]; got != digest {
+			t.Errorf("expected %s, got %v", digest, got)
+		}
+	}
+
+	infos := parseEntries(t, stdout)
+	if len(infos) != 1 || infos[0]["fsck.problems"] != float64(2) {
+		t.Errorf("expected a summary with 2 problems, got %v", infos)
+	}
+
+	// Repairing resolves every problem.
+	res, err = fsck.Fsck(cfg.Data, filesystem.FsckModeRepair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Problems != 2 || res.Resolved != 2 {
+		t.Errorf("expected 2 problems resolved, got %+v", res)
+	}
+
+	res, err = fsck.Fsck(cfg.Data, filesystem.FsckModeCheck)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Problems != 0 {
+		t.Errorf("expected no problems, got %+v", res)
+	}
+}
+
+func TestFsck_NotFilesystem(t *testing.T) {
+	cfg := newConfig(t, "mem://")
+
+	if _, err := fsck.Fsck(cfg.Data, filesystem.FsckModeCheck); !errors.Is(err, fsck.ErrNotFilesystem) {
+		t.Errorf("expected %v, got %v", fsck.ErrNotFilesystem, err)
+	}
+}
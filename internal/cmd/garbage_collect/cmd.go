@@ -4,8 +4,7 @@ import (
 	"fmt"
 
 	"github.com/jlsalvador/simple-registry/internal/config"
-	"github.com/jlsalvador/simple-registry/internal/version"
-	"github.com/jlsalvador/simple-registry/pkg/log"
+	"github.com/jlsalvador/simple-registry/internal/gc"
 )
 
 const CmdName = "garbage-collect"
@@ -38,77 +37,23 @@ func CmdFn() error {
 		return fmt.Errorf("config is nil")
 	}
 
-	deletedBlobs, deletedManifests, seenBlobs, seenManifests, err := GarbageCollect(
-		*cfg,
-		flags.DryRun,
-		flags.LastAccess,
-		flags.DeleteUntagged,
-	)
+	res, err := gc.Collect(*cfg, gc.Options{
+		DryRun:         flags.DryRun,
+		LastAccess:     flags.LastAccess,
+		DeleteUntagged: flags.DeleteUntagged,
+	})
 	if err != nil {
 		return err
 	}
 
-	nManifestsDeleted := 0
-	for digest := range deletedManifests {
-		nManifestsDeleted++
-		if flags.DryRun {
-			log.Debug(
-				"service.name", version.AppName,
-				"service.version", version.AppVersion,
-				"event.dataset", "cmd.garbage_collect",
-				"message", fmt.Sprintf("manifest eligible for deletion: %s", digest),
-			).Print()
-		} else {
-			log.Debug(
-				"service.name", version.AppName,
-				"service.version", version.AppVersion,
-				"event.dataset", "cmd.garbage_collect",
-				"message", fmt.Sprintf("manifest deleted: %s", digest),
-			).Print()
-		}
-	}
+	gc.LogResult(flags.DryRun, res)
 
-	nBlobsDeleted := 0
-	for digest := range deletedBlobs {
-		nBlobsDeleted++
-		if flags.DryRun {
-			log.Debug(
-				"service.name", version.AppName,
-				"service.version", version.AppVersion,
-				"event.dataset", "cmd.garbage_collect",
-				"message", fmt.Sprintf("blob eligible for deletion: %s", digest),
-			).Print()
-		} else {
-			log.Debug(
-				"service.name", version.AppName,
-				"service.version", version.AppVersion,
-				"event.dataset", "cmd.garbage_collect",
-				"message", fmt.Sprintf("blob deleted: %s", digest),
-			).Print()
-		}
+	if flags.UploadMaxAge <= 0 {
+		return nil
 	}
-	if flags.DryRun {
-		log.Info(
-			"service.name", version.AppName,
-			"service.version", version.AppVersion,
-			"event.dataset", "cmd.garbage_collect",
-			"message", fmt.Sprintf(
-				"%d manifests marked, %d blobs marked, %d manifests eligible for deletion, %d blobs eligible for deletion",
-				len(seenManifests), len(seenBlobs), nManifestsDeleted, nBlobsDeleted,
-			),
-		).Print()
 
-	} else {
-		log.Info(
-			"service.name", version.AppName,
-			"service.version", version.AppVersion,
-			"event.dataset", "cmd.garbage_collect",
-			"message", fmt.Sprintf(
-				"%d manifests marked, %d blobs marked, %d manifests deleted, %d blobs deleted",
-				len(seenManifests), len(seenBlobs), nManifestsDeleted, nBlobsDeleted,
-			),
-		).Print()
-	}
+	expired, err := gc.ExpireUploads(cfg.Data, flags.UploadMaxAge, flags.DryRun)
+	gc.LogExpiredUploads(flags.DryRun, expired)
 
-	return nil
+	return err
 }
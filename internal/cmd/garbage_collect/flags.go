@@ -18,6 +18,7 @@ type Flags struct {
 	DryRun         bool
 	DeleteUntagged bool
 	LastAccess     time.Duration
+	UploadMaxAge   time.Duration
 }
 
 func parseFlags() (flags Flags, err error) {
@@ -31,6 +32,8 @@ func parseFlags() (flags Flags, err error) {
 
 	lastAccess := flagSet.String("last-access", common.GetEnv(cmd.ENV_PREFIX+"LAST_ACCESS", "24h"), "The time since the last access to a file before it is considered garbage.\nFormat: 1h, 2m, 3s, etc. Default: 24h.")
 
+	uploadMaxAge := flagSet.String("upload-max-age", common.GetEnv(cmd.ENV_PREFIX+"UPLOAD_MAX_AGE", ""), "The time since an upload session was started before it is considered abandoned.\nFormat: 1h, 2m, 3s, etc. Disabled if empty.")
+
 	if err = flagSet.Parse(os.Args[2:]); err != nil {
 		return
 	}
@@ -40,6 +43,13 @@ func parseFlags() (flags Flags, err error) {
 		return
 	}
 
+	if *uploadMaxAge != "" {
+		flags.UploadMaxAge, err = time.ParseDuration(*uploadMaxAge)
+		if err != nil {
+			return
+		}
+	}
+
 	if envVal, ok := os.LookupEnv(cmd.ENV_PREFIX + "CFGDIR"); len(flags.CfgDir) == 0 && ok {
 		dirs := strings.SplitSeq(envVal, ",")
 		for d := range dirs {
@@ -1,11 +1,18 @@
 package serve
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
 	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/internal/data/immutable"
+	"github.com/jlsalvador/simple-registry/internal/data/proxy"
+	"github.com/jlsalvador/simple-registry/internal/data/quota"
+	"github.com/jlsalvador/simple-registry/internal/gc"
 	"github.com/jlsalvador/simple-registry/internal/http/handler"
+	"github.com/jlsalvador/simple-registry/internal/replication"
 	"github.com/jlsalvador/simple-registry/internal/version"
 	"github.com/jlsalvador/simple-registry/pkg/log"
 )
@@ -88,11 +95,80 @@ func buildOptions(flags *Flags) []config.Option {
 		opts = append(opts, config.WithHttpKeyFile(flags.KeyFile))
 	}
 
+	opts = append(opts, buildGarbageCollectOptions(flags)...)
+
+	if flags.UploadMaxAge > 0 {
+		opts = append(opts, config.WithUploadsMaxAge(flags.UploadMaxAge))
+	}
+
+	if flags.ReplicationQueueDir != "" {
+		opts = append(opts, config.WithReplicationQueueDir(flags.ReplicationQueueDir))
+	}
+
 	return opts
 }
 
+func buildGarbageCollectOptions(flags *Flags) []config.Option {
+	opts := []config.Option{}
+
+	if flags.GCInterval > 0 {
+		opts = append(opts, config.WithGarbageCollectInterval(flags.GCInterval))
+	}
+
+	if flags.GCDryRun {
+		opts = append(opts, config.WithGarbageCollectDryRun(flags.GCDryRun))
+	}
+
+	if flags.GCDeleteUntagged {
+		opts = append(opts, config.WithGarbageCollectDeleteUntagged(flags.GCDeleteUntagged))
+	}
+
+	if flags.GCLastAccess > 0 {
+		opts = append(opts, config.WithGarbageCollectLastAccess(flags.GCLastAccess))
+	}
+
+	return opts
+}
+
+// findProxy returns the pull-through cache wrapped by the quota and immutable
+// tags policies, nil if there is none.
+func findProxy(ds data.DataStorage) *proxy.ProxyDataStorage {
+	if d, ok := ds.(*quota.QuotaDataStorage); ok {
+		ds = d.Next
+	}
+	if d, ok := ds.(*immutable.ImmutableDataStorage); ok {
+		ds = d.Next
+	}
+	p, _ := ds.(*proxy.ProxyDataStorage)
+	return p
+}
+
 func runServer(cfg *config.Config) error {
-	h := handler.NewHandler(*cfg)
+	collector := gc.NewCollector(*cfg)
+	go collector.Schedule(context.Background())
+	go gc.ScheduleUploadsJanitor(context.Background(), *cfg)
+
+	handlerOpts := []handler.Option{handler.WithGarbageCollector(collector)}
+
+	if len(cfg.Replication.Targets) > 0 {
+		replicator, err := replication.NewReplicator(
+			cfg.Data,
+			cfg.Replication.Targets,
+			cfg.Replication.QueueDir,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create replicator: %w", err)
+		}
+		go replicator.Run(context.Background())
+		handlerOpts = append(handlerOpts, handler.WithReplicator(replicator))
+	}
+
+	if p := findProxy(cfg.Data); p != nil && len(p.Proxies) > 0 {
+		go p.Run(context.Background())
+		handlerOpts = append(handlerOpts, handler.WithProxy(p))
+	}
+
+	h := handler.NewHandler(*cfg, handlerOpts...)
 
 	isTLS := cfg.Web.CertFile != "" && cfg.Web.KeyFile != ""
 
@@ -29,12 +29,21 @@ type Flags struct {
 	TokenTimeout    time.Duration
 
 	UI bool
+
+	GCInterval       time.Duration
+	GCDryRun         bool
+	GCDeleteUntagged bool
+	GCLastAccess     time.Duration
+
+	UploadMaxAge time.Duration
+
+	ReplicationQueueDir string
 }
 
 func parseFlags() (flags Flags, err error) {
 	flagSet := flag.NewFlagSet("", flag.ExitOnError)
 	flagSet.StringVar(&flags.Addr, "addr", common.GetEnv(cmd.ENV_PREFIX+"ADDR", "0.0.0.0:5000"), "Listening address")
-	flagSet.StringVar(&flags.DataDir, "datadir", common.GetEnv(cmd.ENV_PREFIX+"DATADIR", "./data"), "Data directory")
+	flagSet.StringVar(&flags.DataDir, "datadir", common.GetEnv(cmd.ENV_PREFIX+"DATADIR", "./data"), "Data directory\nUse mem:// to keep data in memory, optionally limited by bytes like mem://?limit=1073741824")
 
 	flagSet.Var(&flags.CfgDir, "cfgdir", "Directory with YAML configuration files\nCould be specified multiple times")
 
@@ -51,10 +60,38 @@ func parseFlags() (flags Flags, err error) {
 
 	flagSet.BoolVar(&flags.UI, "ui", common.GetBool(common.GetEnv(cmd.ENV_PREFIX+"UI", "false")), "Enable web UI")
 
+	gcInterval := flagSet.String("gc-interval", common.GetEnv(cmd.ENV_PREFIX+"GC_INTERVAL", "0"), "Run the garbage collector periodically\nFormat: 1h, 2m, 3s, etc. Default: 0 (disabled).")
+	flagSet.BoolVar(&flags.GCDeleteUntagged, "gc-delete-untagged", common.GetBool(common.GetEnv(cmd.ENV_PREFIX+"GC_DELETE_UNTAGGED", "false")), "If set, the garbage collector will delete manifests that are not currently referenced by a tag.")
+	flagSet.BoolVar(&flags.GCDryRun, "gc-dryrun", common.GetBool(common.GetEnv(cmd.ENV_PREFIX+"GC_DRYRUN", "false")), "If set, the garbage collector will not actually remove any blobs.")
+	gcLastAccess := flagSet.String("gc-last-access", common.GetEnv(cmd.ENV_PREFIX+"GC_LAST_ACCESS", ""), "The time since the last access to a file before it is considered garbage.\nFormat: 1h, 2m, 3s, etc. Default: 24h.")
+
+	uploadMaxAge := flagSet.String("upload-max-age", common.GetEnv(cmd.ENV_PREFIX+"UPLOAD_MAX_AGE", ""), "The time since an upload session was started before it expires and is deleted.\nFormat: 1h, 2m, 3s, etc. Disabled if empty.")
+
+	flagSet.StringVar(&flags.ReplicationQueueDir, "replication-queue-dir", common.GetEnv(cmd.ENV_PREFIX+"REPLICATION_QUEUE_DIR", ""), "Directory to persist the pending replications to downstream registries\nPending replications are kept in memory if empty")
+
 	if err = flagSet.Parse(os.Args[2:]); err != nil {
 		return
 	}
 
+	flags.GCInterval, err = time.ParseDuration(*gcInterval)
+	if err != nil {
+		return
+	}
+
+	if *gcLastAccess != "" {
+		flags.GCLastAccess, err = time.ParseDuration(*gcLastAccess)
+		if err != nil {
+			return
+		}
+	}
+
+	if *uploadMaxAge != "" {
+		flags.UploadMaxAge, err = time.ParseDuration(*uploadMaxAge)
+		if err != nil {
+			return
+		}
+	}
+
 	if envVal, ok := os.LookupEnv(cmd.ENV_PREFIX + "CFGDIR"); len(flags.CfgDir) == 0 && ok {
 		dirs := strings.SplitSeq(envVal, ",")
 		for d := range dirs {
@@ -18,16 +18,26 @@ import (
 	"crypto/rand"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jlsalvador/simple-registry/internal/data"
 	"github.com/jlsalvador/simple-registry/internal/data/filesystem"
+	"github.com/jlsalvador/simple-registry/internal/data/guard"
+	"github.com/jlsalvador/simple-registry/internal/data/immutable"
+	"github.com/jlsalvador/simple-registry/internal/data/memory"
 	"github.com/jlsalvador/simple-registry/internal/data/proxy"
+	"github.com/jlsalvador/simple-registry/internal/data/quota"
+	dataS3 "github.com/jlsalvador/simple-registry/internal/data/s3"
+	"github.com/jlsalvador/simple-registry/internal/replication"
 	"github.com/jlsalvador/simple-registry/internal/version"
 	"github.com/jlsalvador/simple-registry/pkg/log"
 	"github.com/jlsalvador/simple-registry/pkg/rbac"
+	pkgS3 "github.com/jlsalvador/simple-registry/pkg/s3"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -41,10 +51,52 @@ type Web struct {
 	KeyFile      string
 }
 
+// GarbageCollect are the settings of the garbage collector scheduled by the
+// server.
+type GarbageCollect struct {
+	Interval       time.Duration // Disabled if zero.
+	DryRun         bool
+	DeleteUntagged bool
+	LastAccess     time.Duration
+	Retention      []RetentionPolicy
+}
+
+// RetentionPolicy selects the tags, of the repositories matched by any of
+// Scopes, that the garbage collector keeps. The other ones are expired.
+//
+// A tag is kept if it matches any of KeepTags, if it is one of the KeepLast
+// most recently pushed tags of its repository, or if it is younger than
+// ExpireAfter. A policy without KeepLast nor ExpireAfter keeps every tag.
+type RetentionPolicy struct {
+	Name        string
+	Scopes      []regexp.Regexp // Regular expressions matching the repository path.
+	KeepTags    []regexp.Regexp // Regular expressions matching the tag.
+	KeepLast    int             // Disabled if zero.
+	ExpireAfter time.Duration   // Disabled if zero.
+}
+
+// Uploads are the settings of the blob upload sessions.
+type Uploads struct {
+	// MaxAge is how long an upload session could stay in progress. Older
+	// sessions are unknown to clients, and deleted by the janitor. Sessions
+	// do not expire if zero.
+	MaxAge time.Duration
+}
+
+// Replication are the settings of the push replication to downstream
+// registries.
+type Replication struct {
+	QueueDir string // Pending replications are kept in memory if empty.
+	Targets  []replication.Target
+}
+
 type Config struct {
-	Web  Web
-	Rbac rbac.Engine
-	Data data.DataStorage
+	Web            Web
+	Rbac           rbac.Engine
+	Data           data.DataStorage
+	GarbageCollect GarbageCollect
+	Uploads        Uploads
+	Replication    Replication
 }
 
 type options struct {
@@ -57,8 +109,21 @@ type options struct {
 	certfile     string
 	keyfile      string
 
+	gcInterval       time.Duration
+	gcDryRun         bool
+	gcDeleteUntagged bool
+	gcLastAccess     time.Duration
+	gcRetention      []RetentionPolicy
+
+	uploadsMaxAge time.Duration
+
+	replicationQueueDir string
+	replicationTargets  []replication.Target
+
 	rbacEngine *rbac.Engine
 	data       data.DataStorage
+	quotas     []quota.Quota
+	immutables []immutable.Rule
 }
 
 type Option func(*options)
@@ -107,9 +172,34 @@ func WithHttpTokenTimeout(timeout time.Duration) Option {
 	}
 }
 
+// memoryScheme is the data directory prefix to use an in-memory DataStorage,
+// optionally with a limit of bytes, like "mem://?limit=1073741824".
+const memoryScheme = "mem://"
+
+func newDataStorage(dir string) data.DataStorage {
+	if !strings.HasPrefix(dir, memoryScheme) {
+		return filesystem.NewFilesystemDataStorage(dir)
+	}
+
+	u, err := url.Parse(dir)
+	if err != nil {
+		panic(err)
+	}
+
+	var limit int64
+	if l := u.Query().Get("limit"); l != "" {
+		limit, err = strconv.ParseInt(l, 10, 64)
+		if err != nil {
+			panic(fmt.Errorf("invalid memory limit %q: %w", l, err))
+		}
+	}
+
+	return memory.NewMemoryDataStorage(limit)
+}
+
 func WithDataDir(dir string) Option {
 	return func(o *options) {
-		o.data = filesystem.NewFilesystemDataStorage(dir)
+		o.data = guard.NewGuardDataStorage(newDataStorage(dir))
 	}
 }
 
@@ -137,6 +227,66 @@ func WithHttpKeyFile(keyFile string) Option {
 	}
 }
 
+func WithGarbageCollectInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.gcInterval = interval
+	}
+}
+
+func WithGarbageCollectDryRun(dryRun bool) Option {
+	return func(o *options) {
+		o.gcDryRun = dryRun
+	}
+}
+
+func WithGarbageCollectDeleteUntagged(deleteUntagged bool) Option {
+	return func(o *options) {
+		o.gcDeleteUntagged = deleteUntagged
+	}
+}
+
+func WithGarbageCollectLastAccess(lastAccess time.Duration) Option {
+	return func(o *options) {
+		o.gcLastAccess = lastAccess
+	}
+}
+
+func WithUploadsMaxAge(maxAge time.Duration) Option {
+	return func(o *options) {
+		o.uploadsMaxAge = maxAge
+	}
+}
+
+func WithQuotas(quotas []quota.Quota) Option {
+	return func(o *options) {
+		o.quotas = quotas
+	}
+}
+
+func WithImmutableTags(rules []immutable.Rule) Option {
+	return func(o *options) {
+		o.immutables = rules
+	}
+}
+
+func WithGarbageCollectRetention(policies []RetentionPolicy) Option {
+	return func(o *options) {
+		o.gcRetention = policies
+	}
+}
+
+func WithReplicationQueueDir(dir string) Option {
+	return func(o *options) {
+		o.replicationQueueDir = dir
+	}
+}
+
+func WithReplicationTargets(targets []replication.Target) Option {
+	return func(o *options) {
+		o.replicationTargets = targets
+	}
+}
+
 func WithCfgDirs(dirs []string) Option {
 	return func(o *options) {
 		manifests := []any{}
@@ -165,10 +315,45 @@ func WithCfgDirs(dirs []string) Option {
 			panic(err)
 		}
 
+		quotas, err := getQuotasFromManifests(manifests)
+		if err != nil {
+			panic(err)
+		}
+		if len(quotas) > 0 {
+			WithQuotas(quotas)(o)
+		}
+
+		immutables, err := getImmutableRulesFromManifests(manifests)
+		if err != nil {
+			panic(err)
+		}
+		if len(immutables) > 0 {
+			WithImmutableTags(immutables)(o)
+		}
+
+		s3, err := getS3FromManifests(manifests)
+		if err != nil {
+			panic(err)
+		}
+
 		dataDir := getDataDirFromManifests(manifests)
+		if dataDir != "" && s3.Bucket != "" {
+			panic("Configuration.spec.dataDir and Configuration.spec.s3 are mutually exclusive")
+		}
 		if dataDir != "" {
-			fs := filesystem.NewFilesystemDataStorage(dataDir)
-			o.data = proxy.NewProxyDataStorage(fs, proxies)
+			ds := guard.NewGuardDataStorage(newDataStorage(dataDir))
+			o.data = proxy.NewProxyDataStorage(ds, proxies)
+		}
+		if s3.Bucket != "" {
+			client := pkgS3.New(
+				s3.Endpoint,
+				s3.Region,
+				s3.Bucket,
+				s3.AccessKeyID,
+				s3.SecretAccessKey,
+			)
+			ds := guard.NewGuardDataStorage(dataS3.NewS3DataStorage(client, s3.Prefix))
+			o.data = proxy.NewProxyDataStorage(ds, proxies)
 		}
 
 		http := getWebFromManifests(manifests)
@@ -190,6 +375,39 @@ func WithCfgDirs(dirs []string) Option {
 		if http.KeyFile != "" {
 			WithHttpKeyFile(http.KeyFile)(o)
 		}
+
+		gc := getGarbageCollectFromManifests(manifests)
+		if gc.Interval > 0 {
+			WithGarbageCollectInterval(gc.Interval)(o)
+		}
+		if gc.DryRun {
+			WithGarbageCollectDryRun(gc.DryRun)(o)
+		}
+		if gc.DeleteUntagged {
+			WithGarbageCollectDeleteUntagged(gc.DeleteUntagged)(o)
+		}
+		if gc.LastAccess > 0 {
+			WithGarbageCollectLastAccess(gc.LastAccess)(o)
+		}
+
+		retention, err := getRetentionPoliciesFromManifests(manifests)
+		if err != nil {
+			panic(err)
+		}
+		if len(retention) > 0 {
+			WithGarbageCollectRetention(retention)(o)
+		}
+
+		targets, err := getReplicationTargetsFromManifests(manifests)
+		if err != nil {
+			panic(err)
+		}
+		if len(targets) > 0 {
+			WithReplicationTargets(targets)(o)
+		}
+		if queueDir := getReplicationQueueDirFromManifests(manifests); queueDir != "" {
+			WithReplicationQueueDir(queueDir)(o)
+		}
 	}
 }
 
@@ -201,7 +419,13 @@ func New(opts ...Option) (*Config, error) {
 
 	// Data
 	if o.data == nil {
-		panic("datadir is empty, please use flag -datadir or use YAML Configuration.spec.dataDir")
+		panic("datadir is empty, please use flag -datadir or use YAML Configuration.spec.dataDir or Configuration.spec.s3")
+	}
+	if len(o.immutables) > 0 {
+		o.data = immutable.NewImmutableDataStorage(o.data, o.immutables)
+	}
+	if len(o.quotas) > 0 {
+		o.data = quota.NewQuotaDataStorage(o.data, o.quotas)
 	}
 
 	// RBAC
@@ -308,9 +532,35 @@ func New(opts ...Option) (*Config, error) {
 		KeyFile:      o.keyfile,
 	}
 
+	// Garbage collector
+	if o.gcLastAccess == 0 {
+		o.gcLastAccess = time.Hour * 24
+	}
+	gc := GarbageCollect{
+		Interval:       o.gcInterval,
+		DryRun:         o.gcDryRun,
+		DeleteUntagged: o.gcDeleteUntagged,
+		LastAccess:     o.gcLastAccess,
+		Retention:      o.gcRetention,
+	}
+
+	// Uploads
+	uploads := Uploads{
+		MaxAge: o.uploadsMaxAge,
+	}
+
+	// Replication
+	repl := Replication{
+		QueueDir: o.replicationQueueDir,
+		Targets:  o.replicationTargets,
+	}
+
 	return &Config{
-		Web:  web,
-		Rbac: *o.rbacEngine,
-		Data: o.data,
+		Web:            web,
+		Rbac:           *o.rbacEngine,
+		Data:           o.data,
+		GarbageCollect: gc,
+		Uploads:        uploads,
+		Replication:    repl,
 	}, nil
 }
@@ -15,11 +15,19 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/internal/data/guard"
+	"github.com/jlsalvador/simple-registry/internal/data/immutable"
+	"github.com/jlsalvador/simple-registry/internal/data/memory"
+	"github.com/jlsalvador/simple-registry/internal/data/quota"
 )
 
 func TestNew(t *testing.T) {
@@ -248,3 +256,167 @@ func TestNewPanicsWithoutAdminPwd(t *testing.T) {
 
 	New(WithDataDir(tmpDir))
 }
+
+func TestNewPanicsWithDataDirAndS3(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected panic with both data dir and s3")
+		}
+	}()
+
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "config.yaml"), []byte(`
+apiVersion: `+apiVersion+`
+kind: Configuration
+metadata:
+  name: config
+spec:
+  dataDir: `+tmpDir+`
+  s3:
+    bucket: registry
+`), 0o644)
+
+	New(
+		WithAdminName("admin"),
+		WithAdminPwd([]byte("pwd")),
+		WithCfgDirs([]string{tmpDir}),
+	)
+}
+
+func TestNewWithMemoryDataDir(t *testing.T) {
+	cfg, err := New(
+		WithAdminName("admin"),
+		WithAdminPwd([]byte("pwd")),
+		WithDataDir("mem://?limit=1024"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, ok := cfg.Data.(*guard.GuardDataStorage)
+	if !ok {
+		t.Fatalf("expected *guard.GuardDataStorage, got %T", cfg.Data)
+	}
+	m, ok := g.Next.(*memory.MemoryDataStorage)
+	if !ok {
+		t.Fatalf("expected *memory.MemoryDataStorage, got %T", g.Next)
+	}
+
+	uuid, err := m.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.BlobsUploadWrite("repo", uuid, strings.NewReader(strings.Repeat("a", 1025)), -1)
+	if !errors.Is(err, data.ErrStorageFull) {
+		t.Errorf("expected ErrStorageFull, got %v", err)
+	}
+}
+
+func TestNewPanicsWithInvalidMemoryLimit(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected panic with invalid memory limit")
+		}
+	}()
+
+	New(
+		WithAdminName("admin"),
+		WithAdminPwd([]byte("pwd")),
+		WithDataDir("mem://?limit=lots"),
+	)
+}
+
+func TestNewWithGarbageCollect(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	cfg, err := New(
+		WithAdminName("admin"),
+		WithAdminPwd([]byte("pwd")),
+		WithDataDir(tmpDir),
+		WithGarbageCollectInterval(time.Hour),
+		WithGarbageCollectDryRun(true),
+		WithGarbageCollectDeleteUntagged(true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := GarbageCollect{
+		Interval:       time.Hour,
+		DryRun:         true,
+		DeleteUntagged: true,
+		LastAccess:     24 * time.Hour, // Default.
+	}
+	if !reflect.DeepEqual(cfg.GarbageCollect, want) {
+		t.Errorf("expected %+v, got %+v", want, cfg.GarbageCollect)
+	}
+}
+
+func TestNewWithUploadsMaxAge(t *testing.T) {
+	cfg, err := New(
+		WithAdminName("admin"),
+		WithAdminPwd([]byte("pwd")),
+		WithDataDir("mem://"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Uploads.MaxAge != 0 {
+		t.Errorf("expected disabled by default, got %s", cfg.Uploads.MaxAge)
+	}
+
+	cfg, err = New(
+		WithAdminName("admin"),
+		WithAdminPwd([]byte("pwd")),
+		WithDataDir("mem://"),
+		WithUploadsMaxAge(time.Hour),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Uploads.MaxAge != time.Hour {
+		t.Errorf("expected 1h, got %s", cfg.Uploads.MaxAge)
+	}
+}
+
+func TestNewWithQuotas(t *testing.T) {
+	cfg, err := New(
+		WithAdminName("admin"),
+		WithAdminPwd([]byte("pwd")),
+		WithDataDir("mem://"),
+		WithQuotas([]quota.Quota{{Name: "all", MaxTags: 1}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q, ok := cfg.Data.(*quota.QuotaDataStorage)
+	if !ok {
+		t.Fatalf("expected *quota.QuotaDataStorage, got %T", cfg.Data)
+	}
+	if _, ok := q.Next.(*guard.GuardDataStorage); !ok {
+		t.Errorf("expected *guard.GuardDataStorage, got %T", q.Next)
+	}
+}
+
+func TestNewWithImmutableTags(t *testing.T) {
+	cfg, err := New(
+		WithAdminName("admin"),
+		WithAdminPwd([]byte("pwd")),
+		WithDataDir("mem://"),
+		WithImmutableTags([]immutable.Rule{{Name: "all"}}),
+		WithQuotas([]quota.Quota{{Name: "all", MaxTags: 1}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Quotas are checked before immutable tags.
+	q, ok := cfg.Data.(*quota.QuotaDataStorage)
+	if !ok {
+		t.Fatalf("expected *quota.QuotaDataStorage, got %T", cfg.Data)
+	}
+	if _, ok := q.Next.(*immutable.ImmutableDataStorage); !ok {
+		t.Errorf("expected *immutable.ImmutableDataStorage, got %T", q.Next)
+	}
+}
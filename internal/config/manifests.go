@@ -15,11 +15,18 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"regexp"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/jlsalvador/simple-registry/internal/data/immutable"
 	"github.com/jlsalvador/simple-registry/internal/data/proxy"
+	"github.com/jlsalvador/simple-registry/internal/data/quota"
+	"github.com/jlsalvador/simple-registry/internal/replication"
+	"github.com/jlsalvador/simple-registry/pkg/common"
 	"github.com/jlsalvador/simple-registry/pkg/rbac"
 	"github.com/jlsalvador/simple-registry/pkg/yamlscheme"
 )
@@ -58,7 +65,7 @@ type roleManifest struct {
 		Name string `json:"name" yaml:"name"`
 	} `json:"metadata" yaml:"metadata"`
 	Spec struct {
-		Resources []string `json:"resources" yaml:"resources"` // "catalog", "blobs", "manifests", "tags", or "*".
+		Resources []string `json:"resources" yaml:"resources"` // "catalog", "blobs", "manifests", "tags", "gc", "quotas", "replication", or "*".
 		Verbs     []string `json:"verbs" yaml:"verbs"`         // "HEAD", "GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "TRACE", or "*".
 	} `json:"spec" yaml:"spec"`
 }
@@ -94,9 +101,99 @@ type pullThroughCacheManifest struct {
 			Username     string        `json:"username" yaml:"username"`
 			Password     string        `json:"password" yaml:"password"`
 			PasswordFile string        `json:"passwordFile" yaml:"passwordFile"`
-			TTL          string        `json:"ttl" yaml:"ttl"`
+			CAFile       string        `json:"caFile" yaml:"caFile"`           // PEM bundle of the CAs trusted besides the system ones.
+			CertFile     string        `json:"certFile" yaml:"certFile"`       // PEM client certificate.
+			KeyFile      string        `json:"keyFile" yaml:"keyFile"`         // PEM client key.
+			HTTPProxy    string        `json:"httpProxy" yaml:"httpProxy"`     // From the environment if empty.
+			TTL          string        `json:"ttl" yaml:"ttl"`                 // Like "30d" or "1h". Disabled if empty.
+			NegativeTTL  string        `json:"negativeTtl" yaml:"negativeTtl"` // Like "30d" or "1h". Disabled if empty.
+			Mirrors      []struct {
+				URL          string `json:"url" yaml:"url"`
+				Priority     int    `json:"priority" yaml:"priority"` // Lowest first, the upstream url is zero.
+				Username     string `json:"username" yaml:"username"`
+				Password     string `json:"password" yaml:"password"`
+				PasswordFile string `json:"passwordFile" yaml:"passwordFile"`
+				CAFile       string `json:"caFile" yaml:"caFile"`
+				CertFile     string `json:"certFile" yaml:"certFile"`
+				KeyFile      string `json:"keyFile" yaml:"keyFile"`
+				HTTPProxy    string `json:"httpProxy" yaml:"httpProxy"`
+			} `json:"mirrors" yaml:"mirrors"`
+			HealthCheckInterval string `json:"healthCheckInterval" yaml:"healthCheckInterval"` // Like "30s". Disabled if empty.
 		}
-		Scopes []string `json:"scopes" yaml:"scopes"` // Regular expressions matching the repository path."
+		Scopes   []string `json:"scopes" yaml:"scopes"` // Regular expressions matching the repository path."
+		Policy   string   `json:"policy" yaml:"policy"` // "prefer-upstream" (default), "prefer-cache", or "cache-only".
+		Prefetch struct {
+			Interval string `json:"interval" yaml:"interval"` // Like "6h". Only on start if empty.
+			Images   []struct {
+				Repository string   `json:"repository" yaml:"repository"` // Local repository path.
+				Tags       []string `json:"tags" yaml:"tags"`             // Regular expressions matching the tags.
+			} `json:"images" yaml:"images"`
+		} `json:"prefetch" yaml:"prefetch"`
+		Rewrites []struct {
+			Regexp      string `json:"regexp" yaml:"regexp"`           // Regular expression matching the local repository path.
+			Replacement string `json:"replacement" yaml:"replacement"` // Like "library/$1".
+		} `json:"rewrites" yaml:"rewrites"`
+	} `json:"spec" yaml:"spec"`
+}
+
+type quotaManifest struct {
+	yamlscheme.CommonManifest
+
+	Metadata struct {
+		Name string `json:"name" yaml:"name"`
+	} `json:"metadata" yaml:"metadata"`
+	Spec struct {
+		Scopes   []string `json:"scopes" yaml:"scopes"`     // Regular expressions matching the repository path.
+		MaxBytes int64    `json:"maxBytes" yaml:"maxBytes"` // Unlimited if zero.
+		MaxTags  int      `json:"maxTags" yaml:"maxTags"`   // Unlimited if zero.
+	} `json:"spec" yaml:"spec"`
+}
+
+type immutableTagManifest struct {
+	yamlscheme.CommonManifest
+
+	Metadata struct {
+		Name string `json:"name" yaml:"name"`
+	} `json:"metadata" yaml:"metadata"`
+	Spec struct {
+		Scopes []string `json:"scopes" yaml:"scopes"` // Regular expressions matching the repository path.
+		Tags   []string `json:"tags" yaml:"tags"`     // Regular expressions matching the tag, every tag if empty.
+	} `json:"spec" yaml:"spec"`
+}
+
+type retentionPolicyManifest struct {
+	yamlscheme.CommonManifest
+
+	Metadata struct {
+		Name string `json:"name" yaml:"name"`
+	} `json:"metadata" yaml:"metadata"`
+	Spec struct {
+		Scopes      []string      `json:"scopes" yaml:"scopes"`           // Regular expressions matching the repository path.
+		KeepTags    []string      `json:"keepTags" yaml:"keepTags"`       // Regular expressions matching the tags to keep.
+		KeepLast    int           `json:"keepLast" yaml:"keepLast"`       // Disabled if zero.
+		ExpireAfter time.Duration `json:"expireAfter" yaml:"expireAfter"` // Disabled if zero.
+	} `json:"spec" yaml:"spec"`
+}
+
+type replicationManifest struct {
+	yamlscheme.CommonManifest
+
+	Metadata struct {
+		Name string `json:"name" yaml:"name"`
+	} `json:"metadata" yaml:"metadata"`
+	Spec struct {
+		Downstream struct {
+			URL          string        `json:"url" yaml:"url"`
+			Timeout      time.Duration `json:"timeout" yaml:"timeout"`
+			Username     string        `json:"username" yaml:"username"`
+			Password     string        `json:"password" yaml:"password"`
+			PasswordFile string        `json:"passwordFile" yaml:"passwordFile"`
+			CAFile       string        `json:"caFile" yaml:"caFile"`       // PEM bundle of the CAs trusted besides the system ones.
+			CertFile     string        `json:"certFile" yaml:"certFile"`   // PEM client certificate.
+			KeyFile      string        `json:"keyFile" yaml:"keyFile"`     // PEM client key.
+			HTTPProxy    string        `json:"httpProxy" yaml:"httpProxy"` // From the environment if empty.
+		} `json:"downstream" yaml:"downstream"`
+		Scopes []string `json:"scopes" yaml:"scopes"` // Regular expressions matching the repository path.
 	} `json:"spec" yaml:"spec"`
 }
 
@@ -109,6 +206,16 @@ type configurationManifest struct {
 	Spec struct {
 		DataDir string `json:"dataDir" yaml:"dataDir"`
 
+		S3 struct {
+			Endpoint            string `json:"endpoint" yaml:"endpoint"`
+			Region              string `json:"region" yaml:"region"`
+			Bucket              string `json:"bucket" yaml:"bucket"`
+			Prefix              string `json:"prefix" yaml:"prefix"` // Key prefix for every stored object.
+			AccessKeyID         string `json:"accessKeyId" yaml:"accessKeyId"`
+			SecretAccessKey     string `json:"secretAccessKey" yaml:"secretAccessKey"`
+			SecretAccessKeyFile string `json:"secretAccessKeyFile" yaml:"secretAccessKeyFile"`
+		} `json:"s3" yaml:"s3"`
+
 		Web struct {
 			Addr         string `json:"addr" yaml:"addr"`
 			TokenSecret  string `json:"tokenSecret" yaml:"tokenSecret"`
@@ -117,6 +224,17 @@ type configurationManifest struct {
 			CertFile     string `json:"certfile" yaml:"certfile"`
 			KeyFile      string `json:"keyfile" yaml:"keyfile"`
 		} `json:"web" yaml:"web"`
+
+		GarbageCollect struct {
+			Interval       time.Duration `json:"interval" yaml:"interval"` // Disabled if zero.
+			DryRun         bool          `json:"dryRun" yaml:"dryRun"`
+			DeleteUntagged bool          `json:"deleteUntagged" yaml:"deleteUntagged"`
+			LastAccess     time.Duration `json:"lastAccess" yaml:"lastAccess"`
+		} `json:"garbageCollect" yaml:"garbageCollect"`
+
+		Replication struct {
+			QueueDir string `json:"queueDir" yaml:"queueDir"` // Pending replications are kept in memory if empty.
+		} `json:"replication" yaml:"replication"`
 	} `json:"spec" yaml:"spec"`
 }
 
@@ -127,6 +245,10 @@ func init() {
 	yamlscheme.Register[roleBindingManifest](apiVersion, "RoleBinding")
 	yamlscheme.Register[pullThroughCacheManifest](apiVersion, "PullThroughCache")
 	yamlscheme.Register[configurationManifest](apiVersion, "Configuration")
+	yamlscheme.Register[quotaManifest](apiVersion, "Quota")
+	yamlscheme.Register[immutableTagManifest](apiVersion, "ImmutableTag")
+	yamlscheme.Register[retentionPolicyManifest](apiVersion, "RetentionPolicy")
+	yamlscheme.Register[replicationManifest](apiVersion, "Replication")
 }
 
 func getTokensUsersRolesRoleBindingsFromManifests(manifests []any) (
@@ -208,12 +330,118 @@ func getProxiesFromManifests(manifests []any) (proxies []proxy.Proxy, err error)
 				m.Spec.Upstream.Password = string(password)
 			}
 
+			ttl, err := common.ParseDuration(m.Spec.Upstream.TTL)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ttl of %q: %w", m.Metadata.Name, err)
+			}
+			negativeTTL, err := common.ParseDuration(m.Spec.Upstream.NegativeTTL)
+			if err != nil {
+				return nil, fmt.Errorf("invalid negativeTtl of %q: %w", m.Metadata.Name, err)
+			}
+
+			policy, err := proxy.ParsePolicy(m.Spec.Policy)
+			if err != nil {
+				return nil, fmt.Errorf("invalid policy of %q: %w", m.Metadata.Name, err)
+			}
+
+			healthCheckInterval, err := common.ParseDuration(m.Spec.Upstream.HealthCheckInterval)
+			if err != nil {
+				return nil, fmt.Errorf("invalid healthCheckInterval of %q: %w", m.Metadata.Name, err)
+			}
+
+			var mirrors []proxy.Mirror
+			for _, mirror := range m.Spec.Upstream.Mirrors {
+				if mirror.PasswordFile != "" {
+					password, err := os.ReadFile(mirror.PasswordFile)
+					if err != nil {
+						return nil, err
+					}
+					mirror.Password = string(password)
+				}
+				client, err := proxy.NewClient(proxy.ClientConfig{
+					Timeout:   m.Spec.Upstream.Timeout,
+					CAFile:    mirror.CAFile,
+					CertFile:  mirror.CertFile,
+					KeyFile:   mirror.KeyFile,
+					HTTPProxy: mirror.HTTPProxy,
+				})
+				if err != nil {
+					return nil, fmt.Errorf("invalid mirror %q of %q: %w", mirror.URL, m.Metadata.Name, err)
+				}
+				mirrors = append(mirrors, proxy.Mirror{
+					Url:      mirror.URL,
+					Username: mirror.Username,
+					Password: mirror.Password,
+					Client:   client,
+					Priority: mirror.Priority,
+				})
+			}
+
+			client, err := proxy.NewClient(proxy.ClientConfig{
+				Timeout:   m.Spec.Upstream.Timeout,
+				CAFile:    m.Spec.Upstream.CAFile,
+				CertFile:  m.Spec.Upstream.CertFile,
+				KeyFile:   m.Spec.Upstream.KeyFile,
+				HTTPProxy: m.Spec.Upstream.HTTPProxy,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("invalid upstream of %q: %w", m.Metadata.Name, err)
+			}
+
+			var rewrites []proxy.Rewrite
+			for _, rw := range m.Spec.Rewrites {
+				re, err := regexp.Compile(rw.Regexp)
+				if err != nil {
+					return nil, fmt.Errorf("invalid rewrite of %q: %w", m.Metadata.Name, err)
+				}
+				rewrites = append(rewrites, proxy.Rewrite{
+					Regexp:      *re,
+					Replacement: rw.Replacement,
+				})
+			}
+
+			prefetchInterval, err := common.ParseDuration(m.Spec.Prefetch.Interval)
+			if err != nil {
+				return nil, fmt.Errorf("invalid prefetch interval of %q: %w", m.Metadata.Name, err)
+			}
+
+			var prefetch []proxy.Prefetch
+			for _, image := range m.Spec.Prefetch.Images {
+				if !slices.ContainsFunc(m.Spec.Scopes, func(scope string) bool {
+					re, err := regexp.Compile(scope)
+					return err == nil && re.MatchString(image.Repository)
+				}) {
+					return nil, fmt.Errorf("prefetch of %q is out of the scopes of %q", image.Repository, m.Metadata.Name)
+				}
+
+				p := proxy.Prefetch{Repository: image.Repository}
+				for _, tag := range image.Tags {
+					re, err := regexp.Compile(tag)
+					if err != nil {
+						return nil, fmt.Errorf("invalid prefetch of %q: %w", m.Metadata.Name, err)
+					}
+					p.Tags = append(p.Tags, *re)
+				}
+				prefetch = append(prefetch, p)
+			}
+
 			proxies = append(proxies, proxy.Proxy{
-				Url:      m.Spec.Upstream.URL,
-				Timeout:  m.Spec.Upstream.Timeout,
-				Username: m.Spec.Upstream.Username,
-				Password: m.Spec.Upstream.Password,
-				Scopes:   m.Spec.Scopes,
+				Name:        m.Metadata.Name,
+				Url:         m.Spec.Upstream.URL,
+				Timeout:     m.Spec.Upstream.Timeout,
+				Username:    m.Spec.Upstream.Username,
+				Password:    m.Spec.Upstream.Password,
+				Scopes:      m.Spec.Scopes,
+				Client:      client,
+				TTL:         ttl,
+				NegativeTTL: negativeTTL,
+				Policy:      policy,
+
+				Mirrors:             mirrors,
+				Rewrites:            rewrites,
+				Prefetch:            prefetch,
+				PrefetchInterval:    prefetchInterval,
+				HealthCheckInterval: healthCheckInterval,
 			})
 		}
 	}
@@ -221,6 +449,116 @@ func getProxiesFromManifests(manifests []any) (proxies []proxy.Proxy, err error)
 	return
 }
 
+func getQuotasFromManifests(manifests []any) (quotas []quota.Quota, err error) {
+	for _, manifest := range manifests {
+		if m, ok := manifest.(*quotaManifest); ok {
+			q := quota.Quota{
+				Name:     m.Metadata.Name,
+				MaxBytes: m.Spec.MaxBytes,
+				MaxTags:  m.Spec.MaxTags,
+			}
+
+			for _, s := range m.Spec.Scopes {
+				var re *regexp.Regexp
+				re, err = regexp.Compile(s)
+				if err != nil {
+					return
+				}
+				q.Scopes = append(q.Scopes, *re)
+			}
+
+			quotas = append(quotas, q)
+		}
+	}
+
+	return
+}
+
+func getImmutableRulesFromManifests(manifests []any) (rules []immutable.Rule, err error) {
+	for _, manifest := range manifests {
+		if m, ok := manifest.(*immutableTagManifest); ok {
+			r := immutable.Rule{
+				Name: m.Metadata.Name,
+			}
+
+			for _, s := range m.Spec.Scopes {
+				var re *regexp.Regexp
+				re, err = regexp.Compile(s)
+				if err != nil {
+					return
+				}
+				r.Scopes = append(r.Scopes, *re)
+			}
+
+			for _, s := range m.Spec.Tags {
+				var re *regexp.Regexp
+				re, err = regexp.Compile(s)
+				if err != nil {
+					return
+				}
+				r.Tags = append(r.Tags, *re)
+			}
+
+			rules = append(rules, r)
+		}
+	}
+
+	return
+}
+
+func getReplicationTargetsFromManifests(manifests []any) (targets []replication.Target, err error) {
+	for _, manifest := range manifests {
+		if m, ok := manifest.(*replicationManifest); ok {
+			if m.Spec.Downstream.PasswordFile != "" {
+				var password []byte
+				password, err = os.ReadFile(m.Spec.Downstream.PasswordFile)
+				if err != nil {
+					return
+				}
+				m.Spec.Downstream.Password = strings.TrimSpace(string(password))
+			}
+
+			t := replication.Target{
+				Name:     m.Metadata.Name,
+				Url:      m.Spec.Downstream.URL,
+				Timeout:  m.Spec.Downstream.Timeout,
+				Username: m.Spec.Downstream.Username,
+				Password: m.Spec.Downstream.Password,
+
+				CAFile:    m.Spec.Downstream.CAFile,
+				CertFile:  m.Spec.Downstream.CertFile,
+				KeyFile:   m.Spec.Downstream.KeyFile,
+				HTTPProxy: m.Spec.Downstream.HTTPProxy,
+			}
+
+			for _, s := range m.Spec.Scopes {
+				var re *regexp.Regexp
+				re, err = regexp.Compile(s)
+				if err != nil {
+					return
+				}
+				t.Scopes = append(t.Scopes, *re)
+			}
+
+			targets = append(targets, t)
+		}
+	}
+
+	return
+}
+
+func getReplicationQueueDirFromManifests(manifests []any) (queueDir string) {
+	for _, manifest := range manifests {
+		if m, ok := manifest.(*configurationManifest); ok {
+			if m.Spec.Replication.QueueDir != "" {
+				queueDir = m.Spec.Replication.QueueDir
+			}
+		}
+	}
+
+	return
+}
+
 func getDataDirFromManifests(manifests []any) (dataDir string) {
 	for _, manifest := range manifests {
 		if m, ok := manifest.(*configurationManifest); ok {
@@ -233,6 +571,44 @@ func getDataDirFromManifests(manifests []any) (dataDir string) {
 	return
 }
 
+type s3Storage struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+func getS3FromManifests(manifests []any) (s3 s3Storage, err error) {
+	for _, manifest := range manifests {
+		if m, ok := manifest.(*configurationManifest); ok {
+			if m.Spec.S3.Bucket == "" {
+				continue
+			}
+
+			if m.Spec.S3.SecretAccessKeyFile != "" {
+				secret, err := os.ReadFile(m.Spec.S3.SecretAccessKeyFile)
+				if err != nil {
+					return s3Storage{}, err
+				}
+				m.Spec.S3.SecretAccessKey = strings.TrimSpace(string(secret))
+			}
+
+			s3 = s3Storage{
+				Endpoint:        m.Spec.S3.Endpoint,
+				Region:          m.Spec.S3.Region,
+				Bucket:          m.Spec.S3.Bucket,
+				Prefix:          m.Spec.S3.Prefix,
+				AccessKeyID:     m.Spec.S3.AccessKeyID,
+				SecretAccessKey: m.Spec.S3.SecretAccessKey,
+			}
+		}
+	}
+
+	return
+}
+
 func getWebFromManifests(manifests []any) (web Web) {
 	for _, manifest := range manifests {
 		if m, ok := manifest.(*configurationManifest); ok {
@@ -259,3 +635,58 @@ func getWebFromManifests(manifests []any) (web Web) {
 
 	return
 }
+
+func getGarbageCollectFromManifests(manifests []any) (gc GarbageCollect) {
+	for _, manifest := range manifests {
+		if m, ok := manifest.(*configurationManifest); ok {
+			if m.Spec.GarbageCollect.Interval != 0 {
+				gc.Interval = m.Spec.GarbageCollect.Interval
+			}
+			if m.Spec.GarbageCollect.DryRun {
+				gc.DryRun = m.Spec.GarbageCollect.DryRun
+			}
+			if m.Spec.GarbageCollect.DeleteUntagged {
+				gc.DeleteUntagged = m.Spec.GarbageCollect.DeleteUntagged
+			}
+			if m.Spec.GarbageCollect.LastAccess != 0 {
+				gc.LastAccess = m.Spec.GarbageCollect.LastAccess
+			}
+		}
+	}
+
+	return
+}
+
+func getRetentionPoliciesFromManifests(manifests []any) (policies []RetentionPolicy, err error) {
+	for _, manifest := range manifests {
+		if m, ok := manifest.(*retentionPolicyManifest); ok {
+			p := RetentionPolicy{
+				Name:        m.Metadata.Name,
+				KeepLast:    m.Spec.KeepLast,
+				ExpireAfter: m.Spec.ExpireAfter,
+			}
+
+			for _, s := range m.Spec.Scopes {
+				var re *regexp.Regexp
+				re, err = regexp.Compile(s)
+				if err != nil {
+					return
+				}
+				p.Scopes = append(p.Scopes, *re)
+			}
+
+			for _, s := range m.Spec.KeepTags {
+				var re *regexp.Regexp
+				re, err = regexp.Compile(s)
+				if err != nil {
+					return
+				}
+				p.KeepTags = append(p.KeepTags, *re)
+			}
+
+			policies = append(policies, p)
+		}
+	}
+
+	return
+}
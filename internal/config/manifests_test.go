@@ -18,9 +18,13 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/jlsalvador/simple-registry/internal/data/proxy"
 	"github.com/jlsalvador/simple-registry/pkg/rbac"
 	"github.com/jlsalvador/simple-registry/pkg/yamlscheme"
 )
@@ -231,6 +235,273 @@ spec:
 		}
 	})
 
+	t.Run("parse proxy with ttl", func(t *testing.T) {
+		data := `
+apiVersion: ` + apiVersion + `
+kind: PullThroughCache
+metadata:
+  name: cache
+spec:
+  upstream:
+    url: https://registry.example.com
+    ttl: 30d
+    negativeTtl: 5m
+  policy: prefer-cache
+`
+		m, err := yamlscheme.DecodeAll(strings.NewReader(data))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		proxies, err := getProxiesFromManifests(m)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(proxies) != 1 {
+			t.Fatalf("expected 1 proxy")
+		}
+		if proxies[0].TTL != 30*24*time.Hour || proxies[0].NegativeTTL != 5*time.Minute {
+			t.Fatalf("unexpected ttl %v and negative ttl %v", proxies[0].TTL, proxies[0].NegativeTTL)
+		}
+		if proxies[0].Policy != proxy.PolicyPreferCache {
+			t.Fatalf("expected policy %q, got %q", proxy.PolicyPreferCache, proxies[0].Policy)
+		}
+	})
+
+	t.Run("parse proxy with mirrors", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		pwdFile := filepath.Join(tmpDir, "pwd.txt")
+		os.WriteFile(pwdFile, []byte("mirrorpassword"), 0o644)
+
+		data := `
+apiVersion: ` + apiVersion + `
+kind: PullThroughCache
+metadata:
+  name: cache
+spec:
+  upstream:
+    url: https://registry.example.com
+    healthCheckInterval: 30s
+    mirrors:
+      - url: https://mirror.example.com
+        priority: 10
+        username: user1
+        passwordFile: ` + pwdFile + `
+`
+		m, err := yamlscheme.DecodeAll(strings.NewReader(data))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		proxies, err := getProxiesFromManifests(m)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(proxies) != 1 {
+			t.Fatalf("expected 1 proxy")
+		}
+		if proxies[0].Name != "cache" || proxies[0].HealthCheckInterval != 30*time.Second {
+			t.Fatalf("unexpected name %q and health check interval %v", proxies[0].Name, proxies[0].HealthCheckInterval)
+		}
+		want := []proxy.Mirror{{
+			Url:      "https://mirror.example.com",
+			Username: "user1",
+			Password: "mirrorpassword",
+			Priority: 10,
+		}}
+		mirrors := slices.Clone(proxies[0].Mirrors)
+		for i := range mirrors {
+			if mirrors[i].Client == nil {
+				t.Fatalf("expected a client for mirror %q", mirrors[i].Url)
+			}
+			mirrors[i].Client = nil
+		}
+		if !slices.Equal(mirrors, want) {
+			t.Fatalf("expected mirrors %+v, got %+v", want, mirrors)
+		}
+	})
+
+	t.Run("parse proxy with invalid ca file", func(t *testing.T) {
+		data := `
+apiVersion: ` + apiVersion + `
+kind: PullThroughCache
+metadata:
+  name: cache
+spec:
+  upstream:
+    url: https://registry.example.com
+    caFile: /does/not/exist.pem
+`
+		m, err := yamlscheme.DecodeAll(strings.NewReader(data))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err = getProxiesFromManifests(m); err == nil {
+			t.Fatal("expected error reading non-existent ca file")
+		}
+	})
+
+	t.Run("parse proxy with rewrites", func(t *testing.T) {
+		data := `
+apiVersion: ` + apiVersion + `
+kind: PullThroughCache
+metadata:
+  name: cache
+spec:
+  upstream:
+    url: https://registry.example.com
+  scopes: ["^dockerhub/.+$"]
+  rewrites:
+    - regexp: "^dockerhub/([^/]+)$"
+      replacement: library/$1
+`
+		m, err := yamlscheme.DecodeAll(strings.NewReader(data))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		proxies, err := getProxiesFromManifests(m)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(proxies) != 1 || len(proxies[0].Rewrites) != 1 {
+			t.Fatalf("expected 1 proxy with 1 rewrite")
+		}
+		rw := proxies[0].Rewrites[0]
+		if got := rw.Regexp.ReplaceAllString("dockerhub/nginx", rw.Replacement); got != "library/nginx" {
+			t.Fatalf("expected %q, got %q", "library/nginx", got)
+		}
+	})
+
+	t.Run("parse proxy with invalid rewrite", func(t *testing.T) {
+		data := `
+apiVersion: ` + apiVersion + `
+kind: PullThroughCache
+metadata:
+  name: cache
+spec:
+  upstream:
+    url: https://registry.example.com
+  rewrites:
+    - regexp: "^dockerhub/(.+"
+      replacement: library/$1
+`
+		m, err := yamlscheme.DecodeAll(strings.NewReader(data))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err = getProxiesFromManifests(m); err == nil {
+			t.Fatal("expected error parsing an invalid rewrite")
+		}
+	})
+
+	t.Run("parse proxy with prefetch", func(t *testing.T) {
+		data := `
+apiVersion: ` + apiVersion + `
+kind: PullThroughCache
+metadata:
+  name: cache
+spec:
+  upstream:
+    url: https://registry.example.com
+  scopes: ["^library/.+$"]
+  prefetch:
+    interval: 6h
+    images:
+      - repository: library/nginx
+        tags: ["^1\\.27\\.", "^latest$"]
+`
+		m, err := yamlscheme.DecodeAll(strings.NewReader(data))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		proxies, err := getProxiesFromManifests(m)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(proxies) != 1 || len(proxies[0].Prefetch) != 1 {
+			t.Fatalf("expected 1 proxy with 1 prefetch")
+		}
+		if proxies[0].PrefetchInterval != 6*time.Hour {
+			t.Fatalf("expected prefetch interval 6h, got %v", proxies[0].PrefetchInterval)
+		}
+		p := proxies[0].Prefetch[0]
+		if p.Repository != "library/nginx" || len(p.Tags) != 2 ||
+			!p.Tags[0].MatchString("1.27.3") || p.Tags[0].MatchString("1.28.0") {
+			t.Fatalf("unexpected prefetch %+v", p)
+		}
+	})
+
+	t.Run("parse proxy with prefetch out of scope", func(t *testing.T) {
+		data := `
+apiVersion: ` + apiVersion + `
+kind: PullThroughCache
+metadata:
+  name: cache
+spec:
+  upstream:
+    url: https://registry.example.com
+  scopes: ["^library/.+$"]
+  prefetch:
+    images:
+      - repository: bitnami/nginx
+        tags: ["^latest$"]
+`
+		m, err := yamlscheme.DecodeAll(strings.NewReader(data))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err = getProxiesFromManifests(m); err == nil {
+			t.Fatal("expected error with a prefetch out of the scopes")
+		}
+	})
+
+	t.Run("parse proxy with invalid policy", func(t *testing.T) {
+		data := `
+apiVersion: ` + apiVersion + `
+kind: PullThroughCache
+metadata:
+  name: cache
+spec:
+  upstream:
+    url: https://registry.example.com
+  policy: offline
+`
+		m, err := yamlscheme.DecodeAll(strings.NewReader(data))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err = getProxiesFromManifests(m); !errors.Is(err, proxy.ErrPolicyInvalid) {
+			t.Fatalf("expected ErrPolicyInvalid, got %v", err)
+		}
+	})
+
+	t.Run("parse proxy with invalid ttl", func(t *testing.T) {
+		data := `
+apiVersion: ` + apiVersion + `
+kind: PullThroughCache
+metadata:
+  name: cache
+spec:
+  upstream:
+    url: https://registry.example.com
+    ttl: forever
+`
+		m, err := yamlscheme.DecodeAll(strings.NewReader(data))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err = getProxiesFromManifests(m); err == nil {
+			t.Fatal("expected error parsing an invalid ttl")
+		}
+	})
+
 	t.Run("parse proxy with invalid password file", func(t *testing.T) {
 		data := `
 apiVersion: ` + apiVersion + `
@@ -252,3 +523,275 @@ spec:
 		}
 	})
 }
+
+func TestGetS3FromManifests(t *testing.T) {
+	t.Run("parse s3 with secret access key file", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		secretFile := filepath.Join(tmpDir, "secret.txt")
+		os.WriteFile(secretFile, []byte("filesecret\n"), 0o644)
+
+		data := `
+apiVersion: ` + apiVersion + `
+kind: Configuration
+metadata:
+  name: config
+spec:
+  s3:
+    endpoint: http://localhost:9000
+    region: eu-west-1
+    bucket: registry
+    prefix: replicas
+    accessKeyId: minio
+    secretAccessKeyFile: ` + secretFile + `
+`
+		m, err := yamlscheme.DecodeAll(strings.NewReader(data))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		s3, err := getS3FromManifests(m)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := s3Storage{
+			Endpoint:        "http://localhost:9000",
+			Region:          "eu-west-1",
+			Bucket:          "registry",
+			Prefix:          "replicas",
+			AccessKeyID:     "minio",
+			SecretAccessKey: "filesecret",
+		}
+		if s3 != want {
+			t.Fatalf("expected %+v, got %+v", want, s3)
+		}
+	})
+
+	t.Run("parse configuration without s3", func(t *testing.T) {
+		data := `
+apiVersion: ` + apiVersion + `
+kind: Configuration
+metadata:
+  name: config
+spec:
+  dataDir: /var/lib/registry
+`
+		m, err := yamlscheme.DecodeAll(strings.NewReader(data))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		s3, err := getS3FromManifests(m)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if s3.Bucket != "" {
+			t.Fatalf("expected no bucket, got %q", s3.Bucket)
+		}
+	})
+}
+
+func TestGetGarbageCollectFromManifests(t *testing.T) {
+	data := `
+apiVersion: ` + apiVersion + `
+kind: Configuration
+metadata:
+  name: config
+spec:
+  garbageCollect:
+    interval: 6h
+    dryRun: true
+    deleteUntagged: true
+    lastAccess: 48h
+`
+	m, err := yamlscheme.DecodeAll(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	gc := getGarbageCollectFromManifests(m)
+	want := GarbageCollect{
+		Interval:       6 * time.Hour,
+		DryRun:         true,
+		DeleteUntagged: true,
+		LastAccess:     48 * time.Hour,
+	}
+	if !reflect.DeepEqual(gc, want) {
+		t.Fatalf("expected %+v, got %+v", want, gc)
+	}
+}
+
+func TestGetQuotasFromManifests(t *testing.T) {
+	data := `
+apiVersion: ` + apiVersion + `
+kind: Quota
+metadata:
+  name: team-a
+spec:
+  scopes:
+  - ^team-a/.+$
+  maxBytes: 1073741824
+  maxTags: 100
+`
+	m, err := yamlscheme.DecodeAll(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	quotas, err := getQuotasFromManifests(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(quotas) != 1 {
+		t.Fatalf("expected 1 quota, got %d", len(quotas))
+	}
+	q := quotas[0]
+	if q.Name != "team-a" || q.MaxBytes != 1073741824 || q.MaxTags != 100 {
+		t.Errorf("unexpected quota %+v", q)
+	}
+	if len(q.Scopes) != 1 || !q.Match("team-a/app") || q.Match("team-b/app") {
+		t.Errorf("unexpected scopes %v", q.Scopes)
+	}
+}
+
+func TestGetQuotasFromManifestsInvalidScope(t *testing.T) {
+	data := `
+apiVersion: ` + apiVersion + `
+kind: Quota
+metadata:
+  name: invalid
+spec:
+  scopes:
+  - "["
+`
+	m, err := yamlscheme.DecodeAll(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := getQuotasFromManifests(m); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestGetImmutableRulesFromManifests(t *testing.T) {
+	data := `
+apiVersion: ` + apiVersion + `
+kind: ImmutableTag
+metadata:
+  name: releases
+spec:
+  scopes:
+  - ^team-a/.+$
+  tags:
+  - ^v[0-9]+\.[0-9]+\.[0-9]+$
+`
+	m, err := yamlscheme.DecodeAll(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rules, err := getImmutableRulesFromManifests(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 1 || rules[0].Name != "releases" {
+		t.Fatalf("unexpected rules %+v", rules)
+	}
+	r := rules[0]
+	if !r.Match("team-a/app", "v1.2.3") || r.Match("team-a/app", "latest") || r.Match("team-b/app", "v1.2.3") {
+		t.Errorf("unexpected rule matching %+v", r)
+	}
+}
+
+func TestGetRetentionPoliciesFromManifests(t *testing.T) {
+	data := `
+apiVersion: ` + apiVersion + `
+kind: RetentionPolicy
+metadata:
+  name: ci
+spec:
+  scopes:
+  - ^team-a/.+$
+  keepTags:
+  - ^latest$
+  keepLast: 10
+  expireAfter: 720h
+`
+	m, err := yamlscheme.DecodeAll(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	policies, err := getRetentionPoliciesFromManifests(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(policies) != 1 {
+		t.Fatalf("expected 1 policy, got %d", len(policies))
+	}
+	p := policies[0]
+	if p.Name != "ci" || p.KeepLast != 10 || p.ExpireAfter != 720*time.Hour {
+		t.Errorf("unexpected policy %+v", p)
+	}
+	if len(p.Scopes) != 1 || p.Scopes[0].String() != "^team-a/.+$" {
+		t.Errorf("unexpected scopes %v", p.Scopes)
+	}
+	if len(p.KeepTags) != 1 || p.KeepTags[0].String() != "^latest$" {
+		t.Errorf("unexpected keep tags %v", p.KeepTags)
+	}
+}
+
+func TestGetReplicationFromManifests(t *testing.T) {
+	tmpDir := t.TempDir()
+	pwdFile := filepath.Join(tmpDir, "pwd.txt")
+	os.WriteFile(pwdFile, []byte("filepassword\n"), 0o644)
+
+	data := `
+apiVersion: ` + apiVersion + `
+kind: Replication
+metadata:
+  name: site-b
+spec:
+  downstream:
+    url: https://registry-b.example.com
+    timeout: 30s
+    username: replicator
+    passwordFile: ` + pwdFile + `
+  scopes:
+  - ^team-a/.+$
+---
+apiVersion: ` + apiVersion + `
+kind: Configuration
+metadata:
+  name: cfg
+spec:
+  replication:
+    queueDir: /var/lib/simple-registry/replication
+`
+	m, err := yamlscheme.DecodeAll(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	targets, err := getReplicationTargetsFromManifests(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(targets) != 1 {
+		t.Fatalf("expected 1 target, got %d", len(targets))
+	}
+	target := targets[0]
+	if target.Name != "site-b" || target.Url != "https://registry-b.example.com" || target.Timeout != 30*time.Second {
+		t.Errorf("unexpected target %+v", target)
+	}
+	if target.Username != "replicator" || target.Password != "filepassword" {
+		t.Errorf("unexpected credentials %q/%q", target.Username, target.Password)
+	}
+	if !target.Match("team-a/app") || target.Match("team-b/app") {
+		t.Errorf("unexpected scopes %v", target.Scopes)
+	}
+
+	if dir := getReplicationQueueDirFromManifests(m); dir != "/var/lib/simple-registry/replication" {
+		t.Errorf("expected queue dir, got %q", dir)
+	}
+}
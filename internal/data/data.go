@@ -17,9 +17,17 @@ package data
 import (
 	"io"
 	"iter"
+	"slices"
 	"time"
 )
 
+// Upload is a blob upload session in progress.
+type Upload struct {
+	Repo      string
+	UUID      string
+	StartedAt time.Time
+}
+
 type DataStorage interface {
 	// BlobsGet retrieves a blob from the storage.
 	//
@@ -28,12 +36,24 @@ type DataStorage interface {
 	BlobsDelete(repo, digest string) error
 	BlobsList() (digests iter.Seq[string], err error)
 	BlobLastAccess(digest string) (lastAccess time.Time, err error)
+	// BlobRepositories returns the repositories linking the blob. They are
+	// read from an index kept along the links, without scanning every
+	// repository.
+	BlobRepositories(digest string) (repos []string, err error)
+	// BlobsLink links a stored blob to the repository, as if it had been
+	// uploaded to it, without copying its content.
+	BlobsLink(repo, digest string) error
 
 	BlobsUploadCreate(repo string) (uuid string, err error)
 	BlobsUploadCancel(repo, uuid string) error
 	BlobsUploadWrite(repo, uuid string, r io.Reader, start int64) error
 	BlobsUploadCommit(repo, uuid, digest string) error
 	BlobsUploadSize(repo, uuid string) (size int64, err error)
+	// BlobsUploadStartedAt returns when the upload session was created.
+	BlobsUploadStartedAt(repo, uuid string) (startedAt time.Time, err error)
+	// BlobsUploadsList returns the upload sessions in progress of every
+	// repository.
+	BlobsUploadsList() (uploads iter.Seq[Upload], err error)
 
 	ManifestPut(repo, reference string, r io.Reader) (digest string, err error)
 	ManifestGet(repo, reference string) (r io.ReadCloser, size int64, digest string, err error)
@@ -42,8 +62,15 @@ type DataStorage interface {
 	ManifestLastAccess(digest string) (lastAccess time.Time, err error)
 
 	TagsList(repo string) ([]string, error)
+	// TagLastModified returns when the tag was pushed for the last time.
+	TagLastModified(repo, tag string) (lastModified time.Time, err error)
 
 	RepositoriesList() ([]string, error)
+	// RepositoryDelete removes the tags, manifests, referrers, layer links and
+	// upload sessions of the repository. Blobs are kept until the garbage
+	// collector removes the unreferenced ones, and so are the repositories
+	// nested under it.
+	RepositoryDelete(repo string) error
 
 	// ReferrersGet returns an iter of referrer digests for the given manifest
 	// digest.
@@ -52,3 +79,41 @@ type DataStorage interface {
 		manifestDigest string,
 	) (digests iter.Seq[string], err error)
 }
+
+// TagsPager is implemented by the data storages listing the tags of a
+// repository by pages, like the pull-through cache does with its upstream.
+type TagsPager interface {
+	// TagsListPage returns, sorted, up to n tags of the repository after
+	// last. n is unlimited if negative, and last is ignored if empty.
+	TagsListPage(repo string, n int, last string) ([]string, error)
+}
+
+// TagsListPage returns, sorted, up to n tags of the repository after last,
+// asking ds for that page if it is a [TagsPager].
+func TagsListPage(ds DataStorage, repo string, n int, last string) ([]string, error) {
+	if p, ok := ds.(TagsPager); ok {
+		return p.TagsListPage(repo, n, last)
+	}
+
+	tags, err := ds.TagsList(repo)
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(tags)
+	return PageTags(tags, n, last), nil
+}
+
+// PageTags returns up to n of the sorted tags after last.
+func PageTags(sorted []string, n int, last string) []string {
+	if last != "" {
+		i, found := slices.BinarySearch(sorted, last)
+		if found {
+			i++
+		}
+		sorted = sorted[i:]
+	}
+	if n >= 0 && n < len(sorted) {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
@@ -21,3 +21,7 @@ var ErrUUIDInvalid = errors.New("uuid is not valid")
 var ErrHashShort = errors.New("hash is too short")
 var ErrDigestInvalid = errors.New("digest is not valid")
 var ErrDigestMismatch = errors.New("digest mismatch")
+var ErrStorageFull = errors.New("storage is full")
+var ErrQuotaExceeded = errors.New("quota exceeded")
+var ErrTagImmutable = errors.New("tag is immutable")
+var ErrTagInvalid = errors.New("tag is not valid")
@@ -0,0 +1,82 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// For testing mockups, to inject faults while writing.
+var (
+	createTempFn = os.CreateTemp
+	renameFn     = os.Rename
+	syncFn       = (*os.File).Sync
+)
+
+// writeFileAtomic replaces the file at path with b, so a crash leaves either
+// the previous content or the new one, but never a partial file.
+//
+// The content is written to a temporary file in the same directory, synced,
+// and renamed over path. Then the directory is synced, so the rename survives
+// a power loss.
+func writeFileAtomic(path string, b []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+
+	f, err := createTempFn(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	if err := writeAndSync(f, b, perm); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := renameFn(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+func writeAndSync(f *os.File, b []byte, perm os.FileMode) error {
+	if _, err := f.Write(b); err != nil {
+		return err
+	}
+	if err := f.Chmod(perm); err != nil {
+		return err
+	}
+	return syncFn(f)
+}
+
+// syncDir flushes the entries of the directory, like the files renamed into
+// it.
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return syncFn(f)
+}
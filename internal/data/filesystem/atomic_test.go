@@ -0,0 +1,246 @@
+// Copyright 2026 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystem
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	d "github.com/jlsalvador/simple-registry/pkg/digest"
+)
+
+var errFault = errors.New("injected fault")
+
+// mockFaults restores the filesystem operations after the test.
+func mockFaults(t *testing.T) {
+	t.Helper()
+
+	createTemp, rename, sync := createTempFn, renameFn, syncFn
+	t.Cleanup(func() {
+		createTempFn, renameFn, syncFn = createTemp, rename, sync
+	})
+}
+
+func readDirNames(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	mockFaults(t)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "link")
+
+	var synced []string
+	syncFn = func(f *os.File) error {
+		synced = append(synced, f.Name())
+		return f.Sync()
+	}
+
+	if err := writeFileAtomic(path, []byte("sha256:abc"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil || string(b) != "sha256:abc" {
+		t.Errorf("expected sha256:abc, got %q (%v)", b, err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil || fi.Mode().Perm() != 0o644 {
+		t.Errorf("expected 0644, got %v (%v)", fi.Mode().Perm(), err)
+	}
+	if names := readDirNames(t, dir); !slices.Equal(names, []string{"link"}) {
+		t.Errorf("expected only the link, got %v", names)
+	}
+
+	// The temporary file is synced before the rename, and the directory after.
+	if len(synced) != 2 || !strings.Contains(synced[0], ".link.tmp-") || synced[1] != dir {
+		t.Errorf("expected the temporary file and the directory synced, got %v", synced)
+	}
+}
+
+func TestWriteFileAtomic_Faults(t *testing.T) {
+	tests := []struct {
+		name  string
+		fault func()
+	}{
+		{
+			name: "create temp",
+			fault: func() {
+				createTempFn = func(string, string) (*os.File, error) { return nil, errFault }
+			},
+		},
+		{
+			name: "sync",
+			fault: func() {
+				syncFn = func(*os.File) error { return errFault }
+			},
+		},
+		{
+			name: "rename",
+			fault: func() {
+				renameFn = func(string, string) error { return errFault }
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockFaults(t)
+
+			dir := t.TempDir()
+			path := filepath.Join(dir, "link")
+			if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			tt.fault()
+
+			if err := writeFileAtomic(path, []byte("new"), 0o644); !errors.Is(err, errFault) {
+				t.Errorf("expected %v, got %v", errFault, err)
+			}
+
+			// The previous content is kept, and nothing is left behind.
+			if b, err := os.ReadFile(path); err != nil || string(b) != "old" {
+				t.Errorf("expected old, got %q (%v)", b, err)
+			}
+			if names := readDirNames(t, dir); !slices.Equal(names, []string{"link"}) {
+				t.Errorf("expected only the link, got %v", names)
+			}
+		})
+	}
+}
+
+func TestManifestPut_TagLinkFault(t *testing.T) {
+	mockFaults(t)
+
+	s := NewFilesystemDataStorage(t.TempDir())
+
+	old, err := s.ManifestPut("repo", "latest", strings.NewReader(`{"schemaVersion":2}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Crash while updating the tag.
+	renameFn = func(oldpath, newpath string) error {
+		if strings.HasSuffix(newpath, filepath.Join("tags", "latest", "current", "link")) {
+			return errFault
+		}
+		return os.Rename(oldpath, newpath)
+	}
+
+	if _, err := s.ManifestPut("repo", "latest", strings.NewReader(`{"schemaVersion":2,"new":true}`)); !errors.Is(err, errFault) {
+		t.Errorf("expected %v, got %v", errFault, err)
+	}
+
+	// The tag still points at the previous manifest.
+	r, _, dgst, err := s.ManifestGet("repo", "latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	if dgst != old {
+		t.Errorf("expected %s, got %s", old, dgst)
+	}
+}
+
+func TestBlobsUploadCommit_ReplaceInPlace(t *testing.T) {
+	mockFaults(t)
+
+	base := t.TempDir()
+	s := NewFilesystemDataStorage(base)
+
+	hasher, _ := d.NewHasher("sha256")
+	hasher.Write([]byte("hello"))
+	hash := hasher.GetHashAsString()
+	dgst := "sha256:" + hash
+	blobPath := filepath.Join(base, "blobs", "sha256", hash[0:2], hash)
+
+	upload := func() error {
+		uuid, err := s.BlobsUploadCreate("repo")
+		if err != nil {
+			return err
+		}
+		if err := s.BlobsUploadWrite("repo", uuid, bytes.NewBufferString("hello"), -1); err != nil {
+			return err
+		}
+		return s.BlobsUploadCommit("repo", uuid, dgst)
+	}
+
+	if err := upload(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The blob is never missing while committing it again.
+	renameFn = func(oldpath, newpath string) error {
+		if newpath == blobPath {
+			if _, err := os.Stat(blobPath); err != nil {
+				t.Errorf("expected blob present before the rename, got %v", err)
+			}
+		}
+		return os.Rename(oldpath, newpath)
+	}
+	if err := upload(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nor after a failed commit.
+	renameFn = func(oldpath, newpath string) error {
+		if newpath == blobPath {
+			return errFault
+		}
+		return os.Rename(oldpath, newpath)
+	}
+	if err := upload(); !errors.Is(err, errFault) {
+		t.Errorf("expected %v, got %v", errFault, err)
+	}
+
+	r, _, err := s.BlobsGet("repo", dgst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if b, err := io.ReadAll(r); err != nil || string(b) != "hello" {
+		t.Errorf("expected hello, got %q (%v)", b, err)
+	}
+}
+
+func TestBlobsUploadCreate_StartedAtFault(t *testing.T) {
+	mockFaults(t)
+
+	s := NewFilesystemDataStorage(t.TempDir())
+
+	syncFn = func(*os.File) error { return errFault }
+
+	if _, err := s.BlobsUploadCreate("repo"); !errors.Is(err, errFault) {
+		t.Errorf("expected %v, got %v", errFault, err)
+	}
+}
@@ -19,15 +19,34 @@ import (
 	"fmt"
 	"io"
 	"iter"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"syscall"
 	"time"
 
 	"github.com/jlsalvador/simple-registry/internal/data"
 	d "github.com/jlsalvador/simple-registry/pkg/digest"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
 )
 
+// blobRepositoriesDir returns the directory indexing the repositories linking
+// the blob, with one empty file per repository:
+// _blob_repositories/<algo>/<hex[:2]>/<hex>/<escaped repo>
+func (s *FilesystemDataStorage) blobRepositoriesDir(algo, hash string) string {
+	return filepath.Join(s.base, "_blob_repositories", algo, hash[:2], hash)
+}
+
+// indexBlobRepository records that repo links the blob.
+func (s *FilesystemDataStorage) indexBlobRepository(repo, algo, hash string) error {
+	dir := s.blobRepositoriesDir(algo, hash)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, url.PathEscape(repo)), nil, 0o644)
+}
+
 func (s *FilesystemDataStorage) BlobsGet(repo, digest string) (r io.ReadCloser, size int64, err error) {
 	algo, hash, err := d.Parse(digest)
 	if err != nil {
@@ -106,6 +125,13 @@ func (s *FilesystemDataStorage) BlobsDelete(repo, digest string) error {
 		if err := os.RemoveAll(linkPath); err != nil {
 			return err
 		}
+		indexPath := filepath.Join(
+			s.blobRepositoriesDir(algo, hash),
+			url.PathEscape(repo),
+		)
+		if err := os.Remove(indexPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
 
 		//TODO: Trigger garbage collect for unused blobs.
 		return nil
@@ -128,7 +154,7 @@ func (s *FilesystemDataStorage) BlobsDelete(repo, digest string) error {
 			return err
 		}
 
-		return nil
+		return os.RemoveAll(s.blobRepositoriesDir(algo, hash))
 	}
 }
 
@@ -195,3 +221,71 @@ func (s *FilesystemDataStorage) BlobLastAccess(digest string) (lastAccess time.T
 
 	return time.Unix(fis.Atim.Sec, fis.Atim.Nsec), nil
 }
+
+func (s *FilesystemDataStorage) BlobsLink(repo, digest string) error {
+	if !registry.RegExprName.MatchString(repo) {
+		return data.ErrRepoInvalid
+	}
+
+	algo, hash, err := d.Parse(digest)
+	if err != nil {
+		return err
+	}
+
+	if len(hash) < 2 {
+		return data.ErrHashShort
+	}
+
+	blobPath := filepath.Join(s.base, "blobs", algo, hash[0:2], hash)
+	if _, err := os.Stat(blobPath); err != nil {
+		return fmt.Errorf("cannot find blob %s: %w", blobPath, err)
+	}
+
+	return s.linkBlob(repo, algo, hash, digest)
+}
+
+// BlobRepositories returns the repositories indexed as linking the blob.
+//
+// Index entries whose repository link is gone, e.g. because the repository
+// was removed from disk by hand, are skipped.
+func (s *FilesystemDataStorage) BlobRepositories(digest string) (repos []string, err error) {
+	algo, hash, err := d.Parse(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(hash) < 2 {
+		return nil, data.ErrHashShort
+	}
+
+	entries, err := os.ReadDir(s.blobRepositoriesDir(algo, hash))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	repos = []string{}
+	for _, e := range entries {
+		repo, err := url.PathUnescape(e.Name())
+		if err != nil {
+			continue
+		}
+
+		linkPath := filepath.Join(
+			s.base,
+			"repositories",
+			repo,
+			"_layers",
+			algo,
+			hash,
+			"link",
+		)
+		if _, err := os.Stat(linkPath); err != nil {
+			continue
+		}
+
+		repos = append(repos, repo)
+	}
+	slices.Sort(repos)
+
+	return repos, nil
+}
@@ -15,9 +15,13 @@
 package filesystem
 
 import (
+	"errors"
 	"io"
+	"io/fs"
+	"iter"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
 	"github.com/jlsalvador/simple-registry/internal/data"
@@ -42,7 +46,7 @@ func (s *FilesystemDataStorage) BlobsUploadCreate(repo string) (uuid string, err
 
 	// Store metadata like "startedat".
 	startedAt := time.Now().UTC().Format(time.RFC3339Nano)
-	if err := os.WriteFile(filepath.Join(uploadDir, "startedat"), []byte(startedAt), 0o644); err != nil {
+	if err := writeFileAtomic(filepath.Join(uploadDir, "startedat"), []byte(startedAt), 0o644); err != nil {
 		return "", err
 	}
 
@@ -53,6 +57,15 @@ func (s *FilesystemDataStorage) BlobsUploadCreate(repo string) (uuid string, err
 	}
 	f.Close()
 
+	// Start the running hash of the upload.
+	h, err := d.NewHasher(d.Canonical)
+	if err != nil {
+		return "", err
+	}
+	if err := writeUploadHash(uploadDir, d.Canonical, h, 0); err != nil {
+		return "", err
+	}
+
 	return uuid, nil
 }
 
@@ -84,25 +97,57 @@ func (s *FilesystemDataStorage) BlobsUploadWrite(repo, uuid string, r io.Reader,
 		return data.ErrUUIDInvalid
 	}
 
-	uploadFile := filepath.Join(s.base, "repositories", repo, "_uploads", uuid, "data")
+	uploadDir := filepath.Join(s.base, "repositories", repo, "_uploads", uuid)
+	uploadFile := filepath.Join(uploadDir, "data")
 
-	f, err := os.OpenFile(uploadFile, os.O_WRONLY, 0o644)
+	f, err := os.OpenFile(uploadFile, os.O_RDWR, 0o644)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	if start < 0 {
-		_, err = f.Seek(0, io.SeekEnd)
-	} else {
-		_, err = f.Seek(start, io.SeekStart)
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	size := fi.Size()
+
+	if start >= 0 && start != size {
+		// Out of order writes invalidate the running hash before touching
+		// the data, so the commit hashes the whole upload.
+		if err := os.Remove(uploadHashPath(uploadDir)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		if _, err := f.Seek(start, io.SeekStart); err != nil {
+			return err
+		}
+		_, err = io.Copy(f, r)
+		return err
+	}
+
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		return err
 	}
+
+	h, offset, ok := readUploadHash(uploadDir, d.Canonical, size)
+	if !ok {
+		_, err = io.Copy(f, r)
+		return err
+	}
+
+	// Catch up with the data of interrupted writes.
+	if _, err := io.Copy(h, io.NewSectionReader(f, offset, size-offset)); err != nil {
+		return err
+	}
+
+	n, err := io.Copy(io.MultiWriter(f, h), r)
 	if err != nil {
+		// The stored state is behind the data, so the next write catches up.
 		return err
 	}
 
-	_, err = io.Copy(f, r)
-	return err
+	return writeUploadHash(uploadDir, d.Canonical, h, size+n)
 }
 
 func blobsUploadCommit(
@@ -119,19 +164,37 @@ func blobsUploadCommit(
 		return data.ErrUUIDInvalid
 	}
 
-	uploadFile := filepath.Join(s.base, "repositories", repo, "_uploads", uuid, "data")
+	uploadDir := filepath.Join(s.base, "repositories", repo, "_uploads", uuid)
+	uploadFile := filepath.Join(uploadDir, "data")
 	f, err := os.OpenFile(uploadFile, os.O_RDONLY, 0o644)
 	if err != nil {
 		return err
 	}
 
-	// Calculate the digest of the uploaded file.
-	hasher, err := d.NewHasher(algo)
+	fi, err := f.Stat()
 	if err != nil {
 		f.Close()
 		return err
 	}
-	if _, err := io.Copy(hasher, f); err != nil {
+	size := fi.Size()
+
+	// Calculate the digest of the uploaded file, resuming the running hash
+	// if possible, so only the data not hashed yet is read.
+	hasher, offset, ok := readUploadHash(uploadDir, algo, size)
+	if !ok {
+		if hasher, err = d.NewHasher(algo); err != nil {
+			f.Close()
+			return err
+		}
+		offset = 0
+	}
+	if _, err := io.Copy(hasher, io.NewSectionReader(f, offset, size-offset)); err != nil {
+		f.Close()
+		return err
+	}
+
+	// Flush the uploaded data before it becomes the blob.
+	if err := syncFn(f); err != nil {
 		f.Close()
 		return err
 	}
@@ -142,13 +205,16 @@ func blobsUploadCommit(
 		return data.ErrDigestMismatch
 	}
 
-	// Replace existing blob atomically.
+	// Replace existing blob atomically. The rename replaces it in place, so
+	// the blob is never missing for the clients reading it.
 	blobPath := filepath.Join(s.base, "blobs", algo, hash[0:2], hash)
 	if err := os.MkdirAll(filepath.Dir(blobPath), 0o755); err != nil {
 		return err
 	}
-	_ = os.Remove(blobPath) // ignore error
-	if err := os.Rename(uploadFile, blobPath); err != nil {
+	if err := renameFn(uploadFile, blobPath); err != nil {
+		return err
+	}
+	if err := syncDir(filepath.Dir(blobPath)); err != nil {
 		return err
 	}
 
@@ -173,6 +239,11 @@ func (s *FilesystemDataStorage) BlobsUploadCommit(repo, uuid, digest string) err
 		return err
 	}
 
+	return s.linkBlob(repo, algo, hash, digest)
+}
+
+// linkBlob writes the repository link of the blob, and indexes it.
+func (s *FilesystemDataStorage) linkBlob(repo, algo, hash, digest string) error {
 	// Write repository link:
 	// repositories/<repo>/_layers/<algo>/<hex>/link
 	linkPath := filepath.Join(
@@ -187,7 +258,13 @@ func (s *FilesystemDataStorage) BlobsUploadCommit(repo, uuid, digest string) err
 	if err := os.MkdirAll(filepath.Dir(linkPath), 0o755); err != nil {
 		return err
 	}
-	return os.WriteFile(linkPath, []byte(digest), 0o644)
+	if err := writeFileAtomic(linkPath, []byte(digest), 0o644); err != nil {
+		return err
+	}
+
+	// Index the repository along the link, so the repositories linking the
+	// blob are found without scanning every repository.
+	return s.indexBlobRepository(repo, algo, hash)
 }
 
 func (s *FilesystemDataStorage) BlobsUploadSize(repo, uuid string) (size int64, err error) {
@@ -206,3 +283,92 @@ func (s *FilesystemDataStorage) BlobsUploadSize(repo, uuid string) (size int64,
 
 	return fi.Size(), nil
 }
+
+func (s *FilesystemDataStorage) BlobsUploadStartedAt(repo, uuid string) (startedAt time.Time, err error) {
+	if !registry.RegExprName.MatchString(repo) {
+		return time.Now(), data.ErrRepoInvalid
+	}
+	if !registry.RegExprUUID.MatchString(uuid) {
+		return time.Now(), data.ErrUUIDInvalid
+	}
+
+	uploadDir := filepath.Join(s.base, "repositories", repo, "_uploads", uuid)
+	return uploadStartedAt(uploadDir)
+}
+
+// uploadStartedAt returns the time stored by BlobsUploadCreate in the
+// "startedat" file of the upload directory.
+func uploadStartedAt(uploadDir string) (startedAt time.Time, err error) {
+	b, err := os.ReadFile(filepath.Join(uploadDir, "startedat"))
+	if err != nil {
+		return time.Now(), err
+	}
+
+	return time.Parse(time.RFC3339Nano, string(b))
+}
+
+// BlobsUploadsList returns the upload sessions found in the "_uploads"
+// directory of every repository.
+func (s *FilesystemDataStorage) BlobsUploadsList() (uploads iter.Seq[data.Upload], err error) {
+	reposDir := filepath.Join(s.base, "repositories")
+
+	var list []data.Upload
+	err = filepath.WalkDir(reposDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		if !d.IsDir() {
+			return nil
+		}
+
+		switch d.Name() {
+		case "_manifests", "_layers", "_links":
+			return filepath.SkipDir
+
+		case "_uploads":
+			repo, err := filepath.Rel(reposDir, filepath.Dir(path))
+			if err != nil {
+				return err
+			}
+
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return err
+			}
+			for _, e := range entries {
+				if !e.IsDir() || !registry.RegExprUUID.MatchString(e.Name()) {
+					continue
+				}
+
+				startedAt, err := uploadStartedAt(filepath.Join(path, e.Name()))
+				if err != nil {
+					// Interrupted while being created, so as old as its
+					// directory.
+					info, err := e.Info()
+					if err != nil {
+						continue
+					}
+					startedAt = info.ModTime()
+				}
+
+				list = append(list, data.Upload{
+					Repo:      filepath.ToSlash(repo),
+					UUID:      e.Name(),
+					StartedAt: startedAt,
+				})
+			}
+			return filepath.SkipDir
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.Values(list), nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystem
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	d "github.com/jlsalvador/simple-registry/pkg/digest"
+	"github.com/jlsalvador/simple-registry/pkg/hasher"
+)
+
+// uploadHashState is the hashing state of the first Offset bytes of an upload,
+// stored as JSON in "_uploads/<uuid>/hashstate".
+type uploadHashState struct {
+	Algo   string `json:"algo"`
+	Offset int64  `json:"offset"`
+	State  []byte `json:"state"`
+}
+
+func uploadHashPath(uploadDir string) string {
+	return filepath.Join(uploadDir, "hashstate")
+}
+
+// writeUploadHash stores the hashing state of the first offset bytes of the
+// upload. The file is replaced atomically, so it is never half written.
+func writeUploadHash(uploadDir, algo string, h hasher.Hasher, offset int64) error {
+	state, err := h.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(uploadHashState{Algo: algo, Offset: offset, State: state})
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(uploadHashPath(uploadDir), b, 0o644)
+}
+
+// readUploadHash returns a hasher of the algo resumed from the stored hashing
+// state of the first offset bytes of the upload, whose data file has the given
+// size.
+//
+// It returns false if there is no usable state, like after an out of order
+// write or if it is of another algo.
+func readUploadHash(uploadDir, algo string, size int64) (h hasher.Hasher, offset int64, ok bool) {
+	b, err := os.ReadFile(uploadHashPath(uploadDir))
+	if err != nil {
+		return nil, 0, false
+	}
+
+	var state uploadHashState
+	if err := json.Unmarshal(b, &state); err != nil || state.Algo != algo || state.Offset > size {
+		return nil, 0, false
+	}
+
+	h, err = d.NewHasher(algo)
+	if err != nil {
+		return nil, 0, false
+	}
+	if err := h.UnmarshalBinary(state.State); err != nil {
+		return nil, 0, false
+	}
+
+	return h, state.Offset, true
+}
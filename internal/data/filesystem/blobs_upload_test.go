@@ -19,7 +19,10 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/jlsalvador/simple-registry/internal/data"
 	"github.com/jlsalvador/simple-registry/internal/data/filesystem"
@@ -179,3 +182,208 @@ func TestBlobsUploadSize(t *testing.T) {
 		t.Fatalf("wrong size: %d", size)
 	}
 }
+
+func TestBlobRepositories(t *testing.T) {
+	tmpdir := t.TempDir()
+	fs := filesystem.NewFilesystemDataStorage(tmpdir)
+
+	hasher, _ := digest.NewHasher("sha256")
+	hasher.Write([]byte("hello"))
+	dgst := "sha256:" + hasher.GetHashAsString()
+
+	for _, repo := range []string{"b/img", "a"} {
+		uploadID, err := fs.BlobsUploadCreate(repo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := fs.BlobsUploadWrite(repo, uploadID, bytes.NewBufferString("hello"), -1); err != nil {
+			t.Fatal(err)
+		}
+		if err := fs.BlobsUploadCommit(repo, uploadID, dgst); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repos, err := fs.BlobRepositories(dgst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b/img"}; !slices.Equal(repos, want) {
+		t.Errorf("expected %v, got %v", want, repos)
+	}
+
+	// Unlinked repositories are removed from the index.
+	if err := fs.BlobsDelete("a", dgst); err != nil {
+		t.Fatal(err)
+	}
+	repos, err = fs.BlobRepositories(dgst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"b/img"}; !slices.Equal(repos, want) {
+		t.Errorf("expected %v, got %v", want, repos)
+	}
+
+	// Stale index entries are skipped.
+	if err := os.RemoveAll(filepath.Join(tmpdir, "repositories", "b")); err != nil {
+		t.Fatal(err)
+	}
+	repos, err = fs.BlobRepositories(dgst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 0 {
+		t.Errorf("expected no repositories, got %v", repos)
+	}
+}
+
+func TestBlobsLink(t *testing.T) {
+	fs := filesystem.NewFilesystemDataStorage(t.TempDir())
+
+	hasher, _ := digest.NewHasher("sha256")
+	hasher.Write([]byte("hello"))
+	dgst := "sha256:" + hasher.GetHashAsString()
+
+	uploadID, err := fs.BlobsUploadCreate("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.BlobsUploadWrite("a", uploadID, bytes.NewBufferString("hello"), -1); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.BlobsUploadCommit("a", uploadID, dgst); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fs.BlobsLink("b", dgst); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := fs.BlobsGet("b", dgst); err != nil {
+		t.Errorf("expected linked blob, got %v", err)
+	}
+	if repos, err := fs.BlobRepositories(dgst); err != nil || !slices.Equal(repos, []string{"a", "b"}) {
+		t.Errorf("expected [a b], got %v (%v)", repos, err)
+	}
+
+	missing := "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	if err := fs.BlobsLink("b", missing); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist, got %v", err)
+	}
+}
+
+func TestBlobsUploadsList(t *testing.T) {
+	s := filesystem.NewFilesystemDataStorage(t.TempDir())
+
+	before := time.Now().Add(-time.Second)
+	uuidA, err := s.BlobsUploadCreate("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uuidB, err := s.BlobsUploadCreate("b/img")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	startedAt, err := s.BlobsUploadStartedAt("a", uuidA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if startedAt.Before(before) {
+		t.Errorf("expected started after %v, got %v", before, startedAt)
+	}
+	if _, err := s.BlobsUploadStartedAt("b/img", uuidA); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist, got %v", err)
+	}
+
+	uploads, err := s.BlobsUploadsList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := slices.SortedFunc(uploads, func(a, b data.Upload) int {
+		return strings.Compare(a.Repo, b.Repo)
+	})
+	if len(got) != 2 ||
+		got[0].Repo != "a" || got[0].UUID != uuidA ||
+		got[1].Repo != "b/img" || got[1].UUID != uuidB {
+		t.Fatalf("expected uploads %s and %s, got %v", uuidA, uuidB, got)
+	}
+	if got[0].StartedAt.Before(before) {
+		t.Errorf("expected started after %v, got %v", before, got[0].StartedAt)
+	}
+
+	if err := s.BlobsUploadCancel("a", uuidA); err != nil {
+		t.Fatal(err)
+	}
+	uploads, err = s.BlobsUploadsList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := slices.Collect(uploads); len(got) != 1 || got[0].UUID != uuidB {
+		t.Errorf("expected upload %s, got %v", uuidB, got)
+	}
+}
+
+func TestBlobsUploadWrite_RunningHash(t *testing.T) {
+	tmpdir := t.TempDir()
+	s := filesystem.NewFilesystemDataStorage(tmpdir)
+
+	sha256Of := func(b string) string {
+		hasher, _ := digest.NewHasher("sha256")
+		hasher.Write([]byte(b))
+		return "sha256:" + hasher.GetHashAsString()
+	}
+
+	uploadID, err := s.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataPath := filepath.Join(tmpdir, "repositories/repo/_uploads", uploadID, "data")
+
+	if err := s.BlobsUploadWrite("repo", uploadID, bytes.NewBufferString("hello"), -1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Data written by an interrupted write, without updating the running hash.
+	f, err := os.OpenFile(dataPath, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(" ")
+	f.Close()
+
+	// Hashing continues after a restart.
+	s = filesystem.NewFilesystemDataStorage(tmpdir)
+	if err := s.BlobsUploadWrite("repo", uploadID, bytes.NewBufferString("world"), 6); err != nil {
+		t.Fatal(err)
+	}
+
+	// The running hash is used by the commit instead of reading the data, so
+	// overwriting it behind the storage is not noticed.
+	if err := os.WriteFile(dataPath, []byte("HELLO WORLD"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BlobsUploadCommit("repo", uploadID, sha256Of("hello world")); err != nil {
+		t.Fatalf("expected commit with the running hash, got %v", err)
+	}
+
+	// Out of order writes fallback to hash the whole upload.
+	uploadID, err = s.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BlobsUploadWrite("repo", uploadID, bytes.NewBufferString("hello"), -1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BlobsUploadWrite("repo", uploadID, bytes.NewBufferString("J"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BlobsUploadWrite("repo", uploadID, bytes.NewBufferString("!"), -1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BlobsUploadCommit("repo", uploadID, sha256Of("hello!")); !errors.Is(err, data.ErrDigestMismatch) {
+		t.Errorf("expected ErrDigestMismatch, got %v", err)
+	}
+	if err := s.BlobsUploadCommit("repo", uploadID, sha256Of("Jello!")); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,672 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	d "github.com/jlsalvador/simple-registry/pkg/digest"
+	"github.com/jlsalvador/simple-registry/pkg/mapset"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+// FsckMode selects what [FilesystemDataStorage.Fsck] does with the broken
+// entries it finds.
+type FsckMode int
+
+const (
+	FsckModeCheck      FsckMode = iota // Only report the problems.
+	FsckModeRepair                     // Remove the broken entries.
+	FsckModeQuarantine                 // Move the broken entries under "_quarantine".
+)
+
+// Kinds of [FsckProblem].
+const (
+	FsckBlobCorrupt         = "blob_corrupt"          // Blob content does not match its digest.
+	FsckBlobInvalid         = "blob_invalid"          // Blob path is not a valid digest.
+	FsckLinkInvalid         = "link_invalid"          // Link file is empty or does not match its path.
+	FsckLinkDangling        = "link_dangling"         // Link file points at a missing blob.
+	FsckManifestBlobMissing = "manifest_blob_missing" // Manifest references missing blobs.
+	FsckIndexMissing        = "index_missing"         // Layer link is not in the index of the blob repositories.
+)
+
+// Actions taken on a [FsckProblem].
+const (
+	FsckActionNone        = ""
+	FsckActionRemoved     = "removed"
+	FsckActionQuarantined = "quarantined"
+	FsckActionRewritten   = "rewritten"
+	FsckActionIndexed     = "indexed"
+)
+
+// FsckProblem is a broken entry found by [FilesystemDataStorage.Fsck].
+type FsckProblem struct {
+	Kind       string   `json:"kind"`
+	Path       string   `json:"path"`
+	Repository string   `json:"repository,omitempty"`
+	Digest     string   `json:"digest,omitempty"`
+	Missing    []string `json:"missing,omitempty"`
+	Message    string   `json:"message"`
+	Action     string   `json:"action,omitempty"`
+}
+
+type fsck struct {
+	s          *FilesystemDataStorage
+	mode       FsckMode
+	report     func(FsckProblem)
+	quarantine string
+
+	// Blobs found corrupt, handled as missing even if they were kept.
+	corrupt mapset.MapSet[string]
+}
+
+// Fsck verifies the storage, calling report for every problem found:
+//
+//   - Blobs are re-hashed and compared with the digest of their path.
+//   - Tag, revision, layer and referrer links must point at existing blobs.
+//   - Manifests must only reference existing blobs.
+//   - Layer links must be in the index of the repositories linking the blob,
+//     which misses the links written before it existed.
+//
+// Depending on mode, broken entries are kept, removed or moved under
+// "_quarantine/<timestamp>". Revision, layer and referrer links whose content
+// could be recovered from their path are rewritten instead, and the missing
+// layer links are indexed.
+//
+// The registry should not be serving the storage while Fsck runs.
+func (s *FilesystemDataStorage) Fsck(mode FsckMode, report func(FsckProblem)) error {
+	f := &fsck{
+		s:      s,
+		mode:   mode,
+		report: report,
+		quarantine: filepath.Join(
+			s.base, "_quarantine", time.Now().UTC().Format("20060102T150405Z"),
+		),
+		corrupt: mapset.NewMapSet[string](),
+	}
+
+	if err := f.checkBlobs(); err != nil {
+		return err
+	}
+
+	repos, err := f.repositories()
+	if err != nil {
+		return err
+	}
+	for _, repo := range repos {
+		if err := f.checkRepository(repo); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fix removes or quarantines path, depending on the mode, and reports the
+// problem with the action taken.
+func (f *fsck) fix(p FsckProblem, path string) error {
+	switch f.mode {
+	case FsckModeRepair:
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+		p.Action = FsckActionRemoved
+
+	case FsckModeQuarantine:
+		rel, err := filepath.Rel(f.s.base, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(f.quarantine, rel)
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+		if err := os.Rename(path, dst); err != nil {
+			return err
+		}
+		p.Action = FsckActionQuarantined
+	}
+
+	f.report(p)
+	return nil
+}
+
+// rewrite writes content into the link, unless only checking, and reports the
+// problem with the action taken.
+func (f *fsck) rewrite(p FsckProblem, content string) error {
+	if f.mode != FsckModeCheck {
+		if err := writeFileAtomic(p.Path, []byte(content), 0o644); err != nil {
+			return err
+		}
+		p.Action = FsckActionRewritten
+	}
+
+	f.report(p)
+	return nil
+}
+
+// exists reports whether the blob is stored and not corrupt.
+func (f *fsck) exists(digest string) bool {
+	if f.corrupt.Contains(digest) {
+		return false
+	}
+	algo, hash, err := d.Parse(digest)
+	if err != nil || len(hash) < 2 {
+		return false
+	}
+	fi, err := os.Stat(filepath.Join(f.s.base, "blobs", algo, hash[0:2], hash))
+	return err == nil && fi.Mode().IsRegular()
+}
+
+func (f *fsck) checkBlobs() error {
+	blobsDir := filepath.Join(f.s.base, "blobs")
+
+	algos, err := os.ReadDir(blobsDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for _, algo := range algos {
+		if !algo.IsDir() {
+			continue
+		}
+
+		prefixes, err := os.ReadDir(filepath.Join(blobsDir, algo.Name()))
+		if err != nil {
+			return err
+		}
+		for _, prefix := range prefixes {
+			if !prefix.IsDir() {
+				continue
+			}
+
+			dir := filepath.Join(blobsDir, algo.Name(), prefix.Name())
+			hashes, err := os.ReadDir(dir)
+			if err != nil {
+				return err
+			}
+			for _, hash := range hashes {
+				if hash.IsDir() {
+					continue
+				}
+				if err := f.checkBlob(algo.Name(), prefix.Name(), hash.Name()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (f *fsck) checkBlob(algo, prefix, hash string) error {
+	path := filepath.Join(f.s.base, "blobs", algo, prefix, hash)
+	digest := algo + ":" + hash
+
+	p := FsckProblem{
+		Kind:   FsckBlobInvalid,
+		Path:   path,
+		Digest: digest,
+	}
+
+	hasher, err := d.NewHasher(algo)
+	if err != nil {
+		p.Message = fmt.Sprintf("unsupported algorithm %s", algo)
+		return f.fix(p, path)
+	}
+	if !registry.RegExprDigest.MatchString(digest) || !strings.HasPrefix(hash, prefix) {
+		p.Message = fmt.Sprintf("blob path does not match the digest %s", digest)
+		return f.fix(p, path)
+	}
+
+	p.Kind = FsckBlobCorrupt
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(hasher, file)
+	file.Close()
+	if err != nil {
+		f.corrupt.Add(digest)
+		p.Message = fmt.Sprintf("cannot read blob: %s", err)
+		return f.fix(p, path)
+	}
+
+	if got := hasher.GetHashAsString(); got != hash {
+		f.corrupt.Add(digest)
+		p.Message = fmt.Sprintf("blob content hashes to %s:%s", algo, got)
+		return f.fix(p, path)
+	}
+
+	return nil
+}
+
+// repositories returns every repository with manifests or layers, including
+// the nested ones.
+func (f *fsck) repositories() ([]string, error) {
+	reposDir := filepath.Join(f.s.base, "repositories")
+
+	var repos []string
+	err := filepath.WalkDir(reposDir, func(path string, e fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == reposDir {
+				return filepath.SkipAll
+			}
+			return err
+		}
+
+		if !e.IsDir() || path == reposDir {
+			return nil
+		}
+
+		if slices.Contains(repositoryDirs, e.Name()) {
+			return filepath.SkipDir
+		}
+
+		for _, name := range []string{"_manifests", "_layers"} {
+			if _, err := os.Stat(filepath.Join(path, name)); err == nil {
+				rel, err := filepath.Rel(reposDir, path)
+				if err != nil {
+					return err
+				}
+				repos = append(repos, filepath.ToSlash(rel))
+				break
+			}
+		}
+
+		return nil
+	})
+
+	return repos, err
+}
+
+func (f *fsck) checkRepository(repo string) error {
+	repoDir := filepath.Join(f.s.base, "repositories", repo)
+
+	// Layers and revisions share the same layout, with links holding the
+	// digest of their path.
+	err := f.checkDigestLinks(repo, filepath.Join(repoDir, "_layers"), true)
+	if err != nil {
+		return err
+	}
+	revisionsDir := filepath.Join(repoDir, "_manifests", "revisions")
+	if err := f.checkDigestLinks(repo, revisionsDir, false); err != nil {
+		return err
+	}
+
+	if err := f.checkTags(repo); err != nil {
+		return err
+	}
+	if err := f.checkReferrers(repo); err != nil {
+		return err
+	}
+
+	return f.checkManifests(repo)
+}
+
+// readLink returns the content of a link file, and whether it is a digest.
+func readLink(path string) (content string, ok bool, err error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, err
+	}
+	content = string(b)
+	return content, registry.RegExprDigest.MatchString(content), nil
+}
+
+// checkDigestLinks verifies the links stored as <dir>/<algo>/<hash>/link.
+func (f *fsck) checkDigestLinks(repo, dir string, layers bool) error {
+	algos, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for _, algo := range algos {
+		if !algo.IsDir() {
+			continue
+		}
+
+		hashes, err := os.ReadDir(filepath.Join(dir, algo.Name()))
+		if err != nil {
+			return err
+		}
+		for _, hash := range hashes {
+			if !hash.IsDir() {
+				continue
+			}
+
+			linkDir := filepath.Join(dir, algo.Name(), hash.Name())
+			digest := algo.Name() + ":" + hash.Name()
+			p := FsckProblem{
+				Path:       filepath.Join(linkDir, "link"),
+				Repository: repo,
+				Digest:     digest,
+			}
+
+			if !f.exists(digest) {
+				p.Kind = FsckLinkDangling
+				p.Message = fmt.Sprintf("link points at missing or corrupt blob %s", digest)
+				if layers && f.mode != FsckModeCheck && len(hash.Name()) >= 2 {
+					indexPath := filepath.Join(
+						f.s.blobRepositoriesDir(algo.Name(), hash.Name()),
+						url.PathEscape(repo),
+					)
+					if err := os.Remove(indexPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+						return err
+					}
+				}
+				if err := f.fix(p, linkDir); err != nil {
+					return err
+				}
+				continue
+			}
+
+			content, _, err := readLink(p.Path)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			if content != digest {
+				p.Kind = FsckLinkInvalid
+				p.Message = fmt.Sprintf("link content %q does not match %s", content, digest)
+				if err := f.rewrite(p, digest); err != nil {
+					return err
+				}
+			}
+
+			if layers && len(hash.Name()) >= 2 {
+				if err := f.checkIndex(repo, algo.Name(), hash.Name()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkIndex verifies that the layer link of repo to the blob is in the index
+// of the repositories linking it, indexing it unless only checking.
+func (f *fsck) checkIndex(repo, algo, hash string) error {
+	p := FsckProblem{
+		Kind:       FsckIndexMissing,
+		Path:       filepath.Join(f.s.blobRepositoriesDir(algo, hash), url.PathEscape(repo)),
+		Repository: repo,
+		Digest:     algo + ":" + hash,
+		Message:    fmt.Sprintf("link to %s:%s is not indexed", algo, hash),
+	}
+
+	_, err := os.Stat(p.Path)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if f.mode != FsckModeCheck {
+		if err := f.s.indexBlobRepository(repo, algo, hash); err != nil {
+			return err
+		}
+		p.Action = FsckActionIndexed
+	}
+
+	f.report(p)
+	return nil
+}
+
+func (f *fsck) checkTags(repo string) error {
+	tagsDir := filepath.Join(f.s.base, "repositories", repo, "_manifests", "tags")
+
+	tags, err := os.ReadDir(tagsDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for _, tag := range tags {
+		if !tag.IsDir() {
+			continue
+		}
+
+		tagDir := filepath.Join(tagsDir, tag.Name())
+		p := FsckProblem{
+			Path:       filepath.Join(tagDir, "current", "link"),
+			Repository: repo,
+		}
+
+		content, ok, err := readLink(p.Path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		// The digest of a tag cannot be recovered, so the tag is dropped.
+		switch {
+		case !ok:
+			p.Kind = FsckLinkInvalid
+			p.Message = fmt.Sprintf("tag %s link content %q is not a digest", tag.Name(), content)
+		case !f.exists(content):
+			p.Kind = FsckLinkDangling
+			p.Digest = content
+			p.Message = fmt.Sprintf("tag %s points at missing or corrupt blob %s", tag.Name(), content)
+		default:
+			continue
+		}
+		if err := f.fix(p, tagDir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkReferrers verifies the links stored as
+// referrers/<algo>/<hash>/<referrer digest>/link, holding the subject digest.
+func (f *fsck) checkReferrers(repo string) error {
+	referrersDir := filepath.Join(f.s.base, "repositories", repo, "_manifests", "referrers")
+
+	algos, err := os.ReadDir(referrersDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for _, algo := range algos {
+		if !algo.IsDir() {
+			continue
+		}
+
+		subjects, err := os.ReadDir(filepath.Join(referrersDir, algo.Name()))
+		if err != nil {
+			return err
+		}
+		for _, subject := range subjects {
+			if !subject.IsDir() {
+				continue
+			}
+
+			subjectDir := filepath.Join(referrersDir, algo.Name(), subject.Name())
+			refs, err := os.ReadDir(subjectDir)
+			if err != nil {
+				return err
+			}
+			for _, ref := range refs {
+				if !ref.IsDir() {
+					continue
+				}
+
+				refDir := filepath.Join(subjectDir, ref.Name())
+				subjectDigest := algo.Name() + ":" + subject.Name()
+				p := FsckProblem{
+					Path:       filepath.Join(refDir, "link"),
+					Repository: repo,
+					Digest:     ref.Name(),
+				}
+
+				// The subject may be pushed after its referrers, so only the
+				// referrer manifest must exist.
+				if !f.exists(ref.Name()) {
+					p.Kind = FsckLinkDangling
+					p.Message = fmt.Sprintf("referrer of %s points at missing or corrupt blob %s", subjectDigest, ref.Name())
+					if err := f.fix(p, refDir); err != nil {
+						return err
+					}
+					continue
+				}
+
+				content, _, err := readLink(p.Path)
+				if err != nil && !errors.Is(err, fs.ErrNotExist) {
+					return err
+				}
+				if content != subjectDigest {
+					p.Kind = FsckLinkInvalid
+					p.Message = fmt.Sprintf("referrer link content %q does not match %s", content, subjectDigest)
+					if err := f.rewrite(p, subjectDigest); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkManifests verifies that the revisions of the repository only
+// reference existing blobs. Broken revisions are handled with the tags and
+// referrers pointing at them.
+func (f *fsck) checkManifests(repo string) error {
+	manifestsDir := filepath.Join(f.s.base, "repositories", repo, "_manifests")
+
+	digests, err := f.s.ManifestsList(repo)
+	if err != nil {
+		return err
+	}
+	for digest := range digests {
+		if !f.exists(digest) {
+			continue
+		}
+
+		algo, hash, err := d.Parse(digest)
+		if err != nil {
+			continue
+		}
+
+		b, err := os.ReadFile(filepath.Join(f.s.base, "blobs", algo, hash[0:2], hash))
+		if err != nil {
+			return err
+		}
+		refs, err := registry.References(b)
+		if err != nil {
+			continue
+		}
+
+		var missing []string
+		for _, ref := range refs {
+			if !f.exists(ref) {
+				missing = append(missing, ref)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		revisionDir := filepath.Join(manifestsDir, "revisions", algo, hash)
+		p := FsckProblem{
+			Kind:       FsckManifestBlobMissing,
+			Path:       revisionDir,
+			Repository: repo,
+			Digest:     digest,
+			Missing:    missing,
+			Message:    fmt.Sprintf("manifest %s references %d missing blobs", digest, len(missing)),
+		}
+
+		if f.mode == FsckModeCheck {
+			f.report(p)
+			continue
+		}
+
+		paths, err := f.manifestPaths(manifestsDir, digest)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			if path == revisionDir {
+				continue
+			}
+			if err := f.fix(FsckProblem{
+				Kind:       FsckManifestBlobMissing,
+				Path:       path,
+				Repository: repo,
+				Digest:     digest,
+				Missing:    missing,
+				Message:    fmt.Sprintf("entry points at manifest %s with missing blobs", digest),
+			}, path); err != nil {
+				return err
+			}
+		}
+		if _, err := os.Stat(revisionDir); err == nil {
+			if err := f.fix(p, revisionDir); err != nil {
+				return err
+			}
+		} else {
+			f.report(p)
+		}
+	}
+
+	return nil
+}
+
+// manifestPaths returns the revision, tags and referrer directories of the
+// manifest.
+func (f *fsck) manifestPaths(manifestsDir, digest string) ([]string, error) {
+	algo, hash, err := d.Parse(digest)
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{filepath.Join(manifestsDir, "revisions", algo, hash)}
+
+	tagsDir := filepath.Join(manifestsDir, "tags")
+	tags, err := os.ReadDir(tagsDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for _, tag := range tags {
+		content, _, err := readLink(filepath.Join(tagsDir, tag.Name(), "current", "link"))
+		if err == nil && content == digest {
+			paths = append(paths, filepath.Join(tagsDir, tag.Name()))
+		}
+	}
+
+	refs, _ := filepath.Glob(filepath.Join(manifestsDir, "referrers", "*", "*", digest))
+	paths = append(paths, refs...)
+
+	return paths, nil
+}
@@ -0,0 +1,288 @@
+// Copyright 2026 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystem_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/jlsalvador/simple-registry/internal/data/filesystem"
+	"github.com/jlsalvador/simple-registry/pkg/digest"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+// setupFsck stores an image tagged "latest" in "repo", returning its manifest
+// and layer digests.
+func setupFsck(t *testing.T, s *filesystem.FilesystemDataStorage) (manifest, layer string) {
+	t.Helper()
+
+	hasher, _ := digest.NewHasher("sha256")
+	hasher.Write([]byte("layer"))
+	layer = "sha256:" + hasher.GetHashAsString()
+
+	uploadID, err := s.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BlobsUploadWrite("repo", uploadID, bytes.NewBufferString("layer"), -1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BlobsUploadCommit("repo", uploadID, layer); err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := json.Marshal(registry.ImageManifest{
+		SchemaVersion: 2,
+		MediaType:     registry.MediaTypeOCIImageManifest,
+		Layers: []registry.DescriptorManifest{
+			{MediaType: "application/vnd.oci.image.layer.v1.tar", Digest: layer, Size: 5},
+		},
+	})
+	manifest, err = s.ManifestPut("repo", "latest", bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return manifest, layer
+}
+
+func blobPath(base, dgst string) string {
+	algo, hash, _ := digest.Parse(dgst)
+	return filepath.Join(base, "blobs", algo, hash[0:2], hash)
+}
+
+func runFsck(t *testing.T, s *filesystem.FilesystemDataStorage, mode filesystem.FsckMode) map[string][]filesystem.FsckProblem {
+	t.Helper()
+
+	problems := map[string][]filesystem.FsckProblem{}
+	err := s.Fsck(mode, func(p filesystem.FsckProblem) {
+		problems[p.Kind] = append(problems[p.Kind], p)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return problems
+}
+
+func TestFsck_Clean(t *testing.T) {
+	s := filesystem.NewFilesystemDataStorage(t.TempDir())
+	setupFsck(t, s)
+
+	if problems := runFsck(t, s, filesystem.FsckModeCheck); len(problems) != 0 {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+}
+
+func TestFsck_Empty(t *testing.T) {
+	s := filesystem.NewFilesystemDataStorage(t.TempDir())
+
+	if problems := runFsck(t, s, filesystem.FsckModeRepair); len(problems) != 0 {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+}
+
+func TestFsck_CorruptBlob(t *testing.T) {
+	tmpdir := t.TempDir()
+	s := filesystem.NewFilesystemDataStorage(tmpdir)
+	manifest, layer := setupFsck(t, s)
+
+	// A partial write leaves a truncated blob.
+	if err := os.WriteFile(blobPath(tmpdir, layer), []byte("lay"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	problems := runFsck(t, s, filesystem.FsckModeCheck)
+	for kind, want := range map[string]string{
+		filesystem.FsckBlobCorrupt:         layer,
+		filesystem.FsckLinkDangling:        layer,
+		filesystem.FsckManifestBlobMissing: manifest,
+	} {
+		if len(problems[kind]) != 1 || problems[kind][0].Digest != want {
+			t.Errorf("expected one %s problem for %s, got %v", kind, want, problems[kind])
+			continue
+		}
+		if problems[kind][0].Action != filesystem.FsckActionNone {
+			t.Errorf("expected no action, got %q", problems[kind][0].Action)
+		}
+	}
+	if _, err := os.Stat(blobPath(tmpdir, layer)); err != nil {
+		t.Errorf("expected blob kept while checking, got %v", err)
+	}
+
+	problems = runFsck(t, s, filesystem.FsckModeRepair)
+	if p := problems[filesystem.FsckBlobCorrupt]; len(p) != 1 || p[0].Action != filesystem.FsckActionRemoved {
+		t.Errorf("expected corrupt blob removed, got %v", p)
+	}
+	if _, err := os.Stat(blobPath(tmpdir, layer)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected blob removed, got %v", err)
+	}
+	if _, _, _, err := s.ManifestGet("repo", "latest"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected tag removed, got %v", err)
+	}
+	if repos, err := s.BlobRepositories(layer); err != nil || len(repos) != 0 {
+		t.Errorf("expected no repositories linking the blob, got %v (%v)", repos, err)
+	}
+
+	// Everything was repaired.
+	if problems := runFsck(t, s, filesystem.FsckModeCheck); len(problems) != 0 {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+}
+
+func TestFsck_EmptyLinks(t *testing.T) {
+	tmpdir := t.TempDir()
+	s := filesystem.NewFilesystemDataStorage(tmpdir)
+	manifest, _ := setupFsck(t, s)
+
+	algo, hash, _ := digest.Parse(manifest)
+	manifestsDir := filepath.Join(tmpdir, "repositories", "repo", "_manifests")
+	revisionLink := filepath.Join(manifestsDir, "revisions", algo, hash, "link")
+	tagLink := filepath.Join(manifestsDir, "tags", "latest", "current", "link")
+	for _, link := range []string{revisionLink, tagLink} {
+		if err := os.WriteFile(link, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	problems := runFsck(t, s, filesystem.FsckModeRepair)
+	if p := problems[filesystem.FsckLinkInvalid]; len(p) != 2 {
+		t.Fatalf("expected 2 invalid links, got %v", p)
+	}
+	for _, p := range problems[filesystem.FsckLinkInvalid] {
+		want := filesystem.FsckActionRemoved
+		if p.Path == revisionLink {
+			want = filesystem.FsckActionRewritten
+		}
+		if p.Action != want {
+			t.Errorf("expected %s for %s, got %q", want, p.Path, p.Action)
+		}
+	}
+
+	// The revision link is recovered from its path, the tag is dropped.
+	if b, err := os.ReadFile(revisionLink); err != nil || string(b) != manifest {
+		t.Errorf("expected revision link %s, got %q (%v)", manifest, b, err)
+	}
+	if _, err := os.Stat(filepath.Dir(filepath.Dir(tagLink))); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected tag removed, got %v", err)
+	}
+	if _, _, _, err := s.ManifestGet("repo", manifest); err != nil {
+		t.Errorf("expected manifest by digest, got %v", err)
+	}
+}
+
+func TestFsck_DanglingReferrer(t *testing.T) {
+	tmpdir := t.TempDir()
+	s := filesystem.NewFilesystemDataStorage(tmpdir)
+	manifest, _ := setupFsck(t, s)
+
+	b, _ := json.Marshal(registry.ImageManifest{
+		SchemaVersion: 2,
+		MediaType:     registry.MediaTypeOCIImageManifest,
+		Subject:       &registry.DescriptorManifest{Digest: manifest},
+	})
+	referrer, err := s.ManifestPut("repo", "", bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(blobPath(tmpdir, referrer)); err != nil {
+		t.Fatal(err)
+	}
+
+	problems := runFsck(t, s, filesystem.FsckModeQuarantine)
+	// Manifests are also linked as layers.
+	if p := problems[filesystem.FsckLinkDangling]; len(p) != 3 {
+		t.Fatalf("expected dangling layer, revision and referrer links, got %v", p)
+	}
+	for _, p := range problems[filesystem.FsckLinkDangling] {
+		if p.Digest != referrer || p.Action != filesystem.FsckActionQuarantined {
+			t.Errorf("expected %s quarantined, got %v", referrer, p)
+		}
+	}
+
+	referrers, err := s.ReferrersGet("repo", manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for r := range referrers {
+		t.Errorf("expected no referrers, got %s", r)
+	}
+
+	quarantined, _ := filepath.Glob(filepath.Join(tmpdir, "_quarantine", "*", "repositories", "repo", "_manifests", "referrers", "*", "*", referrer))
+	if len(quarantined) != 1 {
+		t.Errorf("expected referrer quarantined, got %v", quarantined)
+	}
+}
+
+func TestFsck_QuarantineBlob(t *testing.T) {
+	tmpdir := t.TempDir()
+	s := filesystem.NewFilesystemDataStorage(tmpdir)
+	_, layer := setupFsck(t, s)
+
+	if err := os.WriteFile(blobPath(tmpdir, layer), []byte("corrupt"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	runFsck(t, s, filesystem.FsckModeQuarantine)
+
+	if _, err := os.Stat(blobPath(tmpdir, layer)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected blob moved, got %v", err)
+	}
+	rel, _ := filepath.Rel(tmpdir, blobPath(tmpdir, layer))
+	quarantined, _ := filepath.Glob(filepath.Join(tmpdir, "_quarantine", "*", rel))
+	if len(quarantined) != 1 {
+		t.Fatalf("expected blob quarantined, got %v", quarantined)
+	}
+	if b, err := os.ReadFile(quarantined[0]); err != nil || string(b) != "corrupt" {
+		t.Errorf("expected quarantined content, got %q (%v)", b, err)
+	}
+}
+
+func TestFsck_IndexMissing(t *testing.T) {
+	tmpdir := t.TempDir()
+	s := filesystem.NewFilesystemDataStorage(tmpdir)
+	_, layer := setupFsck(t, s)
+
+	// Links written before the index existed.
+	if err := os.RemoveAll(filepath.Join(tmpdir, "_blob_repositories")); err != nil {
+		t.Fatal(err)
+	}
+
+	problems := runFsck(t, s, filesystem.FsckModeCheck)
+	if p := problems[filesystem.FsckIndexMissing]; len(p) != 2 {
+		t.Fatalf("expected 2 missing index entries, got %v", p)
+	}
+	if repos, err := s.BlobRepositories(layer); err != nil || len(repos) != 0 {
+		t.Errorf("expected no repositories, got %v (%v)", repos, err)
+	}
+
+	problems = runFsck(t, s, filesystem.FsckModeRepair)
+	for _, p := range problems[filesystem.FsckIndexMissing] {
+		if p.Action != filesystem.FsckActionIndexed {
+			t.Errorf("expected %s for %s, got %q", filesystem.FsckActionIndexed, p.Path, p.Action)
+		}
+	}
+	if repos, err := s.BlobRepositories(layer); err != nil || !slices.Equal(repos, []string{"repo"}) {
+		t.Errorf("expected [repo], got %v (%v)", repos, err)
+	}
+
+	if problems := runFsck(t, s, filesystem.FsckModeCheck); len(problems) != 0 {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+}
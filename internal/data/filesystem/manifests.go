@@ -65,7 +65,7 @@ func (s *FilesystemDataStorage) indexReferrer(repo, referrerDigest string, manif
 		referrerDir, "link",
 	)
 
-	if err := os.WriteFile(linkPath, []byte(subjectDigest), 0o644); err != nil {
+	if err := writeFileAtomic(linkPath, []byte(subjectDigest), 0o644); err != nil {
 		return fmt.Errorf("cannot write referrer file %s: %w", linkPath, err)
 	}
 
@@ -114,7 +114,7 @@ func (s *FilesystemDataStorage) ManifestPut(repo, reference string, r io.Reader)
 	if err := os.MkdirAll(filepath.Dir(revisionLink), 0o755); err != nil {
 		return "", err
 	}
-	if err := os.WriteFile(revisionLink, []byte(dgst), 0o644); err != nil {
+	if err := writeFileAtomic(revisionLink, []byte(dgst), 0o644); err != nil {
 		return "", err
 	}
 
@@ -127,7 +127,7 @@ func (s *FilesystemDataStorage) ManifestPut(repo, reference string, r io.Reader)
 		if err := os.MkdirAll(filepath.Dir(tagLink), 0o755); err != nil {
 			return "", err
 		}
-		if err := os.WriteFile(tagLink, []byte(dgst), 0o644); err != nil {
+		if err := writeFileAtomic(tagLink, []byte(dgst), 0o644); err != nil {
 			return "", err
 		}
 	}
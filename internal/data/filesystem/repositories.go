@@ -15,11 +15,27 @@
 package filesystem
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
 	"os"
 	"path/filepath"
 	"slices"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
 )
 
+// repositoryDirs are the internal directories of a repository. Any other
+// directory belongs to a nested repository.
+var repositoryDirs = []string{
+	"_manifests",
+	"_layers",
+	"_uploads",
+	"_links",
+}
+
 func (s *FilesystemDataStorage) RepositoriesList() ([]string, error) {
 	reposDir := filepath.Join(s.base, "repositories")
 	var respos []string
@@ -39,12 +55,7 @@ func (s *FilesystemDataStorage) RepositoriesList() ([]string, error) {
 		}
 
 		// Skip internal registry directories
-		if slices.Contains([]string{
-			"_manifests",
-			"_layers",
-			"_uploads",
-			"_links",
-		}, d.Name()) {
+		if slices.Contains(repositoryDirs, d.Name()) {
 			return filepath.SkipDir
 		}
 
@@ -67,3 +78,75 @@ func (s *FilesystemDataStorage) RepositoriesList() ([]string, error) {
 
 	return respos, err
 }
+
+// unindexLayers removes the repository from the index of the blobs it links.
+func (s *FilesystemDataStorage) unindexLayers(repo string) error {
+	layersDir := filepath.Join(s.base, "repositories", repo, "_layers")
+
+	algos, err := os.ReadDir(layersDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for _, algo := range algos {
+		hashes, err := os.ReadDir(filepath.Join(layersDir, algo.Name()))
+		if err != nil {
+			return err
+		}
+		for _, hash := range hashes {
+			if len(hash.Name()) < 2 {
+				continue
+			}
+			indexPath := filepath.Join(
+				s.blobRepositoriesDir(algo.Name(), hash.Name()),
+				url.PathEscape(repo),
+			)
+			if err := os.Remove(indexPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *FilesystemDataStorage) RepositoryDelete(repo string) error {
+	if !registry.RegExprName.MatchString(repo) {
+		return data.ErrRepoInvalid
+	}
+
+	reposDir := filepath.Join(s.base, "repositories")
+	repoDir := filepath.Join(reposDir, repo)
+
+	found := false
+	for _, name := range repositoryDirs {
+		if _, err := os.Stat(filepath.Join(repoDir, name)); err == nil {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("cannot find repository %s: %w", repo, fs.ErrNotExist)
+	}
+
+	if err := s.unindexLayers(repo); err != nil {
+		return err
+	}
+
+	// Only the internal directories are removed, so nested repositories are
+	// kept.
+	for _, name := range repositoryDirs {
+		if err := os.RemoveAll(filepath.Join(repoDir, name)); err != nil {
+			return err
+		}
+	}
+
+	// Remove the directories left empty, up to the repositories one.
+	for dir := repoDir; dir != reposDir; dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			break
+		}
+	}
+
+	return nil
+}
@@ -16,12 +16,15 @@ package filesystem_test
 
 import (
 	"bytes"
+	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"slices"
 	"testing"
 
 	"github.com/jlsalvador/simple-registry/internal/data/filesystem"
+	"github.com/jlsalvador/simple-registry/pkg/digest"
 )
 
 func TestRepositoriesList(t *testing.T) {
@@ -213,3 +216,75 @@ func TestRepositoriesListSkipAll(t *testing.T) {
 		t.Errorf("expected [myrepo], got %v", repos)
 	}
 }
+
+func TestRepositoryDelete(t *testing.T) {
+	baseDir := t.TempDir()
+	storage := filesystem.NewFilesystemDataStorage(baseDir)
+
+	hasher, _ := digest.NewHasher("sha256")
+	hasher.Write([]byte("hello"))
+	dgst := "sha256:" + hasher.GetHashAsString()
+
+	uploadID, err := storage.BlobsUploadCreate("org/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.BlobsUploadWrite("org/app", uploadID, bytes.NewBufferString("hello"), -1); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.BlobsUploadCommit("org/app", uploadID, dgst); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.BlobsUploadCreate("org/app"); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := createTestManifest(nil)
+	for _, repo := range []string{"org/app", "org/app/nested"} {
+		if _, err := storage.ManifestPut(repo, "latest", bytes.NewReader(manifest)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := storage.RepositoryDelete("org/app"); err != nil {
+		t.Fatal(err)
+	}
+
+	repos, err := storage.RepositoriesList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"org/app/nested"}; !slices.Equal(repos, want) {
+		t.Errorf("expected %v, got %v", want, repos)
+	}
+	if _, _, _, err := storage.ManifestGet("org/app", "latest"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+	if repos, err := storage.BlobRepositories(dgst); err != nil || len(repos) != 0 {
+		t.Errorf("expected no repositories, got %v (%v)", repos, err)
+	}
+	uploads, err := storage.BlobsUploadsList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for u := range uploads {
+		t.Errorf("expected no uploads, got %v", u)
+	}
+
+	// The blob is kept for the garbage collector.
+	if _, _, err := storage.BlobsGet("", dgst); err != nil {
+		t.Errorf("expected blob kept, got %v", err)
+	}
+
+	// Empty directories are removed.
+	if err := storage.RepositoryDelete("org/app/nested"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, "repositories", "org")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+
+	if err := storage.RepositoryDelete("org/app"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
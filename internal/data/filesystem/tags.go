@@ -17,6 +17,10 @@ package filesystem
 import (
 	"os"
 	"path/filepath"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
 )
 
 func (s *FilesystemDataStorage) TagsList(repo string) ([]string, error) {
@@ -40,3 +44,23 @@ func (s *FilesystemDataStorage) TagsList(repo string) ([]string, error) {
 
 	return tags, nil
 }
+
+func (s *FilesystemDataStorage) TagLastModified(repo, tag string) (lastModified time.Time, err error) {
+	if !registry.RegExprName.MatchString(repo) {
+		return time.Now(), data.ErrRepoInvalid
+	}
+	if !registry.RegExprTag.MatchString(tag) {
+		return time.Now(), data.ErrTagInvalid
+	}
+
+	tagLink := filepath.Join(
+		s.base, "repositories", repo, "_manifests",
+		"tags", tag, "current", "link",
+	)
+	fi, err := os.Stat(tagLink)
+	if err != nil {
+		return time.Now(), err
+	}
+
+	return fi.ModTime(), nil
+}
@@ -16,11 +16,15 @@ package filesystem_test
 
 import (
 	"bytes"
+	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"slices"
 	"testing"
+	"time"
 
+	"github.com/jlsalvador/simple-registry/internal/data"
 	"github.com/jlsalvador/simple-registry/internal/data/filesystem"
 )
 
@@ -199,3 +203,32 @@ func TestTagsList(t *testing.T) {
 		})
 	}
 }
+
+func TestTagLastModified(t *testing.T) {
+	s := filesystem.NewFilesystemDataStorage(t.TempDir())
+
+	if _, err := s.TagLastModified("repo", "latest"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+
+	before := time.Now().Add(-time.Second)
+	if _, err := s.ManifestPut("repo", "latest", bytes.NewReader(createTestManifest(nil))); err != nil {
+		t.Fatal(err)
+	}
+
+	lastModified, err := s.TagLastModified("repo", "latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastModified.Before(before) || lastModified.After(time.Now()) {
+		t.Errorf("expected last modified after %v, got %v", before, lastModified)
+	}
+
+	if _, err := s.TagLastModified("repo", "unknown"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+	// The tag could not point outside of the tags.
+	if _, err := s.TagLastModified("repo", "../../../_layers"); !errors.Is(err, data.ErrTagInvalid) {
+		t.Errorf("expected data.ErrTagInvalid, got %v", err)
+	}
+}
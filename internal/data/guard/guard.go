@@ -0,0 +1,175 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guard
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/jlsalvador/simple-registry/pkg/mapset"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+// StartTracking starts to record the digests used by clients, until
+// [GuardDataStorage.StopTracking] is called with the returned tracker.
+func (s *GuardDataStorage) StartTracking() *Tracker {
+	s.trackMu.Lock()
+	defer s.trackMu.Unlock()
+
+	t := &Tracker{touched: mapset.NewMapSet[string]()}
+	s.trackers.Add(t)
+	return t
+}
+
+// StopTracking stops to record the digests used by clients in the tracker.
+func (s *GuardDataStorage) StopTracking(t *Tracker) {
+	s.trackMu.Lock()
+	defer s.trackMu.Unlock()
+
+	delete(s.trackers, t)
+}
+
+// tracking reports whether some tracker is recording.
+func (s *GuardDataStorage) tracking() bool {
+	s.trackMu.Lock()
+	defer s.trackMu.Unlock()
+
+	return len(s.trackers) > 0
+}
+
+func (s *GuardDataStorage) touch(digests ...string) {
+	s.trackMu.Lock()
+	defer s.trackMu.Unlock()
+
+	for t := range s.trackers {
+		for _, digest := range digests {
+			if digest != "" {
+				t.touched.Add(digest)
+			}
+		}
+	}
+}
+
+// Exclusive runs fn while client operations are blocked.
+//
+// The digests used by clients since [GuardDataStorage.StartTracking] returned
+// the tracker are passed to fn, so they must not be removed. A nil tracker
+// passes none.
+func (s *GuardDataStorage) Exclusive(t *Tracker, fn func(touched mapset.MapSet[string]) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	touched := mapset.NewMapSet[string]()
+	if t != nil {
+		s.trackMu.Lock()
+		for digest := range t.touched {
+			touched.Add(digest)
+		}
+		s.trackMu.Unlock()
+	}
+
+	return fn(touched)
+}
+
+func (s *GuardDataStorage) BlobsGet(repo, digest string) (r io.ReadCloser, size int64, err error) {
+	if s.Next == nil {
+		return nil, -1, ErrDataStorageNotInitialized
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// A client checking if a blob exists will use it soon.
+	s.touch(digest)
+
+	return s.Next.BlobsGet(repo, digest)
+}
+
+func (s *GuardDataStorage) BlobsUploadCommit(repo, uuid, digest string) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.touch(digest)
+
+	return s.Next.BlobsUploadCommit(repo, uuid, digest)
+}
+
+func (s *GuardDataStorage) BlobsLink(repo, digest string) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.touch(digest)
+
+	return s.Next.BlobsLink(repo, digest)
+}
+
+func (s *GuardDataStorage) ManifestPut(repo, reference string, r io.Reader) (digest string, err error) {
+	if s.Next == nil {
+		return "", ErrDataStorageNotInitialized
+	}
+
+	payload, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	digest, err = s.Next.ManifestPut(repo, reference, bytes.NewReader(payload))
+	if err != nil {
+		return digest, err
+	}
+
+	// The blobs referenced by the manifest could have been uploaded before
+	// the tracking started, and the collector could have marked the
+	// repository before the manifest was pushed.
+	digests := []string{digest}
+	if s.tracking() {
+		if refs, err := registry.References(payload); err == nil {
+			digests = append(digests, refs...)
+		}
+	}
+	s.touch(digests...)
+
+	return digest, nil
+}
+
+func (s *GuardDataStorage) ManifestGet(repo, reference string) (
+	r io.ReadCloser,
+	size int64,
+	digest string,
+	err error,
+) {
+	if s.Next == nil {
+		return nil, -1, "", ErrDataStorageNotInitialized
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, size, digest, err = s.Next.ManifestGet(repo, reference)
+	s.touch(digest)
+
+	return r, size, digest, err
+}
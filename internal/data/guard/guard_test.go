@@ -0,0 +1,182 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guard_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data/guard"
+	"github.com/jlsalvador/simple-registry/internal/data/memory"
+	"github.com/jlsalvador/simple-registry/pkg/mapset"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+func sha256Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func putBlob(t *testing.T, s *guard.GuardDataStorage, repo string, data []byte) string {
+	t.Helper()
+
+	uuid, err := s.BlobsUploadCreate(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BlobsUploadWrite(repo, uuid, bytes.NewReader(data), -1); err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256Digest(data)
+	if err := s.BlobsUploadCommit(repo, uuid, digest); err != nil {
+		t.Fatal(err)
+	}
+	return digest
+}
+
+func touched(t *testing.T, s *guard.GuardDataStorage, tracker *guard.Tracker) mapset.MapSet[string] {
+	t.Helper()
+
+	var got mapset.MapSet[string]
+	if err := s.Exclusive(tracker, func(touched mapset.MapSet[string]) error {
+		got = touched
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestTracking(t *testing.T) {
+	s := guard.NewGuardDataStorage(memory.NewMemoryDataStorage(0))
+
+	// Nothing is tracked before StartTracking.
+	before := putBlob(t, s, "repo", []byte("before"))
+	tracker := s.StartTracking()
+	if got := touched(t, s, tracker); len(got) != 0 {
+		t.Fatalf("expected nothing touched, got %v", got)
+	}
+
+	committed := putBlob(t, s, "repo", []byte("committed"))
+	if _, _, err := s.BlobsGet("repo", before); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := s.ManifestPut("repo", "latest", bytes.NewReader([]byte("{}")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := mapset.NewMapSet[string]().Add(before, committed, manifest)
+	if got := touched(t, s, tracker); !got.Equal(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	s.StopTracking(tracker)
+	putBlob(t, s, "repo", []byte("after"))
+	if got := touched(t, s, tracker); !got.Equal(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestTrackingOverlapping(t *testing.T) {
+	s := guard.NewGuardDataStorage(memory.NewMemoryDataStorage(0))
+
+	first := s.StartTracking()
+	early := putBlob(t, s, "repo", []byte("early"))
+	second := s.StartTracking()
+
+	// Stopping a tracker does not affect the other one.
+	s.StopTracking(first)
+	late := putBlob(t, s, "repo", []byte("late"))
+
+	if want, got := mapset.NewMapSet[string]().Add(early), touched(t, s, first); !got.Equal(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if want, got := mapset.NewMapSet[string]().Add(late), touched(t, s, second); !got.Equal(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	s.StopTracking(second)
+	if got := touched(t, s, nil); len(got) != 0 {
+		t.Errorf("expected nothing touched, got %v", got)
+	}
+}
+
+func TestManifestPutTouchesReferences(t *testing.T) {
+	s := guard.NewGuardDataStorage(memory.NewMemoryDataStorage(0))
+
+	// Uploaded before the collection started.
+	config := putBlob(t, s, "repo", []byte("config"))
+	layer := putBlob(t, s, "repo", []byte("layer"))
+
+	tracker := s.StartTracking()
+	defer s.StopTracking(tracker)
+
+	payload, _ := json.Marshal(registry.ImageManifest{
+		SchemaVersion: 2,
+		MediaType:     registry.MediaTypeOCIImageManifest,
+		Config:        registry.DescriptorManifest{Digest: config},
+		Layers:        []registry.DescriptorManifest{{Digest: layer}},
+	})
+	manifest, err := s.ManifestPut("repo", "latest", bytes.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := mapset.NewMapSet[string]().Add(manifest, config, layer)
+	if got := touched(t, s, tracker); !got.Equal(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestExclusiveBlocksWrites(t *testing.T) {
+	s := guard.NewGuardDataStorage(memory.NewMemoryDataStorage(0))
+
+	uuid, err := s.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BlobsUploadWrite("repo", uuid, bytes.NewReader([]byte("data")), -1); err != nil {
+		t.Fatal(err)
+	}
+
+	committed := make(chan struct{})
+	err = s.Exclusive(nil, func(mapset.MapSet[string]) error {
+		go func() {
+			s.BlobsUploadCommit("repo", uuid, sha256Digest([]byte("data")))
+			close(committed)
+		}()
+
+		select {
+		case <-committed:
+			t.Error("expected commit blocked while exclusive")
+		case <-time.After(50 * time.Millisecond):
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-committed:
+	case <-time.After(time.Second):
+		t.Fatal("expected commit after exclusive")
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package guard is a DataStorage decorator to coordinate the registry clients
+// with an online garbage collector.
+//
+// While a garbage collection is running, every digest committed, pushed or
+// fetched by a client is tracked, so the collector could skip them. The sweep
+// runs exclusively, blocking clients until it finishes.
+package guard
+
+import (
+	"sync"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/pkg/mapset"
+)
+
+type GuardDataStorage struct {
+	Next data.DataStorage
+
+	// mu is held shared by client operations and exclusively by the sweep.
+	mu sync.RWMutex
+
+	trackMu  sync.Mutex
+	trackers mapset.MapSet[*Tracker]
+}
+
+// Tracker records the digests used by clients during a garbage collection.
+//
+// Every collection has its own tracker, so overlapping collections do not
+// lose the digests used while the other ones were running.
+type Tracker struct {
+	touched mapset.MapSet[string] // Guarded by GuardDataStorage.trackMu.
+}
+
+func NewGuardDataStorage(ds data.DataStorage) *GuardDataStorage {
+	return &GuardDataStorage{Next: ds, trackers: mapset.NewMapSet[*Tracker]()}
+}
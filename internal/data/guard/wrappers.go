@@ -0,0 +1,170 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guard
+
+import (
+	"io"
+	"iter"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+)
+
+// Blobs upload
+
+func (s *GuardDataStorage) BlobsUploadCreate(repo string) (uuid string, err error) {
+	if s.Next == nil {
+		return "", ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsUploadCreate(repo)
+}
+func (s *GuardDataStorage) BlobsUploadCancel(repo, uuid string) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsUploadCancel(repo, uuid)
+}
+func (s *GuardDataStorage) BlobsUploadWrite(repo, uuid string, r io.Reader, start int64) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsUploadWrite(repo, uuid, r, start)
+}
+func (s *GuardDataStorage) BlobsUploadSize(repo, uuid string) (size int64, err error) {
+	if s.Next == nil {
+		return -1, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsUploadSize(repo, uuid)
+}
+
+func (s *GuardDataStorage) BlobsUploadStartedAt(repo, uuid string) (startedAt time.Time, err error) {
+	if s.Next == nil {
+		return time.Now(), ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsUploadStartedAt(repo, uuid)
+}
+
+func (s *GuardDataStorage) BlobsUploadsList() (uploads iter.Seq[data.Upload], err error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsUploadsList()
+}
+
+// Blobs
+
+func (s *GuardDataStorage) BlobsDelete(repo, digest string) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsDelete(repo, digest)
+}
+func (s *GuardDataStorage) BlobsList() (digests iter.Seq[string], err error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsList()
+}
+func (s *GuardDataStorage) BlobLastAccess(digest string) (lastAccess time.Time, err error) {
+	if s.Next == nil {
+		return time.Now(), ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobLastAccess(digest)
+}
+
+func (s *GuardDataStorage) BlobRepositories(digest string) (repos []string, err error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobRepositories(digest)
+}
+
+// Manifests
+
+func (s *GuardDataStorage) ManifestDelete(repo, reference string) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	return s.Next.ManifestDelete(repo, reference)
+}
+func (s *GuardDataStorage) ManifestsList(repo string) (digests iter.Seq[string], err error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.ManifestsList(repo)
+}
+func (s *GuardDataStorage) ManifestLastAccess(digest string) (lastAccess time.Time, err error) {
+	if s.Next == nil {
+		return time.Now(), ErrDataStorageNotInitialized
+	}
+
+	return s.Next.ManifestLastAccess(digest)
+}
+
+// Tags
+
+func (s *GuardDataStorage) TagsList(repo string) ([]string, error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.TagsList(repo)
+}
+func (s *GuardDataStorage) TagLastModified(repo, tag string) (lastModified time.Time, err error) {
+	if s.Next == nil {
+		return time.Now(), ErrDataStorageNotInitialized
+	}
+
+	return s.Next.TagLastModified(repo, tag)
+}
+
+// Repositories
+
+func (s *GuardDataStorage) RepositoriesList() ([]string, error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.RepositoriesList()
+}
+func (s *GuardDataStorage) RepositoryDelete(repo string) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	return s.Next.RepositoryDelete(repo)
+}
+
+// Referrers
+
+func (s *GuardDataStorage) ReferrersGet(repo, manifestDigest string) (digests iter.Seq[string], err error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.ReferrersGet(repo, manifestDigest)
+}
@@ -0,0 +1,195 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package immutable
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"regexp"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/internal/data/proxy"
+	d "github.com/jlsalvador/simple-registry/pkg/digest"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+// Match returns if the tag of the repository is immutable by the rule.
+func (r Rule) Match(repo, tag string) bool {
+	return matchAny(r.Scopes, repo) && (len(r.Tags) == 0 || matchAny(r.Tags, tag))
+}
+
+func matchAny(exprs []regexp.Regexp, s string) bool {
+	for i := range exprs {
+		if exprs[i].MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// isImmutable returns the name of the first rule matching the tag, if any.
+func (s *ImmutableDataStorage) isImmutable(repo, reference string) (rule string, ok bool) {
+	if registry.RegExprDigest.MatchString(reference) {
+		return "", false
+	}
+	for _, r := range s.Rules {
+		if r.Match(repo, reference) {
+			return r.Name, true
+		}
+	}
+	return "", false
+}
+
+// IsImmutable returns if the tag of the repository is immutable.
+func (s *ImmutableDataStorage) IsImmutable(repo, tag string) bool {
+	_, immutable := s.isImmutable(repo, tag)
+	return immutable
+}
+
+// local returns the data storage under the pull-through cache, if any.
+//
+// Tags are immutable once stored in the registry, so they are resolved
+// without mirroring upstream.
+func (s *ImmutableDataStorage) local() data.DataStorage {
+	if p, ok := s.Next.(*proxy.ProxyDataStorage); ok {
+		return p.Next
+	}
+	return s.Next
+}
+
+// tagDigest returns the digest of the stored tag, or an empty string if the
+// tag does not exist.
+func (s *ImmutableDataStorage) tagDigest(repo, tag string) (string, error) {
+	r, _, digest, err := s.local().ManifestGet(repo, tag)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+	r.Close()
+	return digest, nil
+}
+
+// ManifestPut refuses to move an immutable tag to another digest. Pushing the
+// same manifest again is allowed, so retries are harmless.
+func (s *ImmutableDataStorage) ManifestPut(repo, reference string, r io.Reader) (digest string, err error) {
+	if s.Next == nil {
+		return "", ErrDataStorageNotInitialized
+	}
+
+	rule, immutable := s.isImmutable(repo, reference)
+	if !immutable {
+		return s.Next.ManifestPut(repo, reference, r)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.tagDigest(repo, reference)
+	if err != nil {
+		return "", err
+	}
+	if current == "" {
+		return s.Next.ManifestPut(repo, reference, r)
+	}
+
+	payload, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	algo, hash, err := d.Parse(current)
+	if err != nil {
+		return "", err
+	}
+	hasher, err := d.NewHasher(algo)
+	if err != nil {
+		return "", err
+	}
+	hasher.Write(payload)
+	if hasher.GetHashAsString() != hash {
+		return "", fmt.Errorf("%w: %s:%s by %q", data.ErrTagImmutable, repo, reference, rule)
+	}
+
+	return s.Next.ManifestPut(repo, reference, bytes.NewReader(payload))
+}
+
+// ManifestDelete refuses to delete an immutable tag, or a manifest referenced
+// by one.
+func (s *ImmutableDataStorage) ManifestDelete(repo, reference string) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rule, immutable := s.isImmutable(repo, reference); immutable {
+		digest, err := s.tagDigest(repo, reference)
+		if err != nil {
+			return err
+		}
+		if digest != "" {
+			return fmt.Errorf("%w: %s:%s by %q", data.ErrTagImmutable, repo, reference, rule)
+		}
+	}
+
+	if registry.RegExprDigest.MatchString(reference) {
+		tags, err := s.local().TagsList(repo)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		for _, tag := range tags {
+			rule, immutable := s.isImmutable(repo, tag)
+			if !immutable {
+				continue
+			}
+			digest, err := s.tagDigest(repo, tag)
+			if err != nil {
+				return err
+			}
+			if digest == reference {
+				return fmt.Errorf("%w: %s:%s by %q", data.ErrTagImmutable, repo, tag, rule)
+			}
+		}
+	}
+
+	return s.Next.ManifestDelete(repo, reference)
+}
+
+// RepositoryDelete refuses to delete a repository with immutable tags.
+func (s *ImmutableDataStorage) RepositoryDelete(repo string) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tags, err := s.local().TagsList(repo)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for _, tag := range tags {
+		if rule, immutable := s.isImmutable(repo, tag); immutable {
+			return fmt.Errorf("%w: %s:%s by %q", data.ErrTagImmutable, repo, tag, rule)
+		}
+	}
+
+	return s.Next.RepositoryDelete(repo)
+}
@@ -0,0 +1,197 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package immutable_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/internal/data/immutable"
+	"github.com/jlsalvador/simple-registry/internal/data/memory"
+	"github.com/jlsalvador/simple-registry/internal/data/proxy"
+)
+
+func sha256Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func putBlob(t *testing.T, s *immutable.ImmutableDataStorage, repo string, data []byte) string {
+	t.Helper()
+
+	uuid, err := s.BlobsUploadCreate(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BlobsUploadWrite(repo, uuid, bytes.NewReader(data), -1); err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256Digest(data)
+	if err := s.BlobsUploadCommit(repo, uuid, digest); err != nil {
+		t.Fatal(err)
+	}
+	return digest
+}
+
+func newReleasesStorage() *immutable.ImmutableDataStorage {
+	return immutable.NewImmutableDataStorage(memory.NewMemoryDataStorage(0), []immutable.Rule{{
+		Name:   "releases",
+		Scopes: []regexp.Regexp{*regexp.MustCompile("^app$")},
+		Tags:   []regexp.Regexp{*regexp.MustCompile(`^v[0-9]+\.[0-9]+\.[0-9]+$`)},
+	}})
+}
+
+func TestManifestPut(t *testing.T) {
+	s := newReleasesStorage()
+
+	v1 := []byte(`{"schemaVersion":2,"annotations":{"v":"1"}}`)
+	v2 := []byte(`{"schemaVersion":2,"annotations":{"v":"2"}}`)
+
+	if _, err := s.ManifestPut("app", "v1.0.0", bytes.NewReader(v1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Retries are harmless.
+	if _, err := s.ManifestPut("app", "v1.0.0", bytes.NewReader(v1)); err != nil {
+		t.Errorf("expected same manifest accepted, got %v", err)
+	}
+
+	if _, err := s.ManifestPut("app", "v1.0.0", bytes.NewReader(v2)); !errors.Is(err, data.ErrTagImmutable) {
+		t.Errorf("expected ErrTagImmutable, got %v", err)
+	}
+	if _, _, digest, err := s.ManifestGet("app", "v1.0.0"); err != nil || digest != sha256Digest(v1) {
+		t.Errorf("expected tag kept at %s, got %s (%v)", sha256Digest(v1), digest, err)
+	}
+
+	// Mutable tags and other repositories.
+	for _, ref := range []struct{ repo, tag string }{
+		{"app", "latest"},
+		{"other", "v1.0.0"},
+	} {
+		if _, err := s.ManifestPut(ref.repo, ref.tag, bytes.NewReader(v1)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.ManifestPut(ref.repo, ref.tag, bytes.NewReader(v2)); err != nil {
+			t.Errorf("expected %s:%s mutable, got %v", ref.repo, ref.tag, err)
+		}
+	}
+}
+
+func TestManifestPut_StoredTagsOnly(t *testing.T) {
+	upstream := []byte(`{"schemaVersion":2,"annotations":{"v":"upstream"}}`)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+		w.Header().Set("Docker-Content-Digest", sha256Digest(upstream))
+		w.Write(upstream)
+	}))
+	defer srv.Close()
+
+	p := proxy.NewProxyDataStorage(memory.NewMemoryDataStorage(0), []proxy.Proxy{{
+		Url:     srv.URL,
+		Timeout: 5 * time.Second,
+		Scopes:  []string{".*"},
+	}})
+	s := newReleasesStorage()
+	s.Next = p
+
+	// The upstream tag is not stored, so it is not mirrored to be compared.
+	pushed := []byte(`{"schemaVersion":2,"annotations":{"v":"1"}}`)
+	if _, err := s.ManifestPut("app", "v1.0.0", bytes.NewReader(pushed)); err != nil {
+		t.Errorf("expected tag pushed, got %v", err)
+	}
+	if _, _, digest, err := p.Next.ManifestGet("app", "v1.0.0"); err != nil || digest != sha256Digest(pushed) {
+		t.Errorf("expected tag at %s, got %s (%v)", sha256Digest(pushed), digest, err)
+	}
+}
+
+func TestManifestDelete(t *testing.T) {
+	s := newReleasesStorage()
+
+	release := []byte(`{"schemaVersion":2,"annotations":{"v":"1"}}`)
+	other := []byte(`{"schemaVersion":2,"annotations":{"v":"2"}}`)
+
+	if _, err := s.ManifestPut("app", "v1.0.0", bytes.NewReader(release)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ManifestPut("app", "latest", bytes.NewReader(other)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.ManifestDelete("app", "v1.0.0"); !errors.Is(err, data.ErrTagImmutable) {
+		t.Errorf("expected ErrTagImmutable, got %v", err)
+	}
+	if err := s.ManifestDelete("app", sha256Digest(release)); !errors.Is(err, data.ErrTagImmutable) {
+		t.Errorf("expected ErrTagImmutable, got %v", err)
+	}
+
+	// Missing immutable tags are not found.
+	if err := s.ManifestDelete("app", "v9.9.9"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+
+	if err := s.ManifestDelete("app", "latest"); err != nil {
+		t.Error(err)
+	}
+	if err := s.ManifestDelete("app", sha256Digest(other)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRuleMatchEveryTag(t *testing.T) {
+	r := immutable.Rule{Scopes: []regexp.Regexp{*regexp.MustCompile("^app$")}}
+
+	if !r.Match("app", "latest") {
+		t.Error("expected every tag matched")
+	}
+	if r.Match("other", "latest") {
+		t.Error("expected other repositories not matched")
+	}
+}
+
+func TestRepositoryDelete(t *testing.T) {
+	s := newReleasesStorage()
+
+	manifest := []byte(`{"schemaVersion":2}`)
+	if _, err := s.ManifestPut("app", "v1.0.0", bytes.NewReader(manifest)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ManifestPut("other", "v1.0.0", bytes.NewReader(manifest)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.RepositoryDelete("app"); !errors.Is(err, data.ErrTagImmutable) {
+		t.Errorf("expected ErrTagImmutable, got %v", err)
+	}
+	if _, _, _, err := s.ManifestGet("app", "v1.0.0"); err != nil {
+		t.Errorf("expected repository kept, got %v", err)
+	}
+
+	// Repositories without immutable tags could be deleted.
+	if err := s.RepositoryDelete("other"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := s.ManifestGet("other", "v1.0.0"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package immutable is a DataStorage decorator to forbid moving or deleting
+// the tags matched by some rules, like release tags.
+package immutable
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+)
+
+// Rule declares as immutable the tags matched by any of Tags, in the
+// repositories matched by any of Scopes.
+type Rule struct {
+	Name   string
+	Scopes []regexp.Regexp // Regular expressions matching the repository path.
+	Tags   []regexp.Regexp // Regular expressions matching the tag, every tag if empty.
+}
+
+type ImmutableDataStorage struct {
+	Next  data.DataStorage
+	Rules []Rule
+
+	// mu serializes the pushes of immutable tags and the deletions, so two
+	// pushes could not set the same tag to different digests, nor a deletion
+	// remove a manifest while it is tagged as immutable.
+	mu sync.Mutex
+}
+
+func NewImmutableDataStorage(ds data.DataStorage, rules []Rule) *ImmutableDataStorage {
+	return &ImmutableDataStorage{Next: ds, Rules: rules}
+}
@@ -0,0 +1,196 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package immutable
+
+import (
+	"io"
+	"iter"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+)
+
+// Blobs upload
+
+func (s *ImmutableDataStorage) BlobsUploadCreate(repo string) (uuid string, err error) {
+	if s.Next == nil {
+		return "", ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsUploadCreate(repo)
+}
+func (s *ImmutableDataStorage) BlobsUploadCancel(repo, uuid string) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsUploadCancel(repo, uuid)
+}
+func (s *ImmutableDataStorage) BlobsUploadWrite(repo, uuid string, r io.Reader, start int64) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsUploadWrite(repo, uuid, r, start)
+}
+func (s *ImmutableDataStorage) BlobsUploadCommit(repo, uuid, digest string) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsUploadCommit(repo, uuid, digest)
+}
+func (s *ImmutableDataStorage) BlobsUploadSize(repo, uuid string) (size int64, err error) {
+	if s.Next == nil {
+		return -1, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsUploadSize(repo, uuid)
+}
+
+func (s *ImmutableDataStorage) BlobsUploadStartedAt(repo, uuid string) (startedAt time.Time, err error) {
+	if s.Next == nil {
+		return time.Now(), ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsUploadStartedAt(repo, uuid)
+}
+
+func (s *ImmutableDataStorage) BlobsUploadsList() (uploads iter.Seq[data.Upload], err error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsUploadsList()
+}
+
+// Blobs
+
+func (s *ImmutableDataStorage) BlobsGet(repo, digest string) (r io.ReadCloser, size int64, err error) {
+	if s.Next == nil {
+		return nil, -1, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsGet(repo, digest)
+}
+func (s *ImmutableDataStorage) BlobsDelete(repo, digest string) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsDelete(repo, digest)
+}
+func (s *ImmutableDataStorage) BlobsList() (digests iter.Seq[string], err error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsList()
+}
+func (s *ImmutableDataStorage) BlobLastAccess(digest string) (lastAccess time.Time, err error) {
+	if s.Next == nil {
+		return time.Now(), ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobLastAccess(digest)
+}
+
+func (s *ImmutableDataStorage) BlobRepositories(digest string) (repos []string, err error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobRepositories(digest)
+}
+func (s *ImmutableDataStorage) BlobsLink(repo, digest string) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsLink(repo, digest)
+}
+
+// Manifests
+
+func (s *ImmutableDataStorage) ManifestGet(repo, reference string) (
+	r io.ReadCloser,
+	size int64,
+	digest string,
+	err error,
+) {
+	if s.Next == nil {
+		return nil, -1, "", ErrDataStorageNotInitialized
+	}
+
+	return s.Next.ManifestGet(repo, reference)
+}
+func (s *ImmutableDataStorage) ManifestsList(repo string) (digests iter.Seq[string], err error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.ManifestsList(repo)
+}
+func (s *ImmutableDataStorage) ManifestLastAccess(digest string) (lastAccess time.Time, err error) {
+	if s.Next == nil {
+		return time.Now(), ErrDataStorageNotInitialized
+	}
+
+	return s.Next.ManifestLastAccess(digest)
+}
+
+// Tags
+
+func (s *ImmutableDataStorage) TagsList(repo string) ([]string, error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.TagsList(repo)
+}
+func (s *ImmutableDataStorage) TagsListPage(repo string, n int, last string) ([]string, error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return data.TagsListPage(s.Next, repo, n, last)
+}
+func (s *ImmutableDataStorage) TagLastModified(repo, tag string) (lastModified time.Time, err error) {
+	if s.Next == nil {
+		return time.Now(), ErrDataStorageNotInitialized
+	}
+
+	return s.Next.TagLastModified(repo, tag)
+}
+
+// Repositories
+
+func (s *ImmutableDataStorage) RepositoriesList() ([]string, error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.RepositoriesList()
+}
+
+// Referrers
+
+func (s *ImmutableDataStorage) ReferrersGet(repo, manifestDigest string) (digests iter.Seq[string], err error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.ReferrersGet(repo, manifestDigest)
+}
@@ -0,0 +1,180 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"bytes"
+	"io"
+	"iter"
+	"slices"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	d "github.com/jlsalvador/simple-registry/pkg/digest"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+func (s *MemoryDataStorage) BlobsGet(repo, digest string) (r io.ReadCloser, size int64, err error) {
+	_, hash, err := d.Parse(digest)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	if len(hash) < 2 {
+		return nil, -1, data.ErrHashShort
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if repo != "" {
+		// Check repository link
+		rp := s.repo(repo, false)
+		if rp == nil || !rp.layers.Contains(digest) {
+			return nil, -1, errNotExist("cannot read link %s@%s", repo, digest)
+		}
+	}
+
+	b, ok := s.blobs[digest]
+	if !ok {
+		return nil, -1, errNotExist("cannot open blob %s", digest)
+	}
+	b.lastAccess = time.Now()
+
+	return io.NopCloser(bytes.NewReader(b.data)), int64(len(b.data)), nil
+}
+
+func (s *MemoryDataStorage) BlobsDelete(repo, digest string) error {
+	_, hash, err := d.Parse(digest)
+	if err != nil {
+		return err
+	}
+
+	if len(hash) < 2 {
+		return data.ErrHashShort
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if repo != "" {
+		// Repo is not empty, so only delete repo blob link.
+		rp := s.repo(repo, false)
+		if rp == nil || !rp.layers.Contains(digest) {
+			return errNotExist("cannot read link %s@%s", repo, digest)
+		}
+		delete(rp.layers, digest)
+		return nil
+	}
+
+	// Repo is empty, so only delete the blob.
+	b, ok := s.blobs[digest]
+	if !ok {
+		return errNotExist("cannot open blob %s", digest)
+	}
+	s.used -= int64(len(b.data))
+	delete(s.blobs, digest)
+
+	return nil
+}
+
+func (s *MemoryDataStorage) BlobsList() (digests iter.Seq[string], err error) {
+	s.mu.RLock()
+	list := make([]string, 0, len(s.blobs))
+	for digest := range s.blobs {
+		list = append(list, digest)
+	}
+	s.mu.RUnlock()
+
+	return func(yield func(string) bool) {
+		for _, digest := range list {
+			if !yield(digest) {
+				return
+			}
+		}
+	}, nil
+}
+
+func (s *MemoryDataStorage) BlobLastAccess(digest string) (lastAccess time.Time, err error) {
+	_, hash, err := d.Parse(digest)
+	if err != nil {
+		return time.Now(), err
+	}
+
+	if len(hash) < 2 {
+		return time.Now(), data.ErrDigestMismatch
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	b, ok := s.blobs[digest]
+	if !ok {
+		return time.Now(), errNotExist("cannot open blob %s", digest)
+	}
+
+	return b.lastAccess, nil
+}
+
+func (s *MemoryDataStorage) BlobsLink(repo, digest string) error {
+	if !registry.RegExprName.MatchString(repo) {
+		return data.ErrRepoInvalid
+	}
+
+	_, hash, err := d.Parse(digest)
+	if err != nil {
+		return err
+	}
+
+	if len(hash) < 2 {
+		return data.ErrHashShort
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.blobs[digest]; !ok {
+		return errNotExist("cannot find blob %s", digest)
+	}
+	s.repo(repo, true).layers.Add(digest)
+
+	return nil
+}
+
+// BlobRepositories returns the repositories linking the blob. Repositories
+// are kept in memory, so they are the index.
+func (s *MemoryDataStorage) BlobRepositories(digest string) (repos []string, err error) {
+	_, hash, err := d.Parse(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(hash) < 2 {
+		return nil, data.ErrHashShort
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	repos = []string{}
+	for name, rp := range s.repos {
+		if rp.layers.Contains(digest) {
+			repos = append(repos, name)
+		}
+	}
+	slices.Sort(repos)
+
+	return repos, nil
+}
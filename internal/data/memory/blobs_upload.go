@@ -0,0 +1,272 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"bytes"
+	"io"
+	"iter"
+	"slices"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	d "github.com/jlsalvador/simple-registry/pkg/digest"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+	u "github.com/jlsalvador/simple-registry/pkg/uuid"
+)
+
+// upload returns the upload in progress.
+//
+// Caller must hold the lock.
+func (s *MemoryDataStorage) upload(repo, uuid string) (*upload, error) {
+	if rp := s.repo(repo, false); rp != nil {
+		if up, ok := rp.uploads[uuid]; ok {
+			return up, nil
+		}
+	}
+	return nil, errNotExist("upload %s not found in %s", uuid, repo)
+}
+
+// BlobsUploadCreate creates a new blob upload session for the given
+// repository, and returns the upload uuid.
+func (s *MemoryDataStorage) BlobsUploadCreate(repo string) (uuid string, err error) {
+	if !registry.RegExprName.MatchString(repo) {
+		return "", data.ErrRepoInvalid
+	}
+
+	uuid = u.MustNew().String()
+
+	h, err := d.NewHasher(d.Canonical)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.repo(repo, true).uploads[uuid] = &upload{startedAt: time.Now().UTC(), hasher: h}
+
+	return uuid, nil
+}
+
+// BlobsUploadCancel cancels a blob upload in progress.
+func (s *MemoryDataStorage) BlobsUploadCancel(repo, uuid string) error {
+	if !registry.RegExprName.MatchString(repo) {
+		return data.ErrRepoInvalid
+	}
+	if !registry.RegExprUUID.MatchString(uuid) {
+		return data.ErrUUIDInvalid
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	up, err := s.upload(repo, uuid)
+	if err != nil {
+		return err
+	}
+	s.used -= int64(len(up.data))
+	delete(s.repos[repo].uploads, uuid)
+
+	return nil
+}
+
+// BlobsUploadWrite writes data to an blob upload in progress.
+//
+// If "start" is less than 0, the data will be appended to the end of the
+// upload. If "start" is beyond the end of the upload, the gap is filled with
+// zeros.
+//
+// It returns [data.ErrStorageFull] if the storage limit would be exceeded,
+// leaving the upload untouched.
+func (s *MemoryDataStorage) BlobsUploadWrite(repo, uuid string, r io.Reader, start int64) error {
+	if !registry.RegExprName.MatchString(repo) {
+		return data.ErrRepoInvalid
+	}
+	if !registry.RegExprUUID.MatchString(uuid) {
+		return data.ErrUUIDInvalid
+	}
+
+	// Read the chunk without holding the lock, up to the available space.
+	s.mu.RLock()
+	up, err := s.upload(repo, uuid)
+	var maxRead int64 = -1
+	if err == nil && s.available() >= 0 {
+		maxRead = s.available() + int64(len(up.data)) - max(start, 0)
+	}
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	if maxRead >= 0 {
+		r = io.LimitReader(r, maxRead+1)
+	}
+	chunk, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if maxRead >= 0 && int64(len(chunk)) > maxRead {
+		return data.ErrStorageFull
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Upload could be canceled or modified meanwhile.
+	up, err = s.upload(repo, uuid)
+	if err != nil {
+		return err
+	}
+
+	oldSize := int64(len(up.data))
+	if start < 0 {
+		start = oldSize
+	}
+	newSize := max(oldSize, start+int64(len(chunk)))
+
+	if avail := s.available(); avail >= 0 && newSize-oldSize > avail {
+		return data.ErrStorageFull
+	}
+
+	if newSize > oldSize {
+		up.data = append(up.data, make([]byte, newSize-oldSize)...)
+	}
+	copy(up.data[start:], chunk)
+	s.used += newSize - oldSize
+
+	if up.hasher != nil && start == oldSize {
+		up.hasher.Write(chunk)
+	} else {
+		up.hasher = nil
+	}
+
+	return nil
+}
+
+// BlobsUploadCommit commits an blob upload in progress as a layer.
+//
+// After check uploaded data hash, the upload data will be moved as a blob and
+// linked into the repository.
+func (s *MemoryDataStorage) BlobsUploadCommit(repo, uuid, digest string) error {
+	algo, hash, err := d.Parse(digest)
+	if err != nil {
+		return err
+	}
+	if len(hash) < 2 {
+		return data.ErrHashShort
+	}
+	if !registry.RegExprName.MatchString(repo) {
+		return data.ErrRepoInvalid
+	}
+	if !registry.RegExprUUID.MatchString(uuid) {
+		return data.ErrUUIDInvalid
+	}
+
+	// The upload is hashed while holding the lock, as writes modify its data
+	// and its running hash.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	up, err := s.upload(repo, uuid)
+	if err != nil {
+		return err
+	}
+
+	// Calculate the digest of the uploaded data, from the running hash if
+	// possible.
+	hasher := up.hasher
+	if algo != d.Canonical || hasher == nil {
+		if hasher, err = d.NewHasher(algo); err != nil {
+			return err
+		}
+		if _, err := io.Copy(hasher, bytes.NewReader(up.data)); err != nil {
+			return err
+		}
+	}
+
+	// Check if the uploaded data matches the expected digest.
+	if hasher.GetHashAsString() != hash {
+		return data.ErrDigestMismatch
+	}
+
+	rp := s.repo(repo, true)
+	delete(rp.uploads, uuid)
+
+	// Replace existing blob.
+	if old, ok := s.blobs[digest]; ok {
+		s.used -= int64(len(old.data))
+	}
+	s.blobs[digest] = &blob{data: up.data, lastAccess: time.Now()}
+
+	rp.layers.Add(digest)
+
+	return nil
+}
+
+func (s *MemoryDataStorage) BlobsUploadSize(repo, uuid string) (size int64, err error) {
+	if !registry.RegExprName.MatchString(repo) {
+		return -1, data.ErrRepoInvalid
+	}
+	if !registry.RegExprUUID.MatchString(uuid) {
+		return -1, data.ErrUUIDInvalid
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	up, err := s.upload(repo, uuid)
+	if err != nil {
+		return -1, err
+	}
+
+	return int64(len(up.data)), nil
+}
+
+func (s *MemoryDataStorage) BlobsUploadStartedAt(repo, uuid string) (startedAt time.Time, err error) {
+	if !registry.RegExprName.MatchString(repo) {
+		return time.Now(), data.ErrRepoInvalid
+	}
+	if !registry.RegExprUUID.MatchString(uuid) {
+		return time.Now(), data.ErrUUIDInvalid
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	up, err := s.upload(repo, uuid)
+	if err != nil {
+		return time.Now(), err
+	}
+
+	return up.startedAt, nil
+}
+
+func (s *MemoryDataStorage) BlobsUploadsList() (uploads iter.Seq[data.Upload], err error) {
+	s.mu.RLock()
+	var list []data.Upload
+	for name, rp := range s.repos {
+		for uuid, up := range rp.uploads {
+			list = append(list, data.Upload{
+				Repo:      name,
+				UUID:      uuid,
+				StartedAt: up.startedAt,
+			})
+		}
+	}
+	s.mu.RUnlock()
+
+	return slices.Values(list), nil
+}
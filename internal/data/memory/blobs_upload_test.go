@@ -0,0 +1,228 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory_test
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/internal/data/memory"
+)
+
+func TestBlobsUploadCreate(t *testing.T) {
+	s := memory.NewMemoryDataStorage(0)
+
+	if _, err := s.BlobsUploadCreate("THIS IS INVALID"); !errors.Is(err, data.ErrRepoInvalid) {
+		t.Fatalf("expected ErrRepoInvalid, got %v", err)
+	}
+
+	uuid, err := s.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	size, err := s.BlobsUploadSize("repo", uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 0 {
+		t.Errorf("expected empty upload, got %d bytes", size)
+	}
+}
+
+func TestBlobsUploadCancel(t *testing.T) {
+	s := memory.NewMemoryDataStorage(0)
+
+	if err := s.BlobsUploadCancel("repo", "not-uuid"); !errors.Is(err, data.ErrUUIDInvalid) {
+		t.Fatalf("expected ErrUUIDInvalid, got %v", err)
+	}
+
+	uuid, err := s.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BlobsUploadCancel("repo", uuid); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.BlobsUploadSize("repo", uuid); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+	if err := s.BlobsUploadCancel("repo", uuid); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestBlobsUploadWriteOffsets(t *testing.T) {
+	s := memory.NewMemoryDataStorage(0)
+
+	uuid, err := s.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writes := []struct {
+		chunk string
+		start int64
+	}{
+		{"hello", 0},
+		{" world", -1},
+		{"W", 6},
+		{"!", 13}, // Gap filled with zeros.
+	}
+	for _, w := range writes {
+		if err := s.BlobsUploadWrite("repo", uuid, bytes.NewReader([]byte(w.chunk)), w.start); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []byte("hello World\x00\x00!")
+	digest := sha256Digest(want)
+	if err := s.BlobsUploadCommit("repo", uuid, digest); err != nil {
+		t.Fatal(err)
+	}
+
+	r, _, err := s.BlobsGet("repo", digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(r)
+	if !bytes.Equal(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestBlobsUploadCommitMismatch(t *testing.T) {
+	s := memory.NewMemoryDataStorage(0)
+
+	uuid, err := s.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BlobsUploadWrite("repo", uuid, bytes.NewReader([]byte("hello")), -1); err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.BlobsUploadCommit("repo", uuid, sha256Digest([]byte("bye")))
+	if !errors.Is(err, data.ErrDigestMismatch) {
+		t.Fatalf("expected ErrDigestMismatch, got %v", err)
+	}
+	if _, _, err := s.BlobsGet("repo", sha256Digest([]byte("bye"))); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestBlobsUploadCommitConcurrentWrites(t *testing.T) {
+	s := memory.NewMemoryDataStorage(0)
+	content := bytes.Repeat([]byte("hello"), 1<<16)
+	sum := sha512.Sum512(content)
+	// Not the running hash, so the whole upload is read.
+	digest := "sha512:" + hex.EncodeToString(sum[:])
+
+	for range 20 {
+		uuid, err := s.BlobsUploadCreate("repo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.BlobsUploadWrite("repo", uuid, bytes.NewReader(content), -1); err != nil {
+			t.Fatal(err)
+		}
+
+		// A write overwriting the upload while it is committed.
+		var wg sync.WaitGroup
+		wg.Go(func() {
+			_ = s.BlobsUploadWrite("repo", uuid, bytes.NewReader([]byte("j")), 0)
+		})
+		err = s.BlobsUploadCommit("repo", uuid, digest)
+		wg.Wait()
+		if err != nil && !errors.Is(err, data.ErrDigestMismatch) {
+			t.Fatal(err)
+		}
+
+		// The committed blob is the content hashed.
+		if err == nil {
+			r, _, err := s.BlobsGet("repo", digest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _ := io.ReadAll(r)
+			r.Close()
+			if !bytes.Equal(got, content) {
+				t.Fatalf("expected blob %q, got %q", content, got)
+			}
+		}
+	}
+}
+
+func TestBlobsUploadsList(t *testing.T) {
+	s := memory.NewMemoryDataStorage(0)
+
+	before := time.Now().Add(-time.Second)
+	uuidA, err := s.BlobsUploadCreate("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uuidB, err := s.BlobsUploadCreate("b/img")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	startedAt, err := s.BlobsUploadStartedAt("a", uuidA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if startedAt.Before(before) {
+		t.Errorf("expected started after %v, got %v", before, startedAt)
+	}
+	if _, err := s.BlobsUploadStartedAt("b/img", uuidA); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+
+	uploads, err := s.BlobsUploadsList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := slices.SortedFunc(uploads, func(a, b data.Upload) int {
+		return strings.Compare(a.Repo, b.Repo)
+	})
+	if len(got) != 2 ||
+		got[0].Repo != "a" || got[0].UUID != uuidA ||
+		got[1].Repo != "b/img" || got[1].UUID != uuidB {
+		t.Fatalf("expected uploads %s and %s, got %v", uuidA, uuidB, got)
+	}
+	if got[0].StartedAt.Before(before) {
+		t.Errorf("expected started after %v, got %v", before, got[0].StartedAt)
+	}
+
+	if err := s.BlobsUploadCancel("a", uuidA); err != nil {
+		t.Fatal(err)
+	}
+	uploads, err = s.BlobsUploadsList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := slices.Collect(uploads); len(got) != 1 || got[0].UUID != uuidB {
+		t.Errorf("expected upload %s, got %v", uuidB, got)
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"iter"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	pkgDigest "github.com/jlsalvador/simple-registry/pkg/digest"
+	"github.com/jlsalvador/simple-registry/pkg/mapset"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+const manifestAlgo = "sha256"
+
+// indexReferrer verifies if the manifest has a subject, if it so, create the
+// referrers.
+//
+// Caller must hold the write lock.
+func (s *MemoryDataStorage) indexReferrer(repo, referrerDigest string, manifestBytes []byte) error {
+	var manifest registry.ImageManifest
+
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return nil // Ignore invalid OCI 1.1.
+	}
+
+	if manifest.Subject == nil {
+		return nil
+	}
+
+	subjectDigest := manifest.Subject.Digest
+	if _, _, err := pkgDigest.Parse(subjectDigest); err != nil {
+		return err
+	}
+
+	rp := s.repo(repo, true)
+	if _, ok := rp.referrers[subjectDigest]; !ok {
+		rp.referrers[subjectDigest] = mapset.NewMapSet[string]()
+	}
+	rp.referrers[subjectDigest].Add(referrerDigest)
+
+	return nil
+}
+
+// ManifestPut stores a manifest identified by "reference" (either a tag or a
+// digest) into the repository.
+func (s *MemoryDataStorage) ManifestPut(repo, reference string, r io.Reader) (dgst string, err error) {
+	if !registry.RegExprName.MatchString(repo) {
+		return "", data.ErrRepoInvalid
+	}
+
+	hasher, err := pkgDigest.NewHasher(manifestAlgo)
+	if err != nil {
+		return "", err
+	}
+
+	data := bytes.NewBuffer([]byte{})
+	m := io.MultiWriter(hasher, data)
+	if _, err := io.Copy(m, r); err != nil {
+		return "", err
+	}
+
+	dgst = manifestAlgo + ":" + hasher.GetHashAsString()
+
+	// Store manifest blob.
+	uuid, err := s.BlobsUploadCreate(repo)
+	if err != nil {
+		return "", err
+	}
+	if err = s.BlobsUploadWrite(repo, uuid, bytes.NewReader(data.Bytes()), -1); err != nil {
+		s.BlobsUploadCancel(repo, uuid)
+		return "", err
+	}
+	if err := s.BlobsUploadCommit(repo, uuid, dgst); err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rp := s.repo(repo, true)
+	rp.hasManifests = true
+
+	// Create revision link.
+	rp.revisions.Add(dgst)
+
+	// If reference is a tag, update tag link.
+	if registry.RegExprTag.MatchString(reference) {
+		rp.tags[reference] = dgst
+		rp.pushedAt[reference] = time.Now()
+	}
+
+	// Index the manifest referrer.
+	s.indexReferrer(repo, dgst, data.Bytes())
+
+	return dgst, nil
+}
+
+// ManifestGet retrieves a manifest using either a tag or a digest.
+func (s *MemoryDataStorage) ManifestGet(repo, reference string) (
+	r io.ReadCloser,
+	size int64,
+	digest string,
+	err error,
+) {
+	if !registry.RegExprName.MatchString(repo) {
+		return nil, -1, "", data.ErrRepoInvalid
+	}
+
+	algo, hash, err := pkgDigest.Parse(reference)
+	if err == nil {
+		// If reference is a digest, use it directly.
+	} else if registry.RegExprTag.MatchString(reference) {
+		// If reference is a tag, resolve tag to digest.
+		s.mu.RLock()
+		var link string
+		if rp := s.repo(repo, false); rp != nil {
+			link = rp.tags[reference]
+		}
+		s.mu.RUnlock()
+		if link == "" {
+			return nil, -1, "", errNotExist("tag %s not found in %s", reference, repo)
+		}
+		algo, hash, err = pkgDigest.Parse(link)
+		if err != nil {
+			return nil, -1, "", err
+		}
+	}
+	if len(hash) < 2 {
+		return nil, -1, "", data.ErrHashShort
+	}
+
+	digest = algo + ":" + hash
+
+	// Open the actual blob manifest.
+	r, size, err = s.BlobsGet("", digest)
+	if err != nil {
+		return nil, -1, "", err
+	}
+
+	return r, size, digest, nil
+}
+
+func (s *MemoryDataStorage) ManifestDelete(repo, reference string) error {
+	if !registry.RegExprName.MatchString(repo) {
+		return data.ErrRepoInvalid
+	}
+
+	// Case 1: reference is a tag.
+	if registry.RegExprTag.MatchString(reference) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		rp := s.repo(repo, false)
+		if rp == nil {
+			return errNotExist("tag %s not found in %s", reference, repo)
+		}
+		if _, ok := rp.tags[reference]; !ok {
+			return errNotExist("tag %s not found in %s", reference, repo)
+		}
+		delete(rp.tags, reference)
+		delete(rp.pushedAt, reference)
+		return nil
+	}
+
+	// Case 2: reference must be a digest.
+	if _, _, err := pkgDigest.Parse(reference); err != nil {
+		return errors.Join(data.ErrDigestInvalid, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rp := s.repo(repo, false)
+	if rp == nil {
+		return errNotExist("manifest %s not found in %s", reference, repo)
+	}
+
+	// Delete the referrers.
+	for subject, referrers := range rp.referrers {
+		delete(referrers, reference)
+		if len(referrers) == 0 {
+			delete(rp.referrers, subject)
+		}
+	}
+
+	// Delete the revision.
+	if !rp.revisions.Contains(reference) {
+		return errNotExist("manifest %s not found in %s", reference, repo)
+	}
+	delete(rp.revisions, reference)
+
+	return nil
+}
+
+func (s *MemoryDataStorage) ManifestsList(repo string) (digests iter.Seq[string], err error) {
+	digestsSet := mapset.NewMapSet[string]()
+
+	s.mu.RLock()
+	if rp := s.repo(repo, false); rp != nil {
+		// 1. Revisions
+		for digest := range rp.revisions {
+			digestsSet.Add(digest)
+		}
+
+		// 2. Referrers
+		for _, referrers := range rp.referrers {
+			for digest := range referrers {
+				digestsSet.Add(digest)
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	return func(yield func(string) bool) {
+		for digest := range digestsSet {
+			if !yield(digest) {
+				return
+			}
+		}
+	}, nil
+}
+
+func (s *MemoryDataStorage) ManifestLastAccess(digest string) (lastAccess time.Time, err error) {
+	// Our manifests are stored as blobs, so return its blob last access time.
+	return s.BlobLastAccess(digest)
+}
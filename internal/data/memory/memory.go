@@ -0,0 +1,119 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package memory is a DataStorage implementation keeping everything in memory.
+//
+// It is intended for tests and ephemeral registries, as all the data is lost
+// when the process exits.
+package memory
+
+import (
+	"fmt"
+	"io/fs"
+	"sync"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/pkg/hasher"
+	"github.com/jlsalvador/simple-registry/pkg/mapset"
+)
+
+type blob struct {
+	data       []byte
+	lastAccess time.Time
+}
+
+type upload struct {
+	data      []byte
+	startedAt time.Time
+
+	// hasher is the running hash of data with [digest.Canonical], or nil after
+	// an out of order write.
+	hasher hasher.Hasher
+}
+
+type repository struct {
+	layers    mapset.MapSet[string]
+	revisions mapset.MapSet[string]
+	tags      map[string]string                // Tag to manifest digest.
+	pushedAt  map[string]time.Time             // Tag to its last push time.
+	referrers map[string]mapset.MapSet[string] // Subject to referrer digests.
+	uploads   map[string]*upload
+
+	// hasManifests is true once a manifest has been pushed, as the
+	// filesystem "_manifests" directory.
+	hasManifests bool
+}
+
+type MemoryDataStorage struct {
+	mu sync.RWMutex
+
+	limit int64 // Maximum stored bytes, unlimited if less or equal than 0.
+	used  int64
+
+	blobs map[string]*blob // Digest to blob.
+	repos map[string]*repository
+}
+
+// NewMemoryDataStorage returns an empty DataStorage which could store up to
+// limit bytes of blobs and uploads in progress.
+//
+// The storage is unlimited if limit is less or equal than 0.
+func NewMemoryDataStorage(limit int64) *MemoryDataStorage {
+	return &MemoryDataStorage{
+		limit: limit,
+		blobs: map[string]*blob{},
+		repos: map[string]*repository{},
+	}
+}
+
+// Used returns the stored bytes of blobs and uploads in progress.
+func (s *MemoryDataStorage) Used() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.used
+}
+
+// available returns how many bytes could be stored yet, or -1 if unlimited.
+//
+// Caller must hold the lock.
+func (s *MemoryDataStorage) available() int64 {
+	if s.limit <= 0 {
+		return -1
+	}
+	return max(s.limit-s.used, 0)
+}
+
+// repo returns the repository named name, creating it if create is true.
+//
+// Caller must hold the write lock if create is true.
+func (s *MemoryDataStorage) repo(name string, create bool) *repository {
+	r, ok := s.repos[name]
+	if !ok && create {
+		r = &repository{
+			layers:    mapset.NewMapSet[string](),
+			revisions: mapset.NewMapSet[string](),
+			tags:      map[string]string{},
+			pushedAt:  map[string]time.Time{},
+			referrers: map[string]mapset.MapSet[string]{},
+			uploads:   map[string]*upload{},
+		}
+		s.repos[name] = r
+	}
+	return r
+}
+
+func errNotExist(format string, a ...any) error {
+	return fmt.Errorf("%s: %w", fmt.Sprintf(format, a...), fs.ErrNotExist)
+}
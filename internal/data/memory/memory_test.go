@@ -0,0 +1,111 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/internal/data/memory"
+)
+
+func sha256Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// putBlob uploads data as a blob into repo and returns its digest.
+func putBlob(t *testing.T, s *memory.MemoryDataStorage, repo string, data []byte) string {
+	t.Helper()
+
+	uuid, err := s.BlobsUploadCreate(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BlobsUploadWrite(repo, uuid, bytes.NewReader(data), -1); err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256Digest(data)
+	if err := s.BlobsUploadCommit(repo, uuid, digest); err != nil {
+		t.Fatal(err)
+	}
+	return digest
+}
+
+func TestUsed(t *testing.T) {
+	s := memory.NewMemoryDataStorage(0)
+
+	digest := putBlob(t, s, "repo", []byte("hello"))
+	if s.Used() != 5 {
+		t.Fatalf("expected 5 used bytes, got %d", s.Used())
+	}
+
+	// Same blob is stored once.
+	putBlob(t, s, "other", []byte("hello"))
+	if s.Used() != 5 {
+		t.Fatalf("expected 5 used bytes, got %d", s.Used())
+	}
+
+	if err := s.BlobsDelete("", digest); err != nil {
+		t.Fatal(err)
+	}
+	if s.Used() != 0 {
+		t.Errorf("expected 0 used bytes, got %d", s.Used())
+	}
+}
+
+func TestLimit(t *testing.T) {
+	s := memory.NewMemoryDataStorage(8)
+
+	putBlob(t, s, "repo", []byte("hello"))
+
+	uuid, err := s.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.BlobsUploadWrite("repo", uuid, bytes.NewReader([]byte("world")), -1)
+	if !errors.Is(err, data.ErrStorageFull) {
+		t.Fatalf("expected ErrStorageFull, got %v", err)
+	}
+
+	// Failed writes leave the upload untouched.
+	size, err := s.BlobsUploadSize("repo", uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 0 {
+		t.Errorf("expected empty upload, got %d bytes", size)
+	}
+
+	// There is room for a smaller chunk.
+	if err := s.BlobsUploadWrite("repo", uuid, bytes.NewReader([]byte("abc")), -1); err != nil {
+		t.Fatal(err)
+	}
+	if s.Used() != 8 {
+		t.Errorf("expected 8 used bytes, got %d", s.Used())
+	}
+
+	// Canceled uploads release their memory.
+	if err := s.BlobsUploadCancel("repo", uuid); err != nil {
+		t.Fatal(err)
+	}
+	if s.Used() != 5 {
+		t.Errorf("expected 5 used bytes, got %d", s.Used())
+	}
+}
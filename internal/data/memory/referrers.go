@@ -0,0 +1,52 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"iter"
+
+	pkgDigest "github.com/jlsalvador/simple-registry/pkg/digest"
+)
+
+func (s *MemoryDataStorage) ReferrersGet(
+	repo,
+	manifestDigest string,
+) (digests iter.Seq[string], err error) {
+	if _, _, err := pkgDigest.Parse(manifestDigest); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	var list []string
+	rp := s.repo(repo, false)
+	if rp != nil {
+		for digest := range rp.referrers[manifestDigest] {
+			list = append(list, digest)
+		}
+	}
+	s.mu.RUnlock()
+
+	if len(list) == 0 {
+		return nil, errNotExist("no referrers for %s in %s", manifestDigest, repo)
+	}
+
+	return func(yield func(string) bool) {
+		for _, digest := range list {
+			if !yield(digest) {
+				return
+			}
+		}
+	}, nil
+}
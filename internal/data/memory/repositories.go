@@ -0,0 +1,60 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"slices"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+func (s *MemoryDataStorage) RepositoriesList() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var repos []string
+	for name, rp := range s.repos {
+		// Same as the filesystem storage, only repositories with manifests.
+		if rp.hasManifests {
+			repos = append(repos, name)
+		}
+	}
+	slices.Sort(repos)
+
+	return repos, nil
+}
+
+func (s *MemoryDataStorage) RepositoryDelete(repo string) error {
+	if !registry.RegExprName.MatchString(repo) {
+		return data.ErrRepoInvalid
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rp := s.repo(repo, false)
+	if rp == nil {
+		return errNotExist("repository %s", repo)
+	}
+
+	// Uploads in progress count as used bytes, blobs are kept.
+	for _, up := range rp.uploads {
+		s.used -= int64(len(up.data))
+	}
+	delete(s.repos, repo)
+
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory_test
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+
+	"github.com/jlsalvador/simple-registry/internal/data/memory"
+)
+
+func TestRepositoriesList(t *testing.T) {
+	s := memory.NewMemoryDataStorage(0)
+
+	repos, err := s.RepositoriesList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 0 {
+		t.Fatalf("expected no repositories, got %v", repos)
+	}
+
+	for _, repo := range []string{"library/nginx", "alpine", "library/nginx"} {
+		if _, err := s.ManifestPut(repo, "latest", bytes.NewReader(createTestManifest(nil))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Blob only repositories are not listed.
+	putBlob(t, s, "blobs-only", []byte("hello"))
+
+	repos, err = s.RepositoriesList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"alpine", "library/nginx"}; !slices.Equal(repos, want) {
+		t.Errorf("expected %v, got %v", want, repos)
+	}
+}
+
+func TestRepositoryDelete(t *testing.T) {
+	s := memory.NewMemoryDataStorage(0)
+
+	digest := putBlob(t, s, "app", []byte("hello"))
+	manifest := createTestManifest(nil)
+	if _, err := s.ManifestPut("app", "latest", bytes.NewReader(manifest)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ManifestPut("app/nested", "latest", bytes.NewReader(manifest)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.BlobsUploadCreate("app"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.RepositoryDelete("app"); err != nil {
+		t.Fatal(err)
+	}
+
+	repos, err := s.RepositoriesList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"app/nested"}; !slices.Equal(repos, want) {
+		t.Errorf("expected %v, got %v", want, repos)
+	}
+	if _, _, _, err := s.ManifestGet("app", "latest"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+	if repos, err := s.BlobRepositories(digest); err != nil || len(repos) != 0 {
+		t.Errorf("expected no repositories, got %v (%v)", repos, err)
+	}
+
+	// The blob is kept for the garbage collector.
+	if _, _, err := s.BlobsGet("", digest); err != nil {
+		t.Errorf("expected blob kept, got %v", err)
+	}
+
+	if err := s.RepositoryDelete("app"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"slices"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+func (s *MemoryDataStorage) TagsList(repo string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rp := s.repo(repo, false)
+	if rp == nil || !rp.hasManifests {
+		return nil, errNotExist("repository %s not found", repo)
+	}
+
+	if len(rp.tags) == 0 {
+		return nil, nil
+	}
+
+	tags := make([]string, 0, len(rp.tags))
+	for tag := range rp.tags {
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+
+	return tags, nil
+}
+
+func (s *MemoryDataStorage) TagLastModified(repo, tag string) (lastModified time.Time, err error) {
+	if !registry.RegExprTag.MatchString(tag) {
+		return time.Now(), data.ErrTagInvalid
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rp := s.repo(repo, false)
+	if rp == nil {
+		return time.Now(), errNotExist("repository %s not found", repo)
+	}
+
+	pushedAt, ok := rp.pushedAt[tag]
+	if !ok {
+		return time.Now(), errNotExist("tag %s not found", tag)
+	}
+
+	return pushedAt, nil
+}
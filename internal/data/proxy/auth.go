@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 )
 
 type BearerChallenge struct {
@@ -63,9 +64,15 @@ func ParseBearerChallenge(h string) (*BearerChallenge, error) {
 }
 
 func FetchBearerToken(proxy *Proxy, ch *BearerChallenge) (string, error) {
+	tok, _, err := fetchBearerToken(proxy.client(), proxy, ch)
+	return tok, err
+}
+
+// fetchBearerToken returns the token for the challenge, and its lifetime.
+func fetchBearerToken(c *Client, proxy *Proxy, ch *BearerChallenge) (string, time.Duration, error) {
 	req, err := http.NewRequest(http.MethodGet, ch.Realm, nil)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 
 	q := req.URL.Query()
@@ -81,29 +88,34 @@ func FetchBearerToken(proxy *Proxy, ch *BearerChallenge) (string, error) {
 		req.SetBasicAuth(proxy.Username, proxy.Password)
 	}
 
-	//TODO: recreate or reuse the previous http client for upstream.
-
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.http.Do(req)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("token request failed: %s", resp.Status)
+		return "", 0, fmt.Errorf("token request failed: %s", resp.Status)
 	}
 
 	var out struct {
 		Token       string `json:"token"`
 		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return "", err
+		return "", 0, err
+	}
+
+	// The token lasts 60 seconds if not told otherwise.
+	expiresIn := 60 * time.Second
+	if out.ExpiresIn > 0 {
+		expiresIn = time.Duration(out.ExpiresIn) * time.Second
 	}
 
 	if out.Token != "" {
-		return out.Token, nil
+		return out.Token, expiresIn, nil
 	}
-	return out.AccessToken, nil
+	return out.AccessToken, expiresIn, nil
 }
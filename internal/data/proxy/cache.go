@@ -0,0 +1,150 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import "time"
+
+// cache remembers the tags checked against upstream and the upstream misses,
+// until they expire, and the tags listed by upstream.
+type cache struct {
+	tags   expiring // Tags, and tags lists, checked against upstream.
+	misses expiring // Upstream misses.
+
+	// Last tags listed by upstream, by repository, served when it fails.
+	tagsLists map[string][]string
+}
+
+// minPurge is the amount of keys of an [expiring] before purging the expired
+// ones.
+const minPurge = 64
+
+// expiring remembers keys until they expire.
+type expiring struct {
+	expiresAt map[string]time.Time
+
+	// purgeAt is the amount of keys purging the expired ones on the next set.
+	// It is twice the keys left after a purge, so set takes amortized constant
+	// time.
+	purgeAt int
+}
+
+// set remembers key until now plus ttl.
+func (e *expiring) set(key string, now time.Time, ttl time.Duration) {
+	if e.expiresAt == nil {
+		e.expiresAt = map[string]time.Time{}
+	}
+	if len(e.expiresAt) >= e.purgeAt {
+		for k, expiresAt := range e.expiresAt {
+			if !now.Before(expiresAt) {
+				delete(e.expiresAt, k)
+			}
+		}
+		e.purgeAt = max(2*len(e.expiresAt), minPurge)
+	}
+	e.expiresAt[key] = now.Add(ttl)
+}
+
+// has returns if key is remembered at now, forgetting it if expired.
+func (e *expiring) has(key string, now time.Time) bool {
+	expiresAt, ok := e.expiresAt[key]
+	if ok && !now.Before(expiresAt) {
+		delete(e.expiresAt, key)
+		return false
+	}
+	return ok
+}
+
+func manifestKey(repo, reference string) string {
+	return "manifests/" + repo + "/" + reference
+}
+
+func tagsListKey(repo string) string {
+	return "tags/" + repo
+}
+
+func blobKey(repo, digest string) string {
+	return "blobs/" + repo + "/" + digest
+}
+
+// isTagFresh returns if the tag was checked against upstream within the TTL.
+func (s *ProxyDataStorage) isTagFresh(repo, tag string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache.tags.has(manifestKey(repo, tag), time.Now())
+}
+
+// tagChecked remembers that the tag is up to date with upstream.
+func (s *ProxyDataStorage) tagChecked(proxy *Proxy, repo, tag string) {
+	if proxy.TTL <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache.tags.set(manifestKey(repo, tag), time.Now(), proxy.TTL)
+}
+
+// isMiss returns if upstream did not find key within the negative TTL.
+func (s *ProxyDataStorage) isMiss(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache.misses.has(key, time.Now())
+}
+
+// missed remembers that upstream did not find key.
+func (s *ProxyDataStorage) missed(proxy *Proxy, key string) {
+	if proxy.NegativeTTL <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache.misses.set(key, time.Now(), proxy.NegativeTTL)
+}
+
+// tagsListed remembers the tags listed by upstream for repo, or forgets them
+// if nil.
+func (s *ProxyDataStorage) tagsListed(proxy *Proxy, repo string, tags []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tags == nil {
+		delete(s.cache.tagsLists, repo)
+		return
+	}
+	if s.cache.tagsLists == nil {
+		s.cache.tagsLists = map[string][]string{}
+	}
+	s.cache.tagsLists[repo] = tags
+
+	if proxy.TTL > 0 {
+		s.cache.tags.set(tagsListKey(repo), time.Now(), proxy.TTL)
+	}
+}
+
+// isTagsListFresh returns if the tags of repo were listed by upstream within
+// the TTL.
+func (s *ProxyDataStorage) isTagsListFresh(repo string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache.tags.has(tagsListKey(repo), time.Now())
+}
+
+// listedTags returns the last tags listed by upstream for repo.
+func (s *ProxyDataStorage) listedTags(repo string) ([]string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tags, ok := s.cache.tagsLists[repo]
+	return tags, ok
+}
@@ -0,0 +1,52 @@
+// Copyright 2026 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestExpiring(t *testing.T) {
+	var e expiring
+	now := time.Now()
+
+	e.set("key", now, time.Minute)
+	if !e.has("key", now) {
+		t.Error("expected key remembered")
+	}
+
+	// Expired keys are forgotten when got.
+	if e.has("key", now.Add(time.Minute)) {
+		t.Error("expected key expired")
+	}
+	if _, ok := e.expiresAt["key"]; ok {
+		t.Error("expected expired key forgotten")
+	}
+
+	// Expired keys are purged once the cache doubles.
+	for i := range minPurge {
+		e.set(fmt.Sprint(i), now, time.Minute)
+	}
+	later := now.Add(time.Minute)
+	e.set("fresh", later, time.Minute)
+	if len(e.expiresAt) != 1 {
+		t.Errorf("expected 1 key, got %d", len(e.expiresAt))
+	}
+	if !e.has("fresh", later) {
+		t.Error("expected fresh key remembered")
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenLeeway is subtracted to the lifetime of the bearer tokens, so they are
+// not used right before they expire.
+const tokenLeeway = 10 * time.Second
+
+// ClientConfig are the connection settings of an upstream.
+type ClientConfig struct {
+	Timeout time.Duration
+
+	CAFile    string // PEM bundle of the CAs trusted besides the system ones.
+	CertFile  string // PEM client certificate, along with KeyFile.
+	KeyFile   string
+	HTTPProxy string // Like "http://proxy.example.com:3128". From the environment if empty.
+}
+
+// Client sends the requests to an upstream, reusing its connections and the
+// bearer tokens until they expire.
+type Client struct {
+	http *http.Client
+
+	mu     sync.Mutex
+	tokens map[string]token // By repository url, like "https://ghcr.io/v2/my/repo".
+}
+
+type token struct {
+	value     string
+	expiresAt time.Time
+}
+
+// NewClient returns a client for an upstream with the given settings.
+func NewClient(cfg ClientConfig) (*Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.HTTPProxy != "" {
+		u, err := url.Parse(cfg.HTTPProxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid http proxy: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return newClient(cfg.Timeout, transport), nil
+}
+
+func newClient(timeout time.Duration, transport http.RoundTripper) *Client {
+	return &Client{
+		http:   &http.Client{Timeout: timeout, Transport: transport},
+		tokens: map[string]token{},
+	}
+}
+
+// client returns the client of the proxy, or a new one without any setting
+// but the timeout if it has none.
+func (p *Proxy) client() *Client {
+	if p.Client != nil {
+		return p.Client
+	}
+	return newClient(p.Timeout, nil)
+}
+
+// Do sends req to the upstream of proxy. If the upstream requires a bearer
+// token, it is fetched and cached for the next requests of the repository.
+func (c *Client) Do(proxy *Proxy, req *http.Request) (*http.Response, error) {
+	key := tokenKey(req)
+
+	var resp *http.Response
+	var err error
+	if tok, ok := c.token(key); ok {
+		var authed *http.Request
+		authed, err = withBearer(req, tok)
+		if err != nil {
+			return nil, err
+		}
+		resp, err = c.http.Do(authed)
+		if err != nil || resp.StatusCode != http.StatusUnauthorized {
+			return resp, err
+		}
+		// The token was revoked, get another one.
+		c.forget(key)
+	} else {
+		resp, err = c.http.Do(req)
+		if err != nil || resp.StatusCode != http.StatusUnauthorized {
+			return resp, err
+		}
+	}
+
+	// Upstream requires authentication.
+	// Do auth and fetch bearer token.
+
+	chHeader := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+
+	ch, err := ParseBearerChallenge(chHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	tok, expiresIn, err := fetchBearerToken(c, proxy, ch)
+	if err != nil {
+		return nil, err
+	}
+	c.store(key, tok, expiresIn)
+
+	authed, err := withBearer(req, tok)
+	if err != nil {
+		return nil, err
+	}
+	return c.http.Do(authed)
+}
+
+func (c *Client) token(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, ok := c.tokens[key]
+	if !ok || !time.Now().Before(t.expiresAt) {
+		return "", false
+	}
+	return t.value, true
+}
+
+func (c *Client) store(key, value string, expiresIn time.Duration) {
+	if expiresIn <= tokenLeeway {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Drop the expired ones, so the map does not grow forever.
+	now := time.Now()
+	for k, t := range c.tokens {
+		if !now.Before(t.expiresAt) {
+			delete(c.tokens, k)
+		}
+	}
+	c.tokens[key] = token{value: value, expiresAt: now.Add(expiresIn - tokenLeeway)}
+}
+
+func (c *Client) forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.tokens, key)
+}
+
+// tokenKey returns the url of the repository requested, which is the url
+// without the endpoint after the repository name, like "/manifests/latest",
+// "/blobs/uploads/<uuid>" or "/tags/list". The repository is the scope of
+// the bearer tokens.
+func tokenKey(req *http.Request) string {
+	u := *req.URL
+	u.RawQuery = ""
+	end := -1
+	for _, endpoint := range []string{"/manifests/", "/blobs/", "/tags/", "/referrers/"} {
+		end = max(end, strings.LastIndex(u.Path, endpoint))
+	}
+	if end >= 0 {
+		u.Path = u.Path[:end]
+	}
+	u.RawPath = ""
+	return u.String()
+}
+
+// withBearer returns a copy of req authorized with the token, with its body
+// rewound by req.GetBody if it has any.
+func withBearer(req *http.Request, tok string) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+	clone.Header.Set("Authorization", "Bearer "+tok)
+	return clone, nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy_test
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data/proxy"
+)
+
+// testBearerUpstream requires a bearer token issued by its own token server,
+// which lasts expiresIn seconds. It counts the tokens issued and the
+// challenges sent.
+func testBearerUpstream(t *testing.T, expiresIn int) (srv *httptest.Server, tokens, challenges *atomic.Int32) {
+	tokens, challenges = &atomic.Int32{}, &atomic.Int32{}
+
+	auth := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"token":      "token-" + r.URL.Query().Get("scope"),
+			"expires_in": expiresIn,
+		})
+	}))
+	t.Cleanup(auth.Close)
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-repository:repo:pull" {
+			challenges.Add(1)
+			w.Header().Set("WWW-Authenticate",
+				`Bearer realm="`+auth.URL+`",service="registry",scope="repository:repo:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, tokens, challenges
+}
+
+func TestClient_CachesTokens(t *testing.T) {
+	srv, tokens, challenges := testBearerUpstream(t, 300)
+
+	client, err := proxy.NewClient(proxy.ClientConfig{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &proxy.Proxy{Url: srv.URL, Client: client}
+
+	for _, path := range []string{"/v2/repo/blobs/sha256:a", "/v2/repo/blobs/uploads/uuid", "/v2/repo/manifests/latest", "/v2/repo/tags/list"} {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		resp, err := proxy.DoUpstreamRequest(p, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+	}
+
+	if tokens.Load() != 1 || challenges.Load() != 1 {
+		t.Errorf("expected 1 token and 1 challenge, got %d and %d", tokens.Load(), challenges.Load())
+	}
+}
+
+func TestClient_DoesNotCacheExpiringTokens(t *testing.T) {
+	srv, tokens, _ := testBearerUpstream(t, 5)
+
+	client, err := proxy.NewClient(proxy.ClientConfig{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &proxy.Proxy{Url: srv.URL, Client: client}
+
+	for range 2 {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/v2/repo/manifests/latest", nil)
+		resp, err := proxy.DoUpstreamRequest(p, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if tokens.Load() != 2 {
+		t.Errorf("expected 2 tokens, got %d", tokens.Load())
+	}
+}
+
+func TestNewClient_CAFile(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	// Not trusted by default.
+	p := &proxy.Proxy{Url: srv.URL, Timeout: 5 * time.Second}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/v2/", nil)
+	if _, err := proxy.DoUpstreamRequest(p, req); err == nil {
+		t.Fatal("expected error with an unknown certificate authority")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, ca, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := proxy.NewClient(proxy.ClientConfig{Timeout: 5 * time.Second, CAFile: caFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Client = client
+	resp, err := proxy.DoUpstreamRequest(p, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestNewClient_HTTPProxy(t *testing.T) {
+	var host atomic.Value
+	httpProxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host.Store(r.URL.Host)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer httpProxy.Close()
+
+	client, err := proxy.NewClient(proxy.ClientConfig{Timeout: 5 * time.Second, HTTPProxy: httpProxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &proxy.Proxy{Url: "http://registry.invalid", Client: client}
+
+	req, _ := http.NewRequest(http.MethodGet, p.Url+"/v2/", nil)
+	resp, err := proxy.DoUpstreamRequest(p, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if host.Load() != "registry.invalid" {
+		t.Errorf("expected request to registry.invalid through the proxy, got %v", host.Load())
+	}
+}
+
+func TestNewClient_Invalid(t *testing.T) {
+	tests := []proxy.ClientConfig{
+		{CAFile: "/does/not/exist.pem"},
+		{CertFile: "/does/not/exist.pem", KeyFile: "/does/not/exist.key"},
+		{HTTPProxy: "://invalid"},
+	}
+	for _, cfg := range tests {
+		if _, err := proxy.NewClient(cfg); err == nil {
+			t.Errorf("expected error with %+v", cfg)
+		}
+	}
+}
@@ -19,4 +19,5 @@ import "errors"
 var (
 	ErrDataStorageNotInitialized = errors.New("data storage not initialized, use NewProxyDataStorage()")
 	ErrUpstreamError             = errors.New("upstream error")
+	ErrPolicyInvalid             = errors.New("invalid pull-through cache policy")
 )
@@ -0,0 +1,64 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+// flight is an in-progress fill of the local storage from the upstream.
+type flight struct {
+	done   chan struct{}
+	digest string
+	err    error
+}
+
+// fill runs fn once for all the concurrent calls with the same key, so the
+// upstream is asked only once. The calls arriving while fn is running wait
+// for it, and get its result.
+func (s *ProxyDataStorage) fill(key string, fn func() (digest string, err error)) (digest string, err error) {
+	f, leader := s.join(key)
+	if !leader {
+		<-f.done
+		return f.digest, f.err
+	}
+
+	digest, err = fn()
+	s.land(key, f, digest, err)
+	return digest, err
+}
+
+// join returns the in-progress fill for key. If there is none, it starts a
+// new one and reports the caller as its leader, who must land it.
+func (s *ProxyDataStorage) join(key string) (f *flight, leader bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if f, ok := s.flights[key]; ok {
+		return f, false
+	}
+	if s.flights == nil {
+		s.flights = map[string]*flight{}
+	}
+	f = &flight{done: make(chan struct{})}
+	s.flights[key] = f
+	return f, true
+}
+
+// land finishes the fill for key, waking up the calls waiting for it.
+func (s *ProxyDataStorage) land(key string, f *flight, digest string, err error) {
+	s.mu.Lock()
+	delete(s.flights, key)
+	s.mu.Unlock()
+
+	f.digest, f.err = digest, err
+	close(f.done)
+}
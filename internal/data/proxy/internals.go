@@ -22,7 +22,9 @@ import (
 	"io/fs"
 	"iter"
 	"net/http"
+	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/jlsalvador/simple-registry/pkg/registry"
@@ -72,36 +74,7 @@ func DoUpstreamRequest(
 	proxy *Proxy,
 	req *http.Request,
 ) (*http.Response, error) {
-	client := &http.Client{Timeout: proxy.Timeout}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode != http.StatusUnauthorized {
-		return resp, nil
-	}
-
-	// Upstream requires authentication.
-	// Do auth and fetch bearer token.
-
-	chHeader := resp.Header.Get("WWW-Authenticate")
-	resp.Body.Close()
-
-	ch, err := ParseBearerChallenge(chHeader)
-	if err != nil {
-		return nil, err
-	}
-
-	token, err := FetchBearerToken(proxy, ch)
-	if err != nil {
-		return nil, err
-	}
-
-	req2 := req.Clone(req.Context())
-	req2.Header.Set("Authorization", "Bearer "+token)
-
-	return client.Do(req2)
+	return proxy.client().Do(proxy, req)
 }
 
 func FetchManifestFromUpstream(
@@ -182,33 +155,86 @@ func FetchBlobFromUpstream(
 	return resp.Body, resp.ContentLength, nil
 }
 
+// maxTagsPages limits the pages of tags requested to an upstream, in case it
+// links them in a loop.
+const maxTagsPages = 1000
+
+// FetchTagsFromUpstream returns all the tags of the repository, following
+// the "next" Link header of every page.
 func FetchTagsFromUpstream(
 	proxy *Proxy,
 	repo string,
 ) ([]string, error) {
-	url := fmt.Sprintf(
+	next := fmt.Sprintf(
 		"%s/v2/%s/tags/list",
 		strings.TrimRight(proxy.Url, "/"),
 		repo,
 	)
 
-	req, err := NewUpstreamRequest(proxy, http.MethodGet, url, nil)
+	tags := []string{}
+	for range maxTagsPages {
+		page, link, err := fetchTagsPage(proxy, next)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, page...)
+
+		if link == "" {
+			return tags, nil
+		}
+		next = link
+	}
+	return nil, errors.Join(ErrUpstreamError, fmt.Errorf("upstream tags exceed %d pages", maxTagsPages))
+}
+
+// FetchTagsPageFromUpstream returns up to n tags of the repository after
+// last, asking the upstream for that page only. n is unlimited if negative,
+// and last is ignored if empty.
+func FetchTagsPageFromUpstream(
+	proxy *Proxy,
+	repo string,
+	n int,
+	last string,
+) ([]string, error) {
+	query := url.Values{}
+	if n >= 0 {
+		query.Set("n", strconv.Itoa(n))
+	}
+	if last != "" {
+		query.Set("last", last)
+	}
+
+	rawUrl := fmt.Sprintf(
+		"%s/v2/%s/tags/list?%s",
+		strings.TrimRight(proxy.Url, "/"),
+		repo,
+		query.Encode(),
+	)
+
+	tags, _, err := fetchTagsPage(proxy, rawUrl)
+	return tags, err
+}
+
+// fetchTagsPage returns the tags of the page at rawUrl, and the url of the
+// next page if any.
+func fetchTagsPage(proxy *Proxy, rawUrl string) (tags []string, next string, err error) {
+	req, err := NewUpstreamRequest(proxy, http.MethodGet, rawUrl, nil)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	resp, err := DoUpstreamRequest(proxy, req)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, fs.ErrNotExist
+		return nil, "", fs.ErrNotExist
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, errors.Join(ErrUpstreamError, fmt.Errorf("upstream tags error: %s", resp.Status))
+		return nil, "", errors.Join(ErrUpstreamError, fmt.Errorf("upstream tags error: %s", resp.Status))
 	}
 
 	var out struct {
@@ -217,10 +243,30 @@ func FetchTagsFromUpstream(
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return out.Tags, nil
+	return out.Tags, nextLink(req.URL, resp.Header.Values("Link")), nil
+}
+
+// nextLink returns the absolute url of the "next" link, like
+// `</v2/repo/tags/list?n=100&last=v1>; rel="next"`, in the Link headers.
+func nextLink(base *url.URL, headers []string) string {
+	for _, h := range headers {
+		for link := range strings.SplitSeq(h, ",") {
+			target, params, ok := strings.Cut(link, ";")
+			if !ok || !strings.Contains(strings.ReplaceAll(params, " ", ""), `rel="next"`) {
+				continue
+			}
+			target = strings.Trim(strings.TrimSpace(target), "<>")
+			u, err := base.Parse(target)
+			if err != nil {
+				return ""
+			}
+			return u.String()
+		}
+	}
+	return ""
 }
 
 func FetchReferrersFromUpstream(
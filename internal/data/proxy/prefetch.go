@@ -0,0 +1,193 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/version"
+	"github.com/jlsalvador/simple-registry/pkg/log"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+// Prefetch selects the tags of a repository fetched before being pulled.
+type Prefetch struct {
+	Repository string          // Local name of the repository.
+	Tags       []regexp.Regexp // Regular expressions matching the upstream tags.
+}
+
+// prefetched counts what a prefetch stored or refreshed.
+type prefetched struct {
+	manifests int
+	blobs     int
+}
+
+// runPrefetch prefetches the images of proxy every [Proxy.PrefetchInterval],
+// or once if it is zero, until ctx is done.
+func (s *ProxyDataStorage) runPrefetch(ctx context.Context, proxy *Proxy) {
+	for {
+		for _, p := range proxy.Prefetch {
+			if ctx.Err() != nil {
+				return
+			}
+			s.prefetchRepository(proxy, p)
+		}
+
+		if proxy.PrefetchInterval <= 0 {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(proxy.PrefetchInterval):
+		}
+	}
+}
+
+// prefetchRepository prefetches the upstream tags of the repository matching
+// any of the patterns, logging the result of every one.
+func (s *ProxyDataStorage) prefetchRepository(proxy *Proxy, p Prefetch) {
+	tags, err := s.fetchTags(proxy, p.Repository, -1, "")
+	if err != nil {
+		log.Error(
+			"service.name", version.AppName,
+			"service.version", version.AppVersion,
+			"event.dataset", "internal.proxy",
+			"prefetch.repository", p.Repository,
+			"error.message", err.Error(),
+			"message", fmt.Sprintf("prefetch of %s failed listing its tags", p.Repository),
+		).Print()
+		return
+	}
+
+	for _, tag := range tags {
+		if !matchesAny(p.Tags, tag) {
+			continue
+		}
+
+		start := time.Now()
+		stats := prefetched{}
+		err := s.prefetchManifest(p.Repository, tag, &stats)
+		if err != nil {
+			log.Error(
+				"service.name", version.AppName,
+				"service.version", version.AppVersion,
+				"event.dataset", "internal.proxy",
+				"prefetch.repository", p.Repository,
+				"prefetch.tag", tag,
+				"error.message", err.Error(),
+				"message", fmt.Sprintf("prefetch of %s:%s failed", p.Repository, tag),
+			).Print()
+			continue
+		}
+
+		log.Info(
+			"service.name", version.AppName,
+			"service.version", version.AppVersion,
+			"event.dataset", "internal.proxy",
+			"prefetch.repository", p.Repository,
+			"prefetch.tag", tag,
+			"prefetch.manifests", stats.manifests,
+			"prefetch.blobs", stats.blobs,
+			"event.duration", time.Since(start).Nanoseconds(),
+			"message", fmt.Sprintf("prefetched %s:%s", p.Repository, tag),
+		).Print()
+	}
+}
+
+func matchesAny(patterns []regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// prefetchManifest fetches the manifest through the pull-through cache, as a
+// pull would, and then the manifests and blobs it references.
+func (s *ProxyDataStorage) prefetchManifest(repo, reference string, stats *prefetched) error {
+	r, _, _, err := s.ManifestGet(repo, reference)
+	if err != nil {
+		return err
+	}
+	payload, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return err
+	}
+
+	// A stale manifest was not refreshed.
+	if stale, ok := r.(*StaleReadCloser); ok {
+		return stale.Err
+	}
+	stats.manifests++
+
+	// Either an image index or an image manifest.
+	var m struct {
+		registry.ImageManifest
+		Manifests []registry.DescriptorManifest `json:"manifests"`
+	}
+	if err := json.Unmarshal(payload, &m); err != nil {
+		return err
+	}
+
+	for _, child := range m.Manifests {
+		if err := s.prefetchManifest(repo, child.Digest, stats); err != nil {
+			return err
+		}
+	}
+
+	blobs := m.Layers
+	if m.Config.Digest != "" {
+		blobs = append(blobs, m.Config)
+	}
+	for _, blob := range blobs {
+		// Non-distributable layers are not served by the upstream.
+		if len(blob.Urls) > 0 {
+			continue
+		}
+		if err := s.prefetchBlob(repo, blob.Digest, stats); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prefetchBlob fetches the blob through the pull-through cache, unless it is
+// already stored.
+func (s *ProxyDataStorage) prefetchBlob(repo, digest string, stats *prefetched) error {
+	if r, _, err := s.Next.BlobsGet(repo, digest); err == nil {
+		return r.Close()
+	}
+
+	r, _, err := s.BlobsGet(repo, digest)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return err
+	}
+	stats.blobs++
+	return nil
+}
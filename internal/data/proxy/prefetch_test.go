@@ -0,0 +1,184 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data/filesystem"
+	"github.com/jlsalvador/simple-registry/internal/data/proxy"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+func TestRun_Prefetch(t *testing.T) {
+	blobs := map[string][]byte{}
+	addBlob := func(content string) string {
+		dgst := testDigest([]byte(content))
+		blobs[dgst] = []byte(content)
+		return dgst
+	}
+	manifests := map[string][]byte{}
+	addManifest := func(m any, tags ...string) string {
+		payload, _ := json.Marshal(m)
+		dgst := testDigest(payload)
+		manifests[dgst] = payload
+		for _, tag := range tags {
+			manifests[tag] = payload
+		}
+		return dgst
+	}
+	image := func(layers ...string) map[string]any {
+		descriptors := []map[string]any{}
+		for _, l := range layers {
+			descriptors = append(descriptors, map[string]any{
+				"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip",
+				"digest":    l,
+				"size":      len(blobs[l]),
+			})
+		}
+		config := addBlob("config of " + strings.Join(layers, ","))
+		return map[string]any{
+			"schemaVersion": 2,
+			"mediaType":     registry.MediaTypeOCIImageManifest,
+			"config": map[string]any{
+				"mediaType": "application/vnd.oci.image.config.v1+json",
+				"digest":    config,
+				"size":      len(blobs[config]),
+			},
+			"layers": descriptors,
+		}
+	}
+
+	base := addBlob("base layer")
+	amd64 := addManifest(image(base, addBlob("amd64 layer")))
+	arm64 := addManifest(image(base, addBlob("arm64 layer")))
+	addManifest(map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     registry.MediaTypeOCIImageIndex,
+		"manifests": []map[string]any{
+			{"mediaType": registry.MediaTypeOCIImageManifest, "digest": amd64, "size": len(manifests[amd64])},
+			{"mediaType": registry.MediaTypeOCIImageManifest, "digest": arm64, "size": len(manifests[arm64])},
+		},
+	}, "1.0")
+	addManifest(image(base, addBlob("1.1 layer")), "1.1")
+	// Its layer is missing upstream.
+	addManifest(image(base, testDigest([]byte("missing layer"))), "1.2")
+	addManifest(image(addBlob("latest layer")), "latest")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/v2/library/app/")
+		switch {
+		case path == "tags/list":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"name": "library/app",
+				"tags": []string{"1.0", "1.1", "1.2", "latest"},
+			})
+		case strings.HasPrefix(path, "manifests/"):
+			payload, ok := manifests[strings.TrimPrefix(path, "manifests/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", testDigest(payload))
+			_, _ = w.Write(payload)
+		case strings.HasPrefix(path, "blobs/"):
+			blob, ok := blobs[strings.TrimPrefix(path, "blobs/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(blob)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	storage := filesystem.NewFilesystemDataStorage(t.TempDir())
+	p := proxy.Proxy{
+		Url:     srv.URL,
+		Timeout: 5 * time.Second,
+		Scopes:  []string{".*"},
+		Prefetch: []proxy.Prefetch{{
+			Repository: "library/app",
+			Tags:       []regexp.Regexp{*regexp.MustCompile(`^1\.`)},
+		}},
+	}
+	s := proxy.NewProxyDataStorage(storage, []proxy.Proxy{p})
+
+	// Without a prefetch interval, it runs once.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s.Run(ctx)
+	if ctx.Err() != nil {
+		t.Fatal("expected prefetch to run once")
+	}
+
+	for _, ref := range []string{"1.0", amd64, arm64, "1.1"} {
+		rc, _, _, err := storage.ManifestGet("library/app", ref)
+		if err != nil {
+			t.Errorf("expected manifest %s prefetched, got %v", ref, err)
+			continue
+		}
+		rc.Close()
+	}
+	for _, ref := range []string{"1.0", "1.1"} {
+		var m struct {
+			registry.ImageManifest
+			Manifests []registry.DescriptorManifest `json:"manifests"`
+		}
+		_ = json.Unmarshal(manifests[ref], &m)
+		images := [][]byte{manifests[ref]}
+		for _, child := range m.Manifests {
+			images = append(images, manifests[child.Digest])
+		}
+		for _, payload := range images {
+			var image registry.ImageManifest
+			_ = json.Unmarshal(payload, &image)
+			for _, blob := range append(image.Layers, image.Config) {
+				if blob.Digest == "" {
+					continue
+				}
+				rc, _, err := storage.BlobsGet("library/app", blob.Digest)
+				if err != nil {
+					t.Errorf("expected blob %s of %s prefetched, got %v", blob.Digest, ref, err)
+					continue
+				}
+				rc.Close()
+			}
+		}
+	}
+	if _, _, err := storage.BlobsGet("library/app", testDigest([]byte("latest layer"))); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected blob of latest not prefetched, got %v", err)
+	}
+
+	tags, err := storage.TagsList("library/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(tags); got != "[1.0 1.1 1.2]" {
+		t.Errorf("expected tags [1.0 1.1 1.2], got %s", got)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"io"
 	"io/fs"
 	"iter"
+	"slices"
 
+	"github.com/jlsalvador/simple-registry/internal/data"
 	"github.com/jlsalvador/simple-registry/pkg/registry"
 )
 
@@ -28,31 +30,55 @@ func (s *ProxyDataStorage) BlobsGet(repo, digest string) (r io.ReadCloser, size
 
 	// Find matching proxy.
 	proxy := s.MatchProxy(repo)
-	if proxy == nil {
+	if proxy.policy() == PolicyCacheOnly {
 		return nil, -1, fs.ErrNotExist
 	}
 
-	// Fetch from upstream.
-	upstreamReader, size, err := FetchBlobFromUpstream(proxy, repo, digest)
+	// Upstream did not find it recently.
+	if s.isMiss(blobKey(repo, digest)) {
+		return nil, -1, fs.ErrNotExist
+	}
+
+	// Fetch from upstream once for all the concurrent requests of the blob.
+	// The others wait for it to be stored, then read it from local.
+	key := blobKey(repo, digest)
+	f, leader := s.join(key)
+	if !leader {
+		<-f.done
+		if f.err != nil {
+			return nil, -1, f.err
+		}
+		return s.Next.BlobsGet(repo, digest)
+	}
+
+	// A previous fill could have just stored it.
+	if r, size, err := s.Next.BlobsGet(repo, digest); err == nil {
+		s.land(key, f, digest, nil)
+		return r, size, nil
+	}
+
+	var upstreamReader io.ReadCloser
+	err = s.failover(proxy, repo, func(upstream *Proxy, upstreamRepo string) (err error) {
+		upstreamReader, size, err = FetchBlobFromUpstream(upstream, upstreamRepo, digest)
+		return err
+	})
 	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			s.missed(proxy, key)
+		}
+		s.land(key, f, "", err)
 		return nil, -1, err
 	}
-	defer upstreamReader.Close()
 
-	// Store locally.
 	uuid, err := s.Next.BlobsUploadCreate(repo)
 	if err != nil {
-		return nil, -1, err
-	}
-	if err := s.Next.BlobsUploadWrite(repo, uuid, upstreamReader, -1); err != nil {
-		return nil, -1, err
-	}
-	if err := s.Next.BlobsUploadCommit(repo, uuid, digest); err != nil {
+		upstreamReader.Close()
+		s.land(key, f, "", err)
 		return nil, -1, err
 	}
 
-	// Read back from local.
-	return s.Next.BlobsGet(repo, digest)
+	// Serve the blob while it is stored locally.
+	return s.stream(key, f, repo, uuid, digest, upstreamReader), size, nil
 }
 
 // Manifests
@@ -68,24 +94,41 @@ func (s *ProxyDataStorage) ManifestGet(repo, reference string) (
 	}
 
 	proxy := s.MatchProxy(repo)
+	policy := proxy.policy()
 	isDigest := registry.RegExprDigest.MatchString(reference)
-	isTag := registry.RegExprTag.MatchString(reference)
+	isTag := !isDigest && registry.RegExprTag.MatchString(reference)
 
-	// If Proxy is found and the reference is a tag,
-	// update manifest from the upstream.
+	// If Proxy prefers upstream and the reference is a tag neither checked
+	// within the TTL nor missed within the negative TTL, update manifest from
+	// the upstream.
 	var upstreamDigest string
-	if proxy != nil && !isDigest && isTag {
+	var upstreamErr error
+	if policy == PolicyPreferUpstream && isTag &&
+		!s.isTagFresh(repo, reference) && !s.isMiss(manifestKey(repo, reference)) {
 
 		// Fetch lastest digest for this tag from upstream.
-		upstreamDigest, _ = FetchManifestDigestHEAD(proxy, repo, reference)
+		upstreamErr = s.failover(proxy, repo, func(upstream *Proxy, upstreamRepo string) (err error) {
+			upstreamDigest, err = FetchManifestDigestHEAD(upstream, upstreamRepo, reference)
+			return err
+		})
 	}
 
 	// Try to get from local.
 	r, size, digest, err = s.Next.ManifestGet(repo, reference)
 	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		return nil, -1, "", err
-	} else if r != nil {
+	}
+	isCached := r != nil
+	if isCached {
+		// Upstream could not confirm the cached tag.
+		if upstreamErr != nil {
+			return &StaleReadCloser{r, upstreamErr}, size, digest, nil
+		}
+
 		if upstreamDigest == "" || upstreamDigest == digest {
+			if upstreamDigest != "" {
+				s.tagChecked(proxy, repo, reference)
+			}
 			return r, size, digest, nil
 		}
 
@@ -93,20 +136,48 @@ func (s *ProxyDataStorage) ManifestGet(repo, reference string) (
 	}
 
 	// Local miss, check Proxy.
-	if proxy == nil {
+	if policy == PolicyCacheOnly {
 		return nil, -1, "", fs.ErrNotExist
 	}
 
-	// Fetch from upstream.
-	upstreamReader, size, err := FetchManifestFromUpstream(proxy, repo, reference)
-	if err != nil {
-		return nil, -1, "", err
+	// Upstream did not find it recently.
+	if s.isMiss(manifestKey(repo, reference)) {
+		return nil, -1, "", fs.ErrNotExist
 	}
-	defer upstreamReader.Close()
 
-	// Store locally.
-	newDigest, err := s.Next.ManifestPut(repo, reference, upstreamReader)
+	// Fetch from upstream and store locally, once for all the concurrent
+	// requests of the reference.
+	newDigest, err := s.fill(manifestKey(repo, reference), func() (string, error) {
+		var upstreamReader io.ReadCloser
+		err := s.failover(proxy, repo, func(upstream *Proxy, upstreamRepo string) (err error) {
+			upstreamReader, _, err = FetchManifestFromUpstream(upstream, upstreamRepo, reference)
+			return err
+		})
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				s.missed(proxy, manifestKey(repo, reference))
+			}
+			return "", err
+		}
+		defer upstreamReader.Close()
+
+		newDigest, err := s.Next.ManifestPut(repo, reference, upstreamReader)
+		if err != nil {
+			return "", err
+		}
+		if isTag {
+			s.tagChecked(proxy, repo, reference)
+		}
+		return newDigest, nil
+	})
 	if err != nil {
+		// Upstream failed, fallback to the outdated cached tag.
+		if isCached && !errors.Is(err, fs.ErrNotExist) {
+			r, size, digest, lerr := s.Next.ManifestGet(repo, reference)
+			if lerr == nil {
+				return &StaleReadCloser{r, err}, size, digest, nil
+			}
+		}
 		return nil, -1, "", err
 	}
 
@@ -135,12 +206,15 @@ func (s *ProxyDataStorage) ReferrersGet(
 
 	// Find matching proxy.
 	proxy := s.MatchProxy(repo)
-	if proxy == nil {
+	if proxy.policy() == PolicyCacheOnly {
 		return nil, fs.ErrNotExist
 	}
 
 	// Fetch from upstream.
-	digests, err = FetchReferrersFromUpstream(*proxy, repo, dgst)
+	err = s.failover(proxy, repo, func(upstream *Proxy, upstreamRepo string) (err error) {
+		digests, err = FetchReferrersFromUpstream(*upstream, upstreamRepo, dgst)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -150,20 +224,98 @@ func (s *ProxyDataStorage) ReferrersGet(
 
 // Tags
 
+// TagsList returns the union of the local tags and the upstream ones. If the
+// upstream fails, its last listed tags are used.
 func (s *ProxyDataStorage) TagsList(repo string) ([]string, error) {
+	return s.TagsListPage(repo, -1, "")
+}
+
+// TagsListPage returns, sorted, up to n tags of the union of the local tags
+// and the upstream ones after last.
+//
+// The page is requested to the upstream with the same n and last, and the
+// local tags within it are merged. The whole list of upstream tags is only
+// fetched, following every page, when no page is asked for, so it is kept
+// for a TTL and used for any page if the upstream fails.
+func (s *ProxyDataStorage) TagsListPage(repo string, n int, last string) ([]string, error) {
 	if s.Next == nil {
 		return nil, ErrDataStorageNotInitialized
 	}
 
+	local, err := s.Next.TagsList(repo)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	isLocal := err == nil
+
 	proxy := s.MatchProxy(repo)
 
-	if proxy != nil {
-		tags, err := FetchTagsFromUpstream(proxy, repo)
-		if err == nil {
-			return tags, nil
+	// Only the whole list of upstream tags is kept, not its pages.
+	paged := n >= 0 || last != ""
+
+	var upstream []string
+	var upstreamErr error
+	isUpstream := false
+	switch proxy.policy() {
+	case PolicyPreferUpstream:
+		// Upstream could list the tags in many pages, so they are listed
+		// once per TTL.
+		if s.isTagsListFresh(repo) {
+			upstream, isUpstream = s.listedTags(repo)
+		}
+		if isUpstream {
+			break
+		}
+
+		upstream, upstreamErr = s.fetchTags(proxy, repo, n, last)
+		switch {
+		case upstreamErr == nil:
+			if !paged {
+				s.tagsListed(proxy, repo, upstream)
+			}
+			isUpstream = true
+		case errors.Is(upstreamErr, fs.ErrNotExist):
+			s.tagsListed(proxy, repo, nil)
+		default:
+			// upstream failed, fallback to its last listed tags.
+			upstream, isUpstream = s.listedTags(repo)
+		}
+
+	case PolicyPreferCache:
+		upstream, isUpstream = s.listedTags(repo)
+		if !isLocal && !isUpstream {
+			upstream, upstreamErr = s.fetchTags(proxy, repo, n, last)
+			if upstreamErr == nil {
+				if !paged {
+					s.tagsListed(proxy, repo, upstream)
+				}
+				isUpstream = true
+			}
 		}
-		// upstream failed, fallback to local.
 	}
 
-	return s.Next.TagsList(repo)
+	if !isLocal && !isUpstream {
+		if upstreamErr != nil && !errors.Is(upstreamErr, fs.ErrNotExist) {
+			return nil, upstreamErr
+		}
+		return nil, fs.ErrNotExist
+	}
+
+	tags := slices.Concat(local, upstream)
+	slices.Sort(tags)
+	return data.PageTags(slices.Compact(tags), n, last), nil
+}
+
+// fetchTags returns the page of upstream tags, or all of them if no page is
+// asked for.
+func (s *ProxyDataStorage) fetchTags(proxy *Proxy, repo string, n int, last string) (tags []string, err error) {
+	err = s.failover(proxy, repo, func(upstream *Proxy, upstreamRepo string) (err error) {
+		if n < 0 && last == "" {
+			tags, err = FetchTagsFromUpstream(upstream, upstreamRepo)
+		} else {
+			tags, err = FetchTagsPageFromUpstream(upstream, upstreamRepo, n, last)
+		}
+		return err
+	})
+	return tags, err
 }
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"io/fs"
 	"net/http"
@@ -25,6 +26,9 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -125,6 +129,163 @@ func TestBlobsGet_UpstreamFetch_Success(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	rc.Close()
+
+	// The blob is cached in background, as the client did not read it.
+	s.Wait()
+	if _, _, err := storage.BlobsGet("repo", digest); err != nil {
+		t.Errorf("expected blob cached, got %v", err)
+	}
+}
+
+func TestBlobsGet_ConcurrentMisses(t *testing.T) {
+	blob := bytes.Repeat([]byte("layer"), 1<<16)
+	hasher, _ := digest.NewHasher("sha256")
+	hasher.Write(blob)
+	digest := "sha256:" + hasher.GetHashAsString()
+
+	// A slow upstream.
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write(blob)
+	}))
+	defer srv.Close()
+
+	storage := filesystem.NewFilesystemDataStorage(t.TempDir())
+	p := proxy.Proxy{Url: srv.URL, Timeout: 5 * time.Second, Scopes: []string{".*"}}
+	s := proxy.NewProxyDataStorage(storage, []proxy.Proxy{p})
+
+	const clients = 20
+	start := make(chan struct{})
+	errs := make(chan error, clients)
+	for range clients {
+		go func() {
+			<-start
+			rc, _, err := s.BlobsGet("repo", digest)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer rc.Close()
+			got, err := io.ReadAll(rc)
+			if err == nil && !bytes.Equal(got, blob) {
+				err = errors.New("unexpected blob content")
+			}
+			errs <- err
+		}()
+	}
+	close(start)
+
+	for range clients {
+		if err := <-errs; err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if requests.Load() != 1 {
+		t.Errorf("expected 1 upstream request, got %d", requests.Load())
+	}
+}
+
+func TestBlobsGet_StreamsWhileCaching(t *testing.T) {
+	head, tail := []byte("first half,"), []byte(" second half")
+	blob := append(append([]byte{}, head...), tail...)
+	hasher, _ := digest.NewHasher("sha256")
+	hasher.Write(blob)
+	digest := "sha256:" + hasher.GetHashAsString()
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(blob)))
+		_, _ = w.Write(head)
+		w.(http.Flusher).Flush()
+		<-release
+		_, _ = w.Write(tail)
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	storage := filesystem.NewFilesystemDataStorage(t.TempDir())
+	p := proxy.Proxy{Url: srv.URL, Timeout: 5 * time.Second, Scopes: []string{".*"}}
+	s := proxy.NewProxyDataStorage(storage, []proxy.Proxy{p})
+
+	rc, size, err := s.BlobsGet("repo", digest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer rc.Close()
+	if size != int64(len(blob)) {
+		t.Errorf("expected size %d, got %d", len(blob), size)
+	}
+
+	// The first bytes arrive while the upstream is still sending.
+	got := make([]byte, len(head))
+	if _, err := io.ReadFull(rc, got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, head) {
+		t.Errorf("expected %q, got %q", head, got)
+	}
+	if _, _, err := storage.BlobsGet("repo", digest); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected blob not cached yet, got %v", err)
+	}
+
+	release <- struct{}{}
+	rest, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(rest, tail) {
+		t.Errorf("expected %q, got %q", tail, rest)
+	}
+
+	// Once read, the blob is cached.
+	cached, _, err := storage.BlobsGet("repo", digest)
+	if err != nil {
+		t.Fatalf("expected blob cached, got %v", err)
+	}
+	defer cached.Close()
+	if got, _ := io.ReadAll(cached); !bytes.Equal(got, blob) {
+		t.Errorf("expected %q, got %q", blob, got)
+	}
+}
+
+func TestBlobsGet_ClientGoneStillCaches(t *testing.T) {
+	blob := bytes.Repeat([]byte("layer"), 64*1024)
+	hasher, _ := digest.NewHasher("sha256")
+	hasher.Write(blob)
+	digest := "sha256:" + hasher.GetHashAsString()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(blob)
+	}))
+	defer srv.Close()
+
+	storage := filesystem.NewFilesystemDataStorage(t.TempDir())
+	p := proxy.Proxy{Url: srv.URL, Timeout: 5 * time.Second, Scopes: []string{".*"}}
+	s := proxy.NewProxyDataStorage(storage, []proxy.Proxy{p})
+
+	rc, _, err := s.BlobsGet("repo", digest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := rc.Read(make([]byte, 10)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rc.Close()
+
+	// A request arriving meanwhile waits for the cache fill.
+	rc, size, err := s.BlobsGet("repo", digest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer rc.Close()
+	if size != int64(len(blob)) {
+		t.Errorf("expected size %d, got %d", len(blob), size)
+	}
+	if got, _ := io.ReadAll(rc); !bytes.Equal(got, blob) {
+		t.Error("unexpected blob content")
+	}
 }
 
 func TestBlobsGet_UpstreamFetch_UpstreamError(t *testing.T) {
@@ -164,7 +325,7 @@ func TestBlobsGet_UploadCreate_Fails(t *testing.T) {
 	p := proxy.Proxy{Url: srv.URL, Timeout: 5 * time.Second, Scopes: []string{".*"}}
 	s := proxy.NewProxyDataStorage(storage, []proxy.Proxy{p})
 
-	_, _, err := s.BlobsGet("repo", "sha256:abc")
+	_, _, err := s.BlobsGet("repo/img", "sha256:abc")
 	if err == nil {
 		t.Fatal("expected error from BlobsUploadCreate failure")
 	}
@@ -190,9 +351,22 @@ func TestBlobsGet_UploadWrite_Fails(t *testing.T) {
 	p := proxy.Proxy{Url: srv.URL, Timeout: 5 * time.Second, Scopes: []string{".*"}}
 	s := proxy.NewProxyDataStorage(invalidStorage, []proxy.Proxy{p})
 
-	_, _, err := s.BlobsGet("repo", "sha256:abc")
-	if err == nil {
-		t.Fatal("expected error from BlobsUploadWrite failure")
+	// The client still gets the blob, but it is not cached.
+	rc, _, err := s.BlobsGet("repo", "sha256:abc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != "data" {
+		t.Errorf("expected %q, got %q", "data", got)
+	}
+
+	if _, _, err := storage.BlobsGet("repo", "sha256:abc"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
 	}
 }
 
@@ -203,14 +377,32 @@ func TestBlobsGet_UploadCommit_Fails(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	storage := filesystem.NewFilesystemDataStorage(t.TempDir())
+	tmpDir := t.TempDir()
+	storage := filesystem.NewFilesystemDataStorage(tmpDir)
 	p := proxy.Proxy{Url: srv.URL, Timeout: 5 * time.Second, Scopes: []string{".*"}}
 	s := proxy.NewProxyDataStorage(storage, []proxy.Proxy{p})
 
-	_, _, err := s.BlobsGet("repo", "sha256:abc")
+	rc, _, err := s.BlobsGet("repo", "sha256:abc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = io.ReadAll(rc)
+	rc.Close()
 	if !errors.Is(err, data.ErrDigestMismatch) {
 		t.Fatal("expected error ErrDigestMismatch from BlobsUploadCommit failure")
 	}
+
+	// Neither the blob nor its upload are left behind.
+	if _, _, err := storage.BlobsGet("repo", "sha256:abc"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+	uploads, err := os.ReadDir(filepath.Join(tmpDir, "repositories", "repo", "_uploads"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		t.Fatal(err)
+	}
+	if len(uploads) != 0 {
+		t.Errorf("expected no uploads, got %d", len(uploads))
+	}
 }
 
 func TestManifestGet_NilNext(t *testing.T) {
@@ -315,6 +507,306 @@ func TestManifestGet_LocalHit_ProxyDigestDiffers_FetchesUpstream(t *testing.T) {
 	}
 }
 
+func TestManifestGet_TTL(t *testing.T) {
+	repo := "repo"
+	tag := "latest"
+	manifest := []byte(`{"schemaVersion":2}`)
+	hasher, _ := digest.NewHasher("sha256")
+	hasher.Write(manifest)
+	digest := "sha256:" + hasher.GetHashAsString()
+
+	var heads, gets atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Docker-Content-Digest", digest)
+		if r.Method == http.MethodHead {
+			heads.Add(1)
+			return
+		}
+		gets.Add(1)
+		_, _ = w.Write(manifest)
+	}))
+	defer srv.Close()
+
+	storage := filesystem.NewFilesystemDataStorage(t.TempDir())
+	p := proxy.Proxy{Url: srv.URL, Timeout: 5 * time.Second, Scopes: []string{".*"}, TTL: 50 * time.Millisecond}
+	s := proxy.NewProxyDataStorage(storage, []proxy.Proxy{p})
+
+	// Within the TTL, the cached tag is served without asking upstream.
+	for range 3 {
+		rc, _, dgst, err := s.ManifestGet(repo, tag)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		rc.Close()
+		if dgst != digest {
+			t.Errorf("wrong digest: %s", dgst)
+		}
+	}
+	if heads.Load() != 1 || gets.Load() != 1 {
+		t.Errorf("expected 1 HEAD and 1 GET, got %d and %d", heads.Load(), gets.Load())
+	}
+
+	// Once expired, the tag is checked again.
+	time.Sleep(60 * time.Millisecond)
+	rc, _, _, err := s.ManifestGet(repo, tag)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rc.Close()
+	if heads.Load() != 2 || gets.Load() != 1 {
+		t.Errorf("expected 2 HEAD and 1 GET, got %d and %d", heads.Load(), gets.Load())
+	}
+}
+
+func TestManifestGet_NegativeTTL(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	storage := filesystem.NewFilesystemDataStorage(t.TempDir())
+	p := proxy.Proxy{Url: srv.URL, Timeout: 5 * time.Second, Scopes: []string{".*"}, NegativeTTL: 50 * time.Millisecond}
+	s := proxy.NewProxyDataStorage(storage, []proxy.Proxy{p})
+
+	blobDigest := "sha256:" + strings.Repeat("a", 64)
+	get := func() {
+		t.Helper()
+		if _, _, _, err := s.ManifestGet("repo", "missing"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected ErrNotExist, got %v", err)
+		}
+		if _, _, err := s.BlobsGet("repo", blobDigest); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected ErrNotExist, got %v", err)
+		}
+	}
+
+	// HEAD and GET of the manifest, and GET of the blob.
+	get()
+	if requests.Load() != 3 {
+		t.Fatalf("expected 3 upstream requests, got %d", requests.Load())
+	}
+
+	// The upstream misses are remembered.
+	get()
+	if requests.Load() != 3 {
+		t.Errorf("expected no more upstream requests, got %d", requests.Load())
+	}
+
+	// Once expired, upstream is asked again.
+	time.Sleep(60 * time.Millisecond)
+	get()
+	if requests.Load() != 6 {
+		t.Errorf("expected 6 upstream requests, got %d", requests.Load())
+	}
+}
+
+func TestManifestGet_Stale(t *testing.T) {
+	repo := "repo"
+	tag := "latest"
+	oldManifest := []byte(`{"schemaVersion":2,"annotations":{"test_version":"old"}}`)
+	newManifest := []byte(`{"schemaVersion":2,"annotations":{"test_version":"new"}}`)
+	hasher, _ := digest.NewHasher("sha256")
+	hasher.Write(newManifest)
+	newDigest := "sha256:" + hasher.GetHashAsString()
+
+	for _, tt := range []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"HEAD fails", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}},
+		{"GET fails after a digest mismatch", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead {
+				w.Header().Set("Docker-Content-Digest", newDigest)
+				return
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+
+			storage := filesystem.NewFilesystemDataStorage(t.TempDir())
+			oldDigest, err := storage.ManifestPut(repo, tag, bytes.NewReader(oldManifest))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p := proxy.Proxy{Url: srv.URL, Timeout: 5 * time.Second, Scopes: []string{".*"}}
+			s := proxy.NewProxyDataStorage(storage, []proxy.Proxy{p})
+
+			rc, _, dgst, err := s.ManifestGet(repo, tag)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer rc.Close()
+			if dgst != oldDigest {
+				t.Errorf("expected cached digest, got %s", dgst)
+			}
+			stale, ok := rc.(*proxy.StaleReadCloser)
+			if !ok || !errors.Is(stale.Err, proxy.ErrUpstreamError) {
+				t.Errorf("expected stale reader with upstream error, got %#v", rc)
+			}
+		})
+	}
+
+	t.Run("upstream unreachable", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		srv.Close()
+
+		storage := filesystem.NewFilesystemDataStorage(t.TempDir())
+		if _, err := storage.ManifestPut(repo, tag, bytes.NewReader(oldManifest)); err != nil {
+			t.Fatal(err)
+		}
+
+		p := proxy.Proxy{Url: srv.URL, Timeout: 5 * time.Second, Scopes: []string{".*"}}
+		s := proxy.NewProxyDataStorage(storage, []proxy.Proxy{p})
+
+		rc, _, _, err := s.ManifestGet(repo, tag)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer rc.Close()
+		if _, ok := rc.(*proxy.StaleReadCloser); !ok {
+			t.Errorf("expected stale reader, got %#v", rc)
+		}
+	})
+}
+
+func TestManifestGet_Policies(t *testing.T) {
+	repo := "repo"
+	manifest := []byte(`{"schemaVersion":2}`)
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write(manifest)
+	}))
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		policy       proxy.Policy
+		wantMiss     error
+		wantRequests int32
+	}{
+		// A missing tag is fetched.
+		{proxy.PolicyPreferCache, nil, 1},
+		// Upstream is never asked.
+		{proxy.PolicyCacheOnly, fs.ErrNotExist, 0},
+	} {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			requests.Store(0)
+
+			storage := filesystem.NewFilesystemDataStorage(t.TempDir())
+			if _, err := storage.ManifestPut(repo, "cached", bytes.NewReader(manifest)); err != nil {
+				t.Fatal(err)
+			}
+
+			p := proxy.Proxy{Url: srv.URL, Timeout: 5 * time.Second, Scopes: []string{".*"}, Policy: tt.policy}
+			s := proxy.NewProxyDataStorage(storage, []proxy.Proxy{p})
+
+			// A cached tag is not checked against upstream.
+			rc, _, _, err := s.ManifestGet(repo, "cached")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			rc.Close()
+			if requests.Load() != 0 {
+				t.Errorf("expected no upstream requests, got %d", requests.Load())
+			}
+
+			rc, _, _, err = s.ManifestGet(repo, "missing")
+			if !errors.Is(err, tt.wantMiss) {
+				t.Fatalf("expected %v, got %v", tt.wantMiss, err)
+			}
+			if err == nil {
+				rc.Close()
+			}
+			if requests.Load() != tt.wantRequests {
+				t.Errorf("expected %d upstream requests, got %d", tt.wantRequests, requests.Load())
+			}
+		})
+	}
+}
+
+func TestBlobsGet_CacheOnly(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer srv.Close()
+
+	storage := filesystem.NewFilesystemDataStorage(t.TempDir())
+	p := proxy.Proxy{Url: srv.URL, Timeout: 5 * time.Second, Scopes: []string{".*"}, Policy: proxy.PolicyCacheOnly}
+	s := proxy.NewProxyDataStorage(storage, []proxy.Proxy{p})
+
+	if _, _, err := s.BlobsGet("repo", "sha256:"+strings.Repeat("a", 64)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected ErrNotExist, got %v", err)
+	}
+	if _, err := s.TagsList("repo"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected ErrNotExist, got %v", err)
+	}
+	if requests.Load() != 0 {
+		t.Errorf("expected no upstream requests, got %d", requests.Load())
+	}
+}
+
+func TestManifestGet_ConcurrentMisses(t *testing.T) {
+	manifest := []byte(`{"schemaVersion":2}`)
+	hasher, _ := digest.NewHasher("sha256")
+	hasher.Write(manifest)
+	digest := "sha256:" + hasher.GetHashAsString()
+
+	// A slow upstream, only answering GET requests.
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		requests.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write(manifest)
+	}))
+	defer srv.Close()
+
+	storage := filesystem.NewFilesystemDataStorage(t.TempDir())
+	p := proxy.Proxy{Url: srv.URL, Timeout: 5 * time.Second, Scopes: []string{".*"}}
+	s := proxy.NewProxyDataStorage(storage, []proxy.Proxy{p})
+
+	const clients = 20
+	start := make(chan struct{})
+	errs := make(chan error, clients)
+	for range clients {
+		go func() {
+			<-start
+			rc, _, dgst, err := s.ManifestGet("repo", "latest")
+			if err != nil {
+				errs <- err
+				return
+			}
+			rc.Close()
+			if dgst != digest {
+				err = fmt.Errorf("unexpected digest %s", dgst)
+			}
+			errs <- err
+		}()
+	}
+	close(start)
+
+	for range clients {
+		if err := <-errs; err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if requests.Load() != 1 {
+		t.Errorf("expected 1 upstream GET request, got %d", requests.Load())
+	}
+}
+
 func TestManifestGet_LocalMiss_NoProxy(t *testing.T) {
 	storage := filesystem.NewFilesystemDataStorage(t.TempDir())
 	s := proxy.NewProxyDataStorage(storage, nil)
@@ -621,6 +1113,165 @@ func TestTagsList_ProxyFail_LocalFallback(t *testing.T) {
 	}
 }
 
+func TestTagsList_MergesLocal(t *testing.T) {
+	repo := "repo"
+
+	var fail atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"name":"repo","tags":["v1","v2"]}`))
+	}))
+	defer srv.Close()
+
+	storage := filesystem.NewFilesystemDataStorage(t.TempDir())
+	for _, tag := range []string{"v2", "pushed"} {
+		if _, err := storage.ManifestPut(repo, tag, bytes.NewReader([]byte(`{"schemaVersion":2}`))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := proxy.Proxy{Url: srv.URL, Timeout: 5 * time.Second, Scopes: []string{".*"}}
+	s := proxy.NewProxyDataStorage(storage, []proxy.Proxy{p})
+
+	want := []string{"pushed", "v1", "v2"}
+	tags, err := s.TagsList(repo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if slices.Compare(want, tags) != 0 {
+		t.Errorf("expected %v, got %v", want, tags)
+	}
+
+	// The last upstream tags are used when it fails.
+	fail.Store(true)
+	tags, err = s.TagsList(repo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if slices.Compare(want, tags) != 0 {
+		t.Errorf("expected %v, got %v", want, tags)
+	}
+
+	// Without local nor listed tags, the upstream error is returned.
+	if _, err := s.TagsList("other"); !errors.Is(err, proxy.ErrUpstreamError) {
+		t.Errorf("expected ErrUpstreamError, got %v", err)
+	}
+}
+
+func TestTagsList_UpstreamPages(t *testing.T) {
+	pages := map[string]string{
+		"":   `{"name":"repo","tags":["a","b"]}`,
+		"b":  `{"name":"repo","tags":["c","d"]}`,
+		"d":  `{"name":"repo","tags":["e"]}`,
+		"zz": `{"name":"repo","tags":["unreachable"]}`,
+	}
+	next := map[string]string{"": "b", "b": "d"}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last := r.URL.Query().Get("last")
+		if n, ok := next[last]; ok {
+			w.Header().Set("Link", `</v2/repo/tags/list?n=2&last=`+n+`>; rel="next"`)
+		}
+		w.Write([]byte(pages[last]))
+	}))
+	defer srv.Close()
+
+	storage := filesystem.NewFilesystemDataStorage(t.TempDir())
+	p := proxy.Proxy{Url: srv.URL, Timeout: 5 * time.Second, Scopes: []string{".*"}}
+	s := proxy.NewProxyDataStorage(storage, []proxy.Proxy{p})
+
+	want := []string{"a", "b", "c", "d", "e"}
+	tags, err := s.TagsList("repo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if slices.Compare(want, tags) != 0 {
+		t.Errorf("expected %v, got %v", want, tags)
+	}
+}
+
+func TestTagsListPage(t *testing.T) {
+	upstream := []string{"a", "c", "e", "g"}
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		q := r.URL.Query()
+		n, err := strconv.Atoi(q.Get("n"))
+		if err != nil {
+			n = -1
+		}
+		page := data.PageTags(upstream, n, q.Get("last"))
+		json.NewEncoder(w).Encode(map[string]any{"name": "repo", "tags": page})
+	}))
+	defer srv.Close()
+
+	storage := filesystem.NewFilesystemDataStorage(t.TempDir())
+	for _, tag := range []string{"b", "d"} {
+		if _, err := storage.ManifestPut("repo", tag, bytes.NewReader([]byte(`{"schemaVersion":2}`))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := proxy.Proxy{Url: srv.URL, Timeout: 5 * time.Second, Scopes: []string{".*"}}
+	s := proxy.NewProxyDataStorage(storage, []proxy.Proxy{p})
+
+	// The local tags within the upstream page are merged.
+	for _, tc := range []struct {
+		n    int
+		last string
+		want []string
+	}{
+		{2, "", []string{"a", "b"}},
+		{3, "b", []string{"c", "d", "e"}},
+		{3, "e", []string{"g"}},
+	} {
+		tags, err := s.TagsListPage("repo", tc.n, tc.last)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.Equal(tags, tc.want) {
+			t.Errorf("expected %v after %q, got %v", tc.want, tc.last, tags)
+		}
+	}
+
+	// Only the pages asked for are requested to the upstream.
+	want := []string{"n=2", "last=b&n=3", "last=e&n=3"}
+	if !slices.Equal(queries, want) {
+		t.Errorf("expected upstream queries %v, got %v", want, queries)
+	}
+}
+
+func TestTagsList_TTL(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`{"name":"repo","tags":["latest"]}`))
+	}))
+	defer srv.Close()
+
+	storage := filesystem.NewFilesystemDataStorage(t.TempDir())
+	p := proxy.Proxy{Url: srv.URL, Timeout: 5 * time.Second, TTL: time.Minute, Scopes: []string{".*"}}
+	s := proxy.NewProxyDataStorage(storage, []proxy.Proxy{p})
+
+	for range 3 {
+		tags, err := s.TagsList("repo")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.Equal(tags, []string{"latest"}) {
+			t.Errorf("expected [latest], got %v", tags)
+		}
+	}
+
+	// The upstream is listed once within the TTL.
+	if n := requests.Load(); n != 1 {
+		t.Errorf("expected 1 upstream request, got %d", n)
+	}
+}
+
 func TestTagsList_NoProxy_LocalResult(t *testing.T) {
 	repo := "repo"
 	storage := filesystem.NewFilesystemDataStorage(t.TempDir())
@@ -0,0 +1,156 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+)
+
+// streamReadCloser serves an upstream blob to the client while it is being
+// written to the local storage.
+//
+// The local upload is committed only when the whole upstream body has been
+// read, so the backend verifies its digest before the blob becomes visible. A
+// failure of the local storage does not interrupt the client; the blob is
+// just not cached.
+type streamReadCloser struct {
+	fills    *sync.WaitGroup // Of the [ProxyDataStorage].
+	upstream io.ReadCloser
+	tee      io.Reader
+	pw       *io.PipeWriter
+
+	// uploaded is closed once the local upload is committed or cancelled,
+	// with its result in err.
+	uploaded chan struct{}
+	err      error
+
+	once sync.Once
+}
+
+// stream starts caching the upstream blob into the upload uuid and returns
+// the reader for the client. The fill of key is landed when the upload ends.
+func (s *ProxyDataStorage) stream(
+	key string,
+	f *flight,
+	repo, uuid, digest string,
+	upstream io.ReadCloser,
+) *streamReadCloser {
+	pr, pw := io.Pipe()
+
+	rc := &streamReadCloser{
+		fills:    &s.fills,
+		upstream: upstream,
+		tee:      io.TeeReader(upstream, &lenientWriter{w: pw}),
+		pw:       pw,
+		uploaded: make(chan struct{}),
+	}
+
+	s.fills.Go(func() {
+		err := s.Next.BlobsUploadWrite(repo, uuid, pr, -1)
+		if err == nil {
+			err = s.Next.BlobsUploadCommit(repo, uuid, digest)
+		}
+		if err != nil {
+			// Unblock the client, and drop what was written.
+			pr.CloseWithError(err)
+			_ = s.Next.BlobsUploadCancel(repo, uuid)
+			digest = ""
+		}
+
+		rc.err = err
+		close(rc.uploaded)
+		s.land(key, f, digest, err)
+	})
+
+	return rc
+}
+
+// Read reads from the upstream, copying to the local upload. At the end of
+// the body it waits for the upload to be committed, and reports a digest
+// mismatch instead of io.EOF.
+func (rc *streamReadCloser) Read(p []byte) (int, error) {
+	n, err := rc.tee.Read(p)
+	if err == nil {
+		return n, nil
+	}
+
+	rc.finish(err)
+	if err == io.EOF {
+		<-rc.uploaded
+		if errors.Is(rc.err, data.ErrDigestMismatch) {
+			return n, rc.err
+		}
+	}
+	return n, err
+}
+
+// Close closes the upstream body. If the client did not read it to the end,
+// the rest is still read in background to complete the local upload, so the
+// concurrent requests waiting for the blob get it.
+func (rc *streamReadCloser) Close() error {
+	select {
+	case <-rc.uploaded:
+		return rc.upstream.Close()
+	default:
+	}
+
+	rc.fills.Go(func() {
+		_, err := io.Copy(io.Discard, rc.tee)
+		if err == nil {
+			err = io.EOF
+		}
+		rc.finish(err)
+		rc.upstream.Close()
+	})
+	return nil
+}
+
+// Wait waits for the upstream blobs being cached in background, like the ones
+// whose client closed before reading them to the end.
+func (s *ProxyDataStorage) Wait() {
+	s.fills.Wait()
+}
+
+// finish ends the local upload, either at the end of the upstream body or
+// with the error reading it.
+func (rc *streamReadCloser) finish(err error) {
+	rc.once.Do(func() {
+		if err == io.EOF {
+			rc.pw.Close()
+		} else {
+			rc.pw.CloseWithError(err)
+		}
+	})
+}
+
+// lenientWriter writes to w until it fails, then discards the rest, so a
+// failing local upload does not break the read of the upstream.
+type lenientWriter struct {
+	w      io.Writer
+	failed bool
+}
+
+func (lw *lenientWriter) Write(p []byte) (int, error) {
+	if !lw.failed {
+		if _, err := lw.w.Write(p); err != nil {
+			lw.failed = true
+		}
+	}
+	return len(p), nil
+}
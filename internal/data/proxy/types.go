@@ -15,24 +15,152 @@
 package proxy
 
 import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/jlsalvador/simple-registry/internal/data"
 )
 
+// Policy selects when the pull-through cache asks the upstream.
+type Policy string
+
+const (
+	// PolicyPreferUpstream checks the tags against the upstream, and serves the
+	// cached manifest if the upstream fails. It is the default policy.
+	PolicyPreferUpstream Policy = "prefer-upstream"
+	// PolicyPreferCache serves the cached content, and asks the upstream only
+	// for what is not cached.
+	PolicyPreferCache Policy = "prefer-cache"
+	// PolicyCacheOnly serves the cached content, never asking the upstream.
+	PolicyCacheOnly Policy = "cache-only"
+)
+
+// ParsePolicy returns the policy named s, or PolicyPreferUpstream if s is
+// empty.
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(s); p {
+	case "":
+		return PolicyPreferUpstream, nil
+	case PolicyPreferUpstream, PolicyPreferCache, PolicyCacheOnly:
+		return p, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrPolicyInvalid, s)
+}
+
+// Mirror is an alternative upstream of a [Proxy], used when the preferred
+// ones fail or are down.
+type Mirror struct {
+	Url      string
+	Username string
+	Password string
+	Client   *Client // With the Timeout of the Proxy if nil.
+
+	// Priority orders the upstreams, the lowest first. The [Proxy.Url] has
+	// priority zero.
+	Priority int
+}
+
+// Rewrite maps the local repositories matching Regexp to the upstream ones,
+// replacing the match by Replacement. It could refer to the submatches, like
+// "$1" or "${name}".
+type Rewrite struct {
+	Regexp      regexp.Regexp
+	Replacement string
+}
+
 type Proxy struct {
+	Name     string
 	Url      string
 	Timeout  time.Duration
 	Username string
 	Password string
 	Scopes   []string
+	Client   *Client // With the Timeout if nil.
+
+	// TTL is how long a cached tag is served without checking upstream for a
+	// newer manifest. Disabled if zero.
+	TTL time.Duration
+
+	// NegativeTTL is how long an upstream "not found" is remembered, so it is
+	// not requested again. Disabled if zero.
+	NegativeTTL time.Duration
+
+	// Policy is PolicyPreferUpstream if empty.
+	Policy Policy
+
+	// Mirrors are tried, by priority, when an upstream request fails.
+	Mirrors []Mirror
+
+	// Rewrites are applied, the first matching one, to the repositories
+	// requested to the upstreams.
+	Rewrites []Rewrite
+
+	// Prefetch are the images fetched, and refreshed, before being pulled.
+	Prefetch []Prefetch
+
+	// PrefetchInterval is how often the Prefetch images are refreshed. They
+	// are fetched once on start if zero.
+	PrefetchInterval time.Duration
+
+	// HealthCheckInterval is how often the upstreams are probed, so the ones
+	// down are skipped. Disabled if zero.
+	HealthCheckInterval time.Duration
+}
+
+// policy returns the policy of the proxy, PolicyCacheOnly if there is no proxy.
+func (p *Proxy) policy() Policy {
+	if p == nil {
+		return PolicyCacheOnly
+	}
+	if p.Policy == "" {
+		return PolicyPreferUpstream
+	}
+	return p.Policy
+}
+
+// upstreamRepo returns the name of repo in the upstream.
+func (p *Proxy) upstreamRepo(repo string) string {
+	for _, r := range p.Rewrites {
+		if r.Regexp.MatchString(repo) {
+			return r.Regexp.ReplaceAllString(repo, r.Replacement)
+		}
+	}
+	return repo
 }
 
 type ProxyDataStorage struct {
 	Next    data.DataStorage
 	Proxies []Proxy
+
+	mu      sync.Mutex
+	cache   cache
+	flights map[string]*flight
+	health  map[string]*health
+
+	// fills are the upstream blobs being cached in background.
+	fills sync.WaitGroup
 }
 
+// NewProxyDataStorage returns a pull-through cache of the proxies into ds. The
+// proxies and mirrors without a [Client] get one, shared by their requests.
 func NewProxyDataStorage(ds data.DataStorage, proxies []Proxy) *ProxyDataStorage {
-	return &ProxyDataStorage{ds, proxies}
+	proxies = slices.Clone(proxies)
+	for i := range proxies {
+		p := &proxies[i]
+		if p.Client == nil {
+			p.Client = newClient(p.Timeout, nil)
+		}
+
+		p.Mirrors = slices.Clone(p.Mirrors)
+		for j := range p.Mirrors {
+			if p.Mirrors[j].Client == nil {
+				p.Mirrors[j].Client = newClient(p.Timeout, nil)
+			}
+		}
+	}
+
+	return &ProxyDataStorage{Next: ds, Proxies: proxies}
 }
@@ -15,6 +15,7 @@
 package proxy_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/jlsalvador/simple-registry/internal/data/filesystem"
@@ -35,3 +36,25 @@ func TestNewProxyDataStorage(t *testing.T) {
 		t.Errorf("expected 1 proxy, got %d", len(s.Proxies))
 	}
 }
+
+func TestParsePolicy(t *testing.T) {
+	for _, tt := range []struct {
+		s       string
+		want    proxy.Policy
+		wantErr error
+	}{
+		{"", proxy.PolicyPreferUpstream, nil},
+		{"prefer-upstream", proxy.PolicyPreferUpstream, nil},
+		{"prefer-cache", proxy.PolicyPreferCache, nil},
+		{"cache-only", proxy.PolicyCacheOnly, nil},
+		{"offline", "", proxy.ErrPolicyInvalid},
+	} {
+		got, err := proxy.ParsePolicy(tt.s)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%q: expected error %v, got %v", tt.s, tt.wantErr, err)
+		}
+		if got != tt.want {
+			t.Errorf("%q: expected %q, got %q", tt.s, tt.want, got)
+		}
+	}
+}
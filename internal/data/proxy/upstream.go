@@ -0,0 +1,236 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/version"
+	"github.com/jlsalvador/simple-registry/pkg/log"
+)
+
+// UpstreamStatus is the health of an upstream of a pull-through cache.
+type UpstreamStatus struct {
+	Proxy     string    `json:"proxy"`
+	Url       string    `json:"url"`
+	Priority  int       `json:"priority"`
+	Up        bool      `json:"up"`
+	LastCheck time.Time `json:"lastCheck,omitzero"`
+	LastError string    `json:"lastError,omitempty"`
+}
+
+// health is the result of the last probe of an upstream.
+type health struct {
+	down      bool
+	lastCheck time.Time
+	lastError string
+}
+
+// upstream is one of the upstreams of a proxy, with its own url and
+// credentials.
+type upstream struct {
+	*Proxy
+	priority int
+}
+
+// upstreams returns the upstreams of proxy by priority.
+func upstreams(proxy *Proxy) []upstream {
+	out := []upstream{{Proxy: proxy}}
+	for _, m := range proxy.Mirrors {
+		p := *proxy
+		p.Url = m.Url
+		p.Username = m.Username
+		p.Password = m.Password
+		p.Client = m.Client
+		p.Mirrors = nil
+		out = append(out, upstream{Proxy: &p, priority: m.Priority})
+	}
+	slices.SortStableFunc(out, func(a, b upstream) int {
+		return a.priority - b.priority
+	})
+	return out
+}
+
+// isDown reports whether the last probe of the upstream at url failed.
+func (s *ProxyDataStorage) isDown(url string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h, ok := s.health[url]
+	return ok && h.down
+}
+
+// failover calls fn with the upstreams of proxy, by priority, and the name of
+// repo in them, until one of them succeeds or reports the content does not
+// exist. The upstreams down are skipped, unless all of them are down.
+func (s *ProxyDataStorage) failover(
+	proxy *Proxy,
+	repo string,
+	fn func(upstream *Proxy, upstreamRepo string) error,
+) error {
+	upstreamRepo := proxy.upstreamRepo(repo)
+
+	all := upstreams(proxy)
+	available := slices.DeleteFunc(slices.Clone(all), func(u upstream) bool {
+		return s.isDown(u.Url)
+	})
+	if len(available) == 0 {
+		available = all
+	}
+
+	var err error
+	for i, u := range available {
+		err = fn(u.Proxy, upstreamRepo)
+		if err == nil || errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if i < len(available)-1 {
+			log.Warn(
+				"service.name", version.AppName,
+				"service.version", version.AppVersion,
+				"event.dataset", "internal.proxy",
+				"error.message", err.Error(),
+				"message", fmt.Sprintf("upstream %s of %s failed, trying the next one", u.Url, proxy.Name),
+			).Print()
+		}
+	}
+	return err
+}
+
+// Run probes the upstreams of the proxies with a [Proxy.HealthCheckInterval],
+// and prefetches their [Proxy.Prefetch] images, until ctx is done.
+func (s *ProxyDataStorage) Run(ctx context.Context) {
+	wg := sync.WaitGroup{}
+	for _, p := range s.Proxies {
+		if len(p.Prefetch) > 0 {
+			wg.Go(func() {
+				s.runPrefetch(ctx, &p)
+			})
+		}
+
+		if p.HealthCheckInterval <= 0 {
+			continue
+		}
+		for _, u := range upstreams(&p) {
+			wg.Go(func() {
+				s.runProbes(ctx, u.Proxy, p.HealthCheckInterval)
+			})
+		}
+	}
+	wg.Wait()
+}
+
+func (s *ProxyDataStorage) runProbes(ctx context.Context, upstream *Proxy, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.probe(upstream)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probe requests the "/v2/" endpoint of the upstream, which answers either
+// 200 or 401 when it is up.
+func (s *ProxyDataStorage) probe(upstream *Proxy) {
+	url := strings.TrimRight(upstream.Url, "/") + "/v2/"
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err == nil {
+		var resp *http.Response
+		resp, err = upstream.client().http.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusUnauthorized {
+				err = errors.Join(ErrUpstreamError, fmt.Errorf("upstream probe failed: %s", resp.Status))
+			}
+		}
+	}
+
+	s.mu.Lock()
+	if s.health == nil {
+		s.health = map[string]*health{}
+	}
+	h, ok := s.health[upstream.Url]
+	if !ok {
+		h = &health{}
+		s.health[upstream.Url] = h
+	}
+	wasDown := h.down
+	h.down = err != nil
+	h.lastCheck = time.Now()
+	h.lastError = ""
+	if err != nil {
+		h.lastError = err.Error()
+	}
+	s.mu.Unlock()
+
+	switch {
+	case err != nil && !wasDown:
+		log.Warn(
+			"service.name", version.AppName,
+			"service.version", version.AppVersion,
+			"event.dataset", "internal.proxy",
+			"error.message", err.Error(),
+			"message", fmt.Sprintf("upstream %s of %s is down", upstream.Url, upstream.Name),
+		).Print()
+	case err == nil && wasDown:
+		log.Info(
+			"service.name", version.AppName,
+			"service.version", version.AppVersion,
+			"event.dataset", "internal.proxy",
+			"message", fmt.Sprintf("upstream %s of %s is up", upstream.Url, upstream.Name),
+		).Print()
+	}
+}
+
+// Status returns the health of the upstreams of every proxy. The upstreams
+// not probed yet are reported as up.
+func (s *ProxyDataStorage) Status() []UpstreamStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := []UpstreamStatus{}
+	for _, p := range s.Proxies {
+		for _, u := range upstreams(&p) {
+			st := UpstreamStatus{
+				Proxy:    p.Name,
+				Url:      u.Url,
+				Priority: u.priority,
+				Up:       true,
+			}
+			if h, ok := s.health[u.Url]; ok {
+				st.Up = !h.down
+				st.LastCheck = h.lastCheck
+				st.LastError = h.lastError
+			}
+			statuses = append(statuses, st)
+		}
+	}
+	return statuses
+}
@@ -0,0 +1,231 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data/filesystem"
+	"github.com/jlsalvador/simple-registry/internal/data/proxy"
+	"github.com/jlsalvador/simple-registry/pkg/digest"
+)
+
+// testUpstream serves blob, or answers status to everything but the probes
+// if status is not zero. It counts the requests that are not probes.
+func testUpstream(t *testing.T, blob []byte, status *atomic.Int32) (*httptest.Server, *atomic.Int32) {
+	requests := &atomic.Int32{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code := int(status.Load())
+		if r.URL.Path == "/v2/" {
+			if code == 0 {
+				code = http.StatusOK
+			}
+			w.WriteHeader(code)
+			return
+		}
+		requests.Add(1)
+		if code != 0 {
+			w.WriteHeader(code)
+			return
+		}
+		_, _ = w.Write(blob)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, requests
+}
+
+func testDigest(blob []byte) string {
+	hasher, _ := digest.NewHasher("sha256")
+	hasher.Write(blob)
+	return "sha256:" + hasher.GetHashAsString()
+}
+
+func TestBlobsGet_MirrorFailover(t *testing.T) {
+	blob := []byte("layer")
+	dgst := testDigest(blob)
+
+	primaryStatus, mirrorStatus := &atomic.Int32{}, &atomic.Int32{}
+	primaryStatus.Store(http.StatusBadGateway)
+	primary, primaryRequests := testUpstream(t, blob, primaryStatus)
+	mirror, mirrorRequests := testUpstream(t, blob, mirrorStatus)
+	backup, backupRequests := testUpstream(t, blob, &atomic.Int32{})
+
+	p := proxy.Proxy{
+		Url:     primary.URL,
+		Timeout: 5 * time.Second,
+		Scopes:  []string{".*"},
+		Mirrors: []proxy.Mirror{
+			{Url: backup.URL, Priority: 20},
+			{Url: mirror.URL, Priority: 10},
+		},
+	}
+	s := proxy.NewProxyDataStorage(filesystem.NewFilesystemDataStorage(t.TempDir()), []proxy.Proxy{p})
+
+	rc, _, err := s.BlobsGet("repo", dgst)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, _ := io.ReadAll(rc)
+	rc.Close()
+	if !bytes.Equal(got, blob) {
+		t.Errorf("expected %q, got %q", blob, got)
+	}
+	if primaryRequests.Load() != 1 || mirrorRequests.Load() != 1 || backupRequests.Load() != 0 {
+		t.Errorf(
+			"expected 1, 1 and 0 upstream requests, got %d, %d and %d",
+			primaryRequests.Load(), mirrorRequests.Load(), backupRequests.Load(),
+		)
+	}
+
+	// Not found is final, the other upstreams are not asked.
+	primaryStatus.Store(http.StatusNotFound)
+	if _, _, err := s.BlobsGet("repo", testDigest([]byte("other"))); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+	if mirrorRequests.Load() != 1 {
+		t.Errorf("expected 1 mirror request, got %d", mirrorRequests.Load())
+	}
+}
+
+func TestRun_HealthCheck(t *testing.T) {
+	blob := []byte("layer")
+
+	primaryStatus := &atomic.Int32{}
+	primaryStatus.Store(http.StatusServiceUnavailable)
+	primary, primaryRequests := testUpstream(t, blob, primaryStatus)
+	mirror, mirrorRequests := testUpstream(t, blob, &atomic.Int32{})
+
+	p := proxy.Proxy{
+		Name:                "docker-io",
+		Url:                 primary.URL,
+		Timeout:             5 * time.Second,
+		Scopes:              []string{".*"},
+		Mirrors:             []proxy.Mirror{{Url: mirror.URL, Priority: 10}},
+		HealthCheckInterval: 10 * time.Millisecond,
+	}
+	s := proxy.NewProxyDataStorage(filesystem.NewFilesystemDataStorage(t.TempDir()), []proxy.Proxy{p})
+
+	// Not probed yet.
+	for _, st := range s.Status() {
+		if !st.Up {
+			t.Errorf("expected %s up before probing", st.Url)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	waitFor := func(up bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			statuses := s.Status()
+			if len(statuses) == 2 && !statuses[0].LastCheck.IsZero() && statuses[0].Up == up {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("expected primary up=%v, got %+v", up, s.Status())
+	}
+
+	waitFor(false)
+	statuses := s.Status()
+	if statuses[0].Proxy != "docker-io" || statuses[0].Url != primary.URL || statuses[0].LastError == "" {
+		t.Errorf("unexpected primary status %+v", statuses[0])
+	}
+	if statuses[1].Url != mirror.URL || statuses[1].Priority != 10 || !statuses[1].Up {
+		t.Errorf("unexpected mirror status %+v", statuses[1])
+	}
+
+	// The primary is down, so it is skipped.
+	rc, _, err := s.BlobsGet("repo", testDigest(blob))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, _ = io.ReadAll(rc)
+	rc.Close()
+	if primaryRequests.Load() != 0 || mirrorRequests.Load() != 1 {
+		t.Errorf(
+			"expected 0 and 1 upstream requests, got %d and %d",
+			primaryRequests.Load(), mirrorRequests.Load(),
+		)
+	}
+
+	// The primary recovers.
+	primaryStatus.Store(0)
+	waitFor(true)
+}
+
+func TestBlobsGet_Rewrite(t *testing.T) {
+	blob := []byte("layer")
+	dgst := testDigest(blob)
+
+	var path atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path.Store(r.URL.Path)
+		_, _ = w.Write(blob)
+	}))
+	defer srv.Close()
+
+	storage := filesystem.NewFilesystemDataStorage(t.TempDir())
+	p := proxy.Proxy{
+		Url:     srv.URL,
+		Timeout: 5 * time.Second,
+		Scopes:  []string{"^dockerhub/.+$"},
+		Rewrites: []proxy.Rewrite{
+			{Regexp: *regexp.MustCompile(`^dockerhub/([^/]+)$`), Replacement: "library/$1"},
+			{Regexp: *regexp.MustCompile(`^dockerhub/`), Replacement: ""},
+		},
+	}
+	s := proxy.NewProxyDataStorage(storage, []proxy.Proxy{p})
+
+	tests := []struct {
+		repo     string
+		upstream string
+	}{
+		{"dockerhub/nginx", "library/nginx"},
+		{"dockerhub/bitnami/nginx", "bitnami/nginx"},
+	}
+	for _, tt := range tests {
+		rc, _, err := s.BlobsGet(tt.repo, dgst)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_, _ = io.ReadAll(rc)
+		rc.Close()
+
+		if want := "/v2/" + tt.upstream + "/blobs/" + dgst; path.Load() != want {
+			t.Errorf("expected upstream path %q, got %q", want, path.Load())
+		}
+
+		// Cached under the local name.
+		rc, _, err = storage.BlobsGet(tt.repo, dgst)
+		if err != nil {
+			t.Fatalf("expected blob cached in %s, got %v", tt.repo, err)
+		}
+		rc.Close()
+	}
+}
@@ -18,6 +18,8 @@ import (
 	"io"
 	"iter"
 	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
 )
 
 // Blobs upload
@@ -58,6 +60,22 @@ func (s *ProxyDataStorage) BlobsUploadSize(repo, uuid string) (size int64, err e
 	return s.Next.BlobsUploadSize(repo, uuid)
 }
 
+func (s *ProxyDataStorage) BlobsUploadStartedAt(repo, uuid string) (startedAt time.Time, err error) {
+	if s.Next == nil {
+		return time.Now(), ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsUploadStartedAt(repo, uuid)
+}
+
+func (s *ProxyDataStorage) BlobsUploadsList() (uploads iter.Seq[data.Upload], err error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsUploadsList()
+}
+
 // Blobs
 
 func (s *ProxyDataStorage) BlobsDelete(repo, digest string) error {
@@ -82,6 +100,21 @@ func (s *ProxyDataStorage) BlobLastAccess(digest string) (lastAccess time.Time,
 	return s.Next.BlobLastAccess(digest)
 }
 
+func (s *ProxyDataStorage) BlobRepositories(digest string) (repos []string, err error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobRepositories(digest)
+}
+func (s *ProxyDataStorage) BlobsLink(repo, digest string) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsLink(repo, digest)
+}
+
 // Manifests
 
 func (s *ProxyDataStorage) ManifestPut(repo, reference string, r io.Reader) (dgst string, err error) {
@@ -113,6 +146,16 @@ func (s *ProxyDataStorage) ManifestLastAccess(digest string) (lastAccess time.Ti
 	return s.Next.BlobLastAccess(digest)
 }
 
+// Tags
+
+func (s *ProxyDataStorage) TagLastModified(repo, tag string) (lastModified time.Time, err error) {
+	if s.Next == nil {
+		return time.Now(), ErrDataStorageNotInitialized
+	}
+
+	return s.Next.TagLastModified(repo, tag)
+}
+
 // Repositories
 
 func (s *ProxyDataStorage) RepositoriesList() ([]string, error) {
@@ -122,3 +165,10 @@ func (s *ProxyDataStorage) RepositoriesList() ([]string, error) {
 
 	return s.Next.RepositoriesList()
 }
+func (s *ProxyDataStorage) RepositoryDelete(repo string) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	return s.Next.RepositoryDelete(repo)
+}
@@ -17,6 +17,7 @@ package proxy_test
 import (
 	"bytes"
 	"errors"
+	"slices"
 	"strings"
 	"testing"
 
@@ -44,6 +45,12 @@ func TestWrappers_NilNext(t *testing.T) {
 	if _, err := s.BlobsUploadSize("r", "u"); !errors.Is(err, proxy.ErrDataStorageNotInitialized) {
 		t.Errorf("BlobsUploadSize: expected ErrDataStorageNotInitialized, got %v", err)
 	}
+	if _, err := s.BlobsUploadStartedAt("r", "u"); !errors.Is(err, proxy.ErrDataStorageNotInitialized) {
+		t.Errorf("BlobsUploadStartedAt: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if _, err := s.BlobsUploadsList(); !errors.Is(err, proxy.ErrDataStorageNotInitialized) {
+		t.Errorf("BlobsUploadsList: expected ErrDataStorageNotInitialized, got %v", err)
+	}
 	if err := s.BlobsDelete("r", "d"); !errors.Is(err, proxy.ErrDataStorageNotInitialized) {
 		t.Errorf("BlobsDelete: expected ErrDataStorageNotInitialized, got %v", err)
 	}
@@ -53,6 +60,12 @@ func TestWrappers_NilNext(t *testing.T) {
 	if _, err := s.BlobLastAccess("d"); !errors.Is(err, proxy.ErrDataStorageNotInitialized) {
 		t.Errorf("BlobLastAccess: expected ErrDataStorageNotInitialized, got %v", err)
 	}
+	if _, err := s.BlobRepositories("d"); !errors.Is(err, proxy.ErrDataStorageNotInitialized) {
+		t.Errorf("BlobRepositories: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if err := s.BlobsLink("r", "d"); !errors.Is(err, proxy.ErrDataStorageNotInitialized) {
+		t.Errorf("BlobsLink: expected ErrDataStorageNotInitialized, got %v", err)
+	}
 	if err := s.ManifestDelete("r", "ref"); !errors.Is(err, proxy.ErrDataStorageNotInitialized) {
 		t.Errorf("ManifestDelete: expected ErrDataStorageNotInitialized, got %v", err)
 	}
@@ -62,9 +75,15 @@ func TestWrappers_NilNext(t *testing.T) {
 	if _, err := s.ManifestLastAccess("d"); !errors.Is(err, proxy.ErrDataStorageNotInitialized) {
 		t.Errorf("ManifestLastAccess: expected ErrDataStorageNotInitialized, got %v", err)
 	}
+	if _, err := s.TagLastModified("r", "t"); !errors.Is(err, proxy.ErrDataStorageNotInitialized) {
+		t.Errorf("TagLastModified: expected ErrDataStorageNotInitialized, got %v", err)
+	}
 	if _, err := s.RepositoriesList(); !errors.Is(err, proxy.ErrDataStorageNotInitialized) {
 		t.Errorf("RepositoriesList: expected ErrDataStorageNotInitialized, got %v", err)
 	}
+	if err := s.RepositoryDelete("r"); !errors.Is(err, proxy.ErrDataStorageNotInitialized) {
+		t.Errorf("RepositoryDelete: expected ErrDataStorageNotInitialized, got %v", err)
+	}
 	if _, err := s.ManifestPut("r", "ref", strings.NewReader("")); !errors.Is(err, proxy.ErrDataStorageNotInitialized) {
 		t.Errorf("ManifestPut: expected ErrDataStorageNotInitialized, got %v", err)
 	}
@@ -96,6 +115,14 @@ func TestWrappers_BlobDelegation(t *testing.T) {
 	if size, err := s.BlobsUploadSize("r", uuid); err != nil || size != int64(len(blob)) {
 		t.Errorf("BlobsUploadSize: %v %v", size, err)
 	}
+	if _, err := s.BlobsUploadStartedAt("r", uuid); err != nil {
+		t.Errorf("BlobsUploadStartedAt: %v", err)
+	}
+	if uploads, err := s.BlobsUploadsList(); err != nil {
+		t.Errorf("BlobsUploadsList: %v", err)
+	} else if got := slices.Collect(uploads); len(got) != 1 || got[0].UUID != uuid {
+		t.Errorf("BlobsUploadsList: expected upload %s, got %v", uuid, got)
+	}
 	if err := s.BlobsUploadCommit("r", uuid, dgst); err != nil {
 		t.Errorf("BlobsUploadCommit: %v", err)
 	}
@@ -105,6 +132,12 @@ func TestWrappers_BlobDelegation(t *testing.T) {
 	if _, err := s.BlobLastAccess(dgst); err != nil {
 		t.Errorf("BlobLastAccess: %v", err)
 	}
+	if repos, err := s.BlobRepositories(dgst); err != nil || !slices.Equal(repos, []string{"r"}) {
+		t.Errorf("BlobRepositories: %v %v", repos, err)
+	}
+	if err := s.BlobsLink("other", dgst); err != nil {
+		t.Errorf("BlobsLink: %v", err)
+	}
 	if err := s.BlobsDelete("r", dgst); err != nil {
 		t.Errorf("BlobsDelete: %v", err)
 	}
@@ -118,6 +151,9 @@ func TestWrappers_ManifestAndRepoDelegation(t *testing.T) {
 	if err != nil || !registry.RegExprDigest.MatchString(dgst) {
 		t.Errorf("ManifestPut: %v %v", dgst, err)
 	}
+	if _, err := s.TagLastModified("r", "ref"); err != nil {
+		t.Errorf("TagLastModified: %v", err)
+	}
 	if err := s.ManifestDelete("r", "ref"); err != nil {
 		t.Errorf("ManifestDelete: %v", err)
 	}
@@ -130,4 +166,7 @@ func TestWrappers_ManifestAndRepoDelegation(t *testing.T) {
 	if repos, err := s.RepositoriesList(); err != nil || len(repos) != 1 {
 		t.Errorf("RepositoriesList: %v %v", repos, err)
 	}
+	if err := s.RepositoryDelete("r"); err != nil {
+		t.Errorf("RepositoryDelete: %v", err)
+	}
 }
@@ -0,0 +1,417 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"slices"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/internal/data/immutable"
+	"github.com/jlsalvador/simple-registry/internal/data/proxy"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+// Match returns if the repository is limited by the quota.
+func (q Quota) Match(repo string) bool {
+	for i := range q.Scopes {
+		if q.Scopes[i].MatchString(repo) {
+			return true
+		}
+	}
+	return false
+}
+
+// quotasFor returns the indexes of the quotas limiting the repository.
+func (s *QuotaDataStorage) quotasFor(repo string) []int {
+	var quotas []int
+	for i, q := range s.Quotas {
+		if q.Match(repo) {
+			quotas = append(quotas, i)
+		}
+	}
+	return quotas
+}
+
+// lock locks the usages of the quotas, in order, so pushes limited by several
+// quotas could not deadlock.
+func (s *QuotaDataStorage) lock(quotas []int) {
+	for _, i := range quotas {
+		s.usages[i].mu.Lock()
+	}
+}
+
+func (s *QuotaDataStorage) unlock(quotas []int) {
+	for _, i := range quotas {
+		s.usages[i].mu.Unlock()
+	}
+}
+
+// local returns the data storage under the immutable tags policy and the
+// pull-through cache, if any.
+//
+// The usage is read from the stored repositories, without mirroring upstream.
+func (s *QuotaDataStorage) local() data.DataStorage {
+	ds := s.Next
+	if d, ok := ds.(*immutable.ImmutableDataStorage); ok {
+		ds = d.Next
+	}
+	if d, ok := ds.(*proxy.ProxyDataStorage); ok {
+		ds = d.Next
+	}
+	return ds
+}
+
+func sum(sizes map[string]int64) (total int64) {
+	for _, size := range sizes {
+		total += size
+	}
+	return total
+}
+
+// blobSize returns the stored size of the blob.
+func (s *QuotaDataStorage) blobSize(digest string) (int64, error) {
+	r, size, err := s.local().BlobsGet("", digest)
+	if err != nil {
+		return -1, err
+	}
+	r.Close()
+	return size, nil
+}
+
+// manifestSizes returns the size of the manifest, and the stored size of the
+// blobs it references, by digest, without the ones already in known.
+//
+// The sizes declared by the manifest are not trusted, and the blobs that are
+// not stored are not counted.
+func (s *QuotaDataStorage) manifestSizes(known map[string]int64, digest string, payload []byte) (
+	sizes map[string]int64,
+	err error,
+) {
+	sizes = map[string]int64{}
+	if _, ok := known[digest]; !ok {
+		sizes[digest] = int64(len(payload))
+	}
+
+	refs, err := registry.References(payload)
+	if err != nil {
+		// Not a manifest referencing blobs.
+		return sizes, nil
+	}
+	for _, ref := range refs {
+		if !registry.RegExprDigest.MatchString(ref) {
+			continue
+		}
+		if _, ok := known[ref]; ok {
+			continue
+		}
+		if _, ok := sizes[ref]; ok {
+			continue
+		}
+
+		size, err := s.blobSize(ref)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		sizes[ref] = size
+	}
+	return sizes, nil
+}
+
+// repositories returns the stored repositories limited by the quota.
+func (s *QuotaDataStorage) repositories(q Quota) ([]string, error) {
+	allRepos, err := s.local().RepositoriesList()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	repos := []string{}
+	for _, repo := range allRepos {
+		if q.Match(repo) {
+			repos = append(repos, repo)
+		}
+	}
+	return repos, nil
+}
+
+// load computes the usage of the quota i, if not computed yet: the stored
+// sizes by digest of the manifests, and the blobs they reference, of the
+// repositories limited by the quota, and their amount of tags.
+//
+// Blobs shared between repositories are counted once.
+//
+// The usage must be locked.
+func (s *QuotaDataStorage) load(i int) error {
+	u := s.usages[i]
+	if u.sizes != nil {
+		return nil
+	}
+
+	repos, err := s.repositories(s.Quotas[i])
+	if err != nil {
+		return err
+	}
+
+	ds := s.local()
+	sizes := map[string]int64{}
+	tags := 0
+	for _, repo := range repos {
+		repoTags, err := ds.TagsList(repo)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		tags += len(repoTags)
+
+		digests, err := ds.ManifestsList(repo)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return err
+		}
+		for digest := range digests {
+			r, _, _, err := ds.ManifestGet(repo, digest)
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					continue
+				}
+				return err
+			}
+			payload, err := io.ReadAll(r)
+			r.Close()
+			if err != nil {
+				return err
+			}
+			added, err := s.manifestSizes(sizes, digest, payload)
+			if err != nil {
+				return err
+			}
+			maps.Copy(sizes, added)
+		}
+	}
+
+	u.sizes, u.tags = sizes, tags
+	return nil
+}
+
+// reset drops the usages of the quotas limiting the repository, so they are
+// computed again on the next push.
+func (s *QuotaDataStorage) reset(repo string) {
+	quotas := s.quotasFor(repo)
+	s.lock(quotas)
+	defer s.unlock(quotas)
+
+	for _, i := range quotas {
+		s.usages[i].sizes = nil
+	}
+}
+
+// Reset drops the usages of every quota, so they are computed again on the
+// next push.
+//
+// Deletions through the decorator are accounted, Reset is for the ones made
+// under it, like the ones of the garbage collector.
+func (s *QuotaDataStorage) Reset() {
+	for _, u := range s.usages {
+		u.mu.Lock()
+		u.sizes = nil
+		u.mu.Unlock()
+	}
+}
+
+// Usage returns the current usage of every quota.
+func (s *QuotaDataStorage) Usage() ([]Usage, error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	usages := make([]Usage, 0, len(s.Quotas))
+	for i, q := range s.Quotas {
+		u := s.usages[i]
+		u.mu.Lock()
+		err := s.load(i)
+		used, tags := sum(u.sizes), u.tags
+		u.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+
+		repos, err := s.repositories(q)
+		if err != nil {
+			return nil, err
+		}
+
+		scopes := make([]string, len(q.Scopes))
+		for i := range q.Scopes {
+			scopes[i] = q.Scopes[i].String()
+		}
+
+		slices.Sort(repos)
+		usages = append(usages, Usage{
+			Name:         q.Name,
+			Scopes:       scopes,
+			MaxBytes:     q.MaxBytes,
+			MaxTags:      q.MaxTags,
+			Bytes:        used,
+			Tags:         tags,
+			Repositories: repos,
+		})
+	}
+	return usages, nil
+}
+
+// reserve adds the blob to the usages of the quotas, unless it does not fit
+// in them, which returns [data.ErrQuotaExceeded].
+func (s *QuotaDataStorage) reserve(quotas []int, digest string, size int64) error {
+	s.lock(quotas)
+	defer s.unlock(quotas)
+
+	for _, i := range quotas {
+		if err := s.load(i); err != nil {
+			return err
+		}
+
+		q, sizes := s.Quotas[i], s.usages[i].sizes
+		if _, ok := sizes[digest]; ok || q.MaxBytes == 0 {
+			continue
+		}
+		if sum(sizes)+size > q.MaxBytes {
+			return fmt.Errorf("%w: %q allows %d bytes", data.ErrQuotaExceeded, q.Name, q.MaxBytes)
+		}
+	}
+
+	for _, i := range quotas {
+		if sizes := s.usages[i].sizes; sizes != nil {
+			if _, ok := sizes[digest]; !ok {
+				sizes[digest] = size
+			}
+		}
+	}
+	return nil
+}
+
+// BlobsUploadCommit rejects the blob if it does not fit in the quotas of the
+// repository, counting it otherwise.
+//
+// A blob is counted from its commit until the usage is computed again, when
+// only the blobs referenced by manifests are counted.
+func (s *QuotaDataStorage) BlobsUploadCommit(repo, uuid, digest string) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	quotas := s.quotasFor(repo)
+	if len(quotas) == 0 {
+		return s.Next.BlobsUploadCommit(repo, uuid, digest)
+	}
+
+	size, err := s.Next.BlobsUploadSize(repo, uuid)
+	if err != nil {
+		return err
+	}
+
+	if err := s.reserve(quotas, digest, size); err != nil {
+		return err
+	}
+
+	if err := s.Next.BlobsUploadCommit(repo, uuid, digest); err != nil {
+		// Drop the reserved blob.
+		s.reset(repo)
+		return err
+	}
+	return nil
+}
+
+// ManifestPut rejects the manifest if the blobs it references, or its tag, do
+// not fit in the quotas of the repository.
+//
+// Pushes that do not increase the usage, like tagging an already pushed
+// manifest, are allowed even if the quota is exceeded.
+func (s *QuotaDataStorage) ManifestPut(repo, reference string, r io.Reader) (digest string, err error) {
+	if s.Next == nil {
+		return "", ErrDataStorageNotInitialized
+	}
+
+	quotas := s.quotasFor(repo)
+	if len(quotas) == 0 {
+		return s.Next.ManifestPut(repo, reference, r)
+	}
+
+	payload, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	sum256 := sha256.Sum256(payload)
+	manifestDigest := "sha256:" + hex.EncodeToString(sum256[:])
+
+	// The usages are locked until the manifest is stored, small compared to
+	// the blobs, so two pushes could not exceed a quota together.
+	s.lock(quotas)
+	defer s.unlock(quotas)
+
+	isNewTag := false
+	if !registry.RegExprDigest.MatchString(reference) {
+		tags, err := s.local().TagsList(repo)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		isNewTag = !slices.Contains(tags, reference)
+	}
+
+	added := make([]map[string]int64, len(quotas))
+	for j, i := range quotas {
+		q := s.Quotas[i]
+		if err := s.load(i); err != nil {
+			return "", err
+		}
+		u := s.usages[i]
+
+		if q.MaxTags > 0 && isNewTag && u.tags+1 > q.MaxTags {
+			return "", fmt.Errorf("%w: %q allows %d tags", data.ErrQuotaExceeded, q.Name, q.MaxTags)
+		}
+
+		added[j], err = s.manifestSizes(u.sizes, manifestDigest, payload)
+		if err != nil {
+			return "", err
+		}
+		if size := sum(added[j]); q.MaxBytes > 0 && size > 0 && sum(u.sizes)+size > q.MaxBytes {
+			return "", fmt.Errorf("%w: %q allows %d bytes", data.ErrQuotaExceeded, q.Name, q.MaxBytes)
+		}
+	}
+
+	digest, err = s.Next.ManifestPut(repo, reference, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+
+	for j, i := range quotas {
+		u := s.usages[i]
+		maps.Copy(u.sizes, added[j])
+		if isNewTag {
+			u.tags++
+		}
+	}
+	return digest, nil
+}
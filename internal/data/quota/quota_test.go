@@ -0,0 +1,289 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/internal/data/memory"
+	"github.com/jlsalvador/simple-registry/internal/data/quota"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+func sha256Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func pushBlob(s *quota.QuotaDataStorage, repo string, data []byte) (registry.DescriptorManifest, error) {
+	d := registry.DescriptorManifest{
+		MediaType: "application/octet-stream",
+		Digest:    sha256Digest(data),
+		Size:      int64(len(data)),
+	}
+
+	uuid, err := s.BlobsUploadCreate(repo)
+	if err != nil {
+		return d, err
+	}
+	if err := s.BlobsUploadWrite(repo, uuid, bytes.NewReader(data), -1); err != nil {
+		return d, err
+	}
+	return d, s.BlobsUploadCommit(repo, uuid, d.Digest)
+}
+
+func imageManifest(t *testing.T, layers ...registry.DescriptorManifest) []byte {
+	t.Helper()
+
+	payload, err := json.Marshal(registry.ImageManifest{
+		SchemaVersion: 2,
+		MediaType:     registry.MediaTypeOCIImageManifest,
+		Config: registry.DescriptorManifest{
+			MediaType: "application/vnd.oci.image.config.v1+json",
+			Digest:    sha256Digest([]byte("{}")),
+			Size:      2,
+		},
+		Layers: layers,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+func putManifest(s *quota.QuotaDataStorage, repo, tag string, payload []byte) error {
+	_, err := s.ManifestPut(repo, tag, bytes.NewReader(payload))
+	return err
+}
+
+func newQuota(name, scope string, maxBytes int64, maxTags int) quota.Quota {
+	return quota.Quota{
+		Name:     name,
+		Scopes:   []regexp.Regexp{*regexp.MustCompile(scope)},
+		MaxBytes: maxBytes,
+		MaxTags:  maxTags,
+	}
+}
+
+func TestMaxBytes(t *testing.T) {
+	layerA := bytes.Repeat([]byte("a"), 1000)
+	layerB := bytes.Repeat([]byte("b"), 1000)
+
+	manifestA := imageManifest(t, registry.DescriptorManifest{
+		MediaType: "application/octet-stream",
+		Digest:    sha256Digest(layerA),
+		Size:      int64(len(layerA)),
+	})
+	usedA := int64(len(manifestA)) + 2 + 1000
+
+	s := quota.NewQuotaDataStorage(memory.NewMemoryDataStorage(0), []quota.Quota{
+		newQuota("team", "^team/.+$", usedA+1200, 0),
+	})
+
+	if _, err := pushBlob(s, "team/a", []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	descA, err := pushBlob(s, "team/a", layerA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := putManifest(s, "team/a", "latest", manifestA); err != nil {
+		t.Fatal(err)
+	}
+
+	// Blobs shared between repositories are counted once.
+	if _, err := pushBlob(s, "team/b", layerA); err != nil {
+		t.Fatal(err)
+	}
+	if err := putManifest(s, "team/b", "latest", imageManifest(t, descA)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Blobs are counted once referenced.
+	descB, err := pushBlob(s, "team/b", layerB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = putManifest(s, "team/b", "v2", imageManifest(t, descA, descB))
+	if !errors.Is(err, data.ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+
+	// Blobs that do not fit are rejected.
+	if _, err := pushBlob(s, "team/c", bytes.Repeat([]byte("c"), 1300)); !errors.Is(err, data.ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded, got %v", err)
+	}
+
+	// Already used blobs and manifests could be pushed again.
+	if _, err := pushBlob(s, "team/c", layerA); err != nil {
+		t.Errorf("expected already used blob accepted, got %v", err)
+	}
+	if err := putManifest(s, "team/c", "latest", manifestA); err != nil {
+		t.Errorf("expected already used manifest accepted, got %v", err)
+	}
+
+	// Other repositories are not limited.
+	desc, err := pushBlob(s, "other", layerB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := putManifest(s, "other", "latest", imageManifest(t, descA, desc)); err != nil {
+		t.Error(err)
+	}
+
+	usages, err := s.Usage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usages) != 1 {
+		t.Fatalf("expected 1 usage, got %d", len(usages))
+	}
+	// The rejected manifest blob is counted since its commit.
+	if want := usedA + int64(len(layerB)); usages[0].Bytes != want {
+		t.Errorf("expected %d bytes used, got %d", want, usages[0].Bytes)
+	}
+	if usages[0].Tags != 3 {
+		t.Errorf("expected 3 tags, got %d", usages[0].Tags)
+	}
+	if !slices.Equal(usages[0].Repositories, []string{"team/a", "team/b", "team/c"}) {
+		t.Errorf("unexpected repositories %v", usages[0].Repositories)
+	}
+	if !slices.Equal(usages[0].Scopes, []string{"^team/.+$"}) {
+		t.Errorf("unexpected scopes %v", usages[0].Scopes)
+	}
+}
+
+func TestMaxBytes_StoredSizes(t *testing.T) {
+	ds := memory.NewMemoryDataStorage(0)
+	s := quota.NewQuotaDataStorage(ds, []quota.Quota{
+		newQuota("repo", "^repo$", 1500, 0),
+	})
+
+	// Blobs uploaded to a repository without quota, fitting the quota alone.
+	var layers []registry.DescriptorManifest
+	for _, c := range []string{"a", "b"} {
+		desc, err := pushBlob(s, "other", bytes.Repeat([]byte(c), 1000))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The manifest lies about their size.
+		desc.Size = 1
+		layers = append(layers, desc)
+	}
+
+	err := putManifest(s, "repo", "latest", imageManifest(t, layers...))
+	if !errors.Is(err, data.ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded, got %v", err)
+	}
+
+	// Every committed blob is counted.
+	if _, err := pushBlob(s, "repo", bytes.Repeat([]byte("c"), 1000)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pushBlob(s, "repo", bytes.Repeat([]byte("d"), 1000)); !errors.Is(err, data.ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded, got %v", err)
+	}
+}
+
+func TestMaxTags(t *testing.T) {
+	s := quota.NewQuotaDataStorage(memory.NewMemoryDataStorage(0), []quota.Quota{
+		newQuota("repo", "^repo$", 0, 2),
+	})
+
+	manifest := imageManifest(t)
+	for _, tag := range []string{"v1", "v2"} {
+		if err := putManifest(s, "repo", tag, manifest); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := putManifest(s, "repo", "v3", manifest); !errors.Is(err, data.ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded, got %v", err)
+	}
+
+	// Existing tags and digests could be pushed.
+	if err := putManifest(s, "repo", "v2", imageManifest(t, registry.DescriptorManifest{})); err != nil {
+		t.Errorf("expected existing tag accepted, got %v", err)
+	}
+	if err := putManifest(s, "repo", sha256Digest(manifest), manifest); err != nil {
+		t.Errorf("expected digest accepted, got %v", err)
+	}
+
+	// Deleting a tag frees it.
+	if err := s.ManifestDelete("repo", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := putManifest(s, "repo", "v3", manifest); err != nil {
+		t.Errorf("expected tag accepted, got %v", err)
+	}
+}
+
+func TestReset(t *testing.T) {
+	ds := memory.NewMemoryDataStorage(0)
+	s := quota.NewQuotaDataStorage(ds, []quota.Quota{
+		newQuota("repo", "^repo$", 0, 1),
+	})
+
+	if err := putManifest(s, "repo", "v1", imageManifest(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deletions under the decorator are not seen until reset.
+	if err := ds.ManifestDelete("repo", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := putManifest(s, "repo", "v2", imageManifest(t)); !errors.Is(err, data.ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded, got %v", err)
+	}
+
+	s.Reset()
+	if err := putManifest(s, "repo", "v2", imageManifest(t)); err != nil {
+		t.Errorf("expected tag accepted, got %v", err)
+	}
+}
+
+func TestConcurrentPushes(t *testing.T) {
+	s := quota.NewQuotaDataStorage(memory.NewMemoryDataStorage(0), []quota.Quota{
+		newQuota("team", "^team/.+$", 0, 5),
+		newQuota("app", "^team/app$", 0, 0),
+	})
+
+	manifest := imageManifest(t)
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Go(func() {
+			putManifest(s, "team/app", fmt.Sprintf("v%d", i), manifest)
+		})
+	}
+	wg.Wait()
+
+	usages, err := s.Usage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usages[0].Tags != 5 {
+		t.Errorf("expected 5 tags, got %d", usages[0].Tags)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package quota is a DataStorage decorator to limit the bytes and tags pushed
+// to a repository, or to a set of repositories matched by regular expressions.
+package quota
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+)
+
+// Quota limits the repositories matched by any of its scopes, as a whole.
+type Quota struct {
+	Name     string
+	Scopes   []regexp.Regexp // Regular expressions matching the repository path.
+	MaxBytes int64           // Unlimited if zero.
+	MaxTags  int             // Unlimited if zero.
+}
+
+// Usage is the current usage of a [Quota].
+type Usage struct {
+	Name         string   `json:"name"`
+	Scopes       []string `json:"scopes"`
+	MaxBytes     int64    `json:"maxBytes,omitempty"`
+	MaxTags      int      `json:"maxTags,omitempty"`
+	Bytes        int64    `json:"bytes"`
+	Tags         int      `json:"tags"`
+	Repositories []string `json:"repositories"`
+}
+
+type QuotaDataStorage struct {
+	Next   data.DataStorage
+	Quotas []Quota
+
+	// usages are the usages of Quotas, by index.
+	usages []*usage
+}
+
+// usage is the usage of a quota, computed on its first push and kept by the
+// following ones.
+type usage struct {
+	// mu serializes the pushes to the repositories of the quota, so two of
+	// them could not exceed it together.
+	mu sync.Mutex
+
+	sizes map[string]int64 // By digest, nil if not computed.
+	tags  int
+}
+
+func NewQuotaDataStorage(ds data.DataStorage, quotas []Quota) *QuotaDataStorage {
+	usages := make([]*usage, len(quotas))
+	for i := range usages {
+		usages[i] = &usage{}
+	}
+	return &QuotaDataStorage{Next: ds, Quotas: quotas, usages: usages}
+}
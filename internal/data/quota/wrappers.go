@@ -0,0 +1,205 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota
+
+import (
+	"io"
+	"iter"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+)
+
+// Blobs upload
+
+func (s *QuotaDataStorage) BlobsUploadCreate(repo string) (uuid string, err error) {
+	if s.Next == nil {
+		return "", ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsUploadCreate(repo)
+}
+func (s *QuotaDataStorage) BlobsUploadCancel(repo, uuid string) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsUploadCancel(repo, uuid)
+}
+func (s *QuotaDataStorage) BlobsUploadWrite(repo, uuid string, r io.Reader, start int64) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsUploadWrite(repo, uuid, r, start)
+}
+func (s *QuotaDataStorage) BlobsUploadSize(repo, uuid string) (size int64, err error) {
+	if s.Next == nil {
+		return -1, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsUploadSize(repo, uuid)
+}
+
+func (s *QuotaDataStorage) BlobsUploadStartedAt(repo, uuid string) (startedAt time.Time, err error) {
+	if s.Next == nil {
+		return time.Now(), ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsUploadStartedAt(repo, uuid)
+}
+
+func (s *QuotaDataStorage) BlobsUploadsList() (uploads iter.Seq[data.Upload], err error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsUploadsList()
+}
+
+// Blobs
+
+func (s *QuotaDataStorage) BlobsGet(repo, digest string) (r io.ReadCloser, size int64, err error) {
+	if s.Next == nil {
+		return nil, -1, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsGet(repo, digest)
+}
+func (s *QuotaDataStorage) BlobsDelete(repo, digest string) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsDelete(repo, digest)
+}
+func (s *QuotaDataStorage) BlobsList() (digests iter.Seq[string], err error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsList()
+}
+func (s *QuotaDataStorage) BlobLastAccess(digest string) (lastAccess time.Time, err error) {
+	if s.Next == nil {
+		return time.Now(), ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobLastAccess(digest)
+}
+
+func (s *QuotaDataStorage) BlobRepositories(digest string) (repos []string, err error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobRepositories(digest)
+}
+func (s *QuotaDataStorage) BlobsLink(repo, digest string) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	return s.Next.BlobsLink(repo, digest)
+}
+
+// Manifests
+
+func (s *QuotaDataStorage) ManifestGet(repo, reference string) (
+	r io.ReadCloser,
+	size int64,
+	digest string,
+	err error,
+) {
+	if s.Next == nil {
+		return nil, -1, "", ErrDataStorageNotInitialized
+	}
+
+	return s.Next.ManifestGet(repo, reference)
+}
+func (s *QuotaDataStorage) ManifestDelete(repo, reference string) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	defer s.reset(repo)
+	return s.Next.ManifestDelete(repo, reference)
+}
+func (s *QuotaDataStorage) ManifestsList(repo string) (digests iter.Seq[string], err error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.ManifestsList(repo)
+}
+func (s *QuotaDataStorage) ManifestLastAccess(digest string) (lastAccess time.Time, err error) {
+	if s.Next == nil {
+		return time.Now(), ErrDataStorageNotInitialized
+	}
+
+	return s.Next.ManifestLastAccess(digest)
+}
+
+// Tags
+
+func (s *QuotaDataStorage) TagsList(repo string) ([]string, error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.TagsList(repo)
+}
+func (s *QuotaDataStorage) TagsListPage(repo string, n int, last string) ([]string, error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return data.TagsListPage(s.Next, repo, n, last)
+}
+func (s *QuotaDataStorage) TagLastModified(repo, tag string) (lastModified time.Time, err error) {
+	if s.Next == nil {
+		return time.Now(), ErrDataStorageNotInitialized
+	}
+
+	return s.Next.TagLastModified(repo, tag)
+}
+
+// Repositories
+
+func (s *QuotaDataStorage) RepositoriesList() ([]string, error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.RepositoriesList()
+}
+func (s *QuotaDataStorage) RepositoryDelete(repo string) error {
+	if s.Next == nil {
+		return ErrDataStorageNotInitialized
+	}
+
+	defer s.reset(repo)
+	return s.Next.RepositoryDelete(repo)
+}
+
+// Referrers
+
+func (s *QuotaDataStorage) ReferrersGet(repo, manifestDigest string) (digests iter.Seq[string], err error) {
+	if s.Next == nil {
+		return nil, ErrDataStorageNotInitialized
+	}
+
+	return s.Next.ReferrersGet(repo, manifestDigest)
+}
@@ -0,0 +1,167 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota_test
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jlsalvador/simple-registry/internal/data/memory"
+	"github.com/jlsalvador/simple-registry/internal/data/quota"
+)
+
+func TestWrappers_NilNext(t *testing.T) {
+	s := &quota.QuotaDataStorage{}
+
+	if _, _, err := s.BlobsGet("r", "d"); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("BlobsGet: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if _, err := s.BlobsUploadCreate("r"); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("BlobsUploadCreate: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if err := s.BlobsUploadCancel("r", "u"); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("BlobsUploadCancel: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if err := s.BlobsUploadWrite("r", "u", strings.NewReader(""), 0); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("BlobsUploadWrite: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if err := s.BlobsUploadCommit("r", "u", "d"); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("BlobsUploadCommit: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if _, err := s.BlobsUploadSize("r", "u"); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("BlobsUploadSize: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if _, err := s.BlobsUploadStartedAt("r", "u"); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("BlobsUploadStartedAt: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if _, err := s.BlobsUploadsList(); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("BlobsUploadsList: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if err := s.BlobsDelete("r", "d"); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("BlobsDelete: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if _, err := s.BlobsList(); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("BlobsList: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if _, err := s.BlobLastAccess("d"); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("BlobLastAccess: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if _, err := s.BlobRepositories("d"); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("BlobRepositories: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if err := s.BlobsLink("r", "d"); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("BlobsLink: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if _, err := s.ManifestPut("r", "ref", strings.NewReader("")); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("ManifestPut: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if _, _, _, err := s.ManifestGet("r", "ref"); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("ManifestGet: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if err := s.ManifestDelete("r", "ref"); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("ManifestDelete: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if _, err := s.ManifestsList("r"); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("ManifestsList: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if _, err := s.ManifestLastAccess("d"); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("ManifestLastAccess: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if _, err := s.TagsList("r"); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("TagsList: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if _, err := s.TagLastModified("r", "t"); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("TagLastModified: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if _, err := s.RepositoriesList(); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("RepositoriesList: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if err := s.RepositoryDelete("r"); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("RepositoryDelete: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+	if _, err := s.ReferrersGet("r", "d"); !errors.Is(err, quota.ErrDataStorageNotInitialized) {
+		t.Errorf("ReferrersGet: expected ErrDataStorageNotInitialized, got %v", err)
+	}
+}
+
+func TestWrappers_Delegation(t *testing.T) {
+	s := quota.NewQuotaDataStorage(memory.NewMemoryDataStorage(0), nil)
+
+	desc, err := pushBlob(s, "repo", []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := desc.Digest
+	manifest, err := s.ManifestPut("repo", "latest", bytes.NewReader([]byte("{}")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blobs, err := s.BlobsList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := slices.Collect(blobs); len(got) != 2 {
+		t.Errorf("expected 2 blobs, got %v", got)
+	}
+	if _, err := s.BlobLastAccess(digest); err != nil {
+		t.Error(err)
+	}
+	if repos, err := s.BlobRepositories(digest); err != nil || !slices.Equal(repos, []string{"repo"}) {
+		t.Errorf("expected [repo], got %v (%v)", repos, err)
+	}
+	if err := s.BlobsLink("other", digest); err != nil {
+		t.Error(err)
+	}
+
+	uuid, err := s.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.BlobsUploadStartedAt("repo", uuid); err != nil {
+		t.Error(err)
+	}
+	uploads, err := s.BlobsUploadsList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := slices.Collect(uploads); len(got) != 1 || got[0].UUID != uuid {
+		t.Errorf("expected upload %s, got %v", uuid, got)
+	}
+	if _, err := s.ManifestLastAccess(manifest); err != nil {
+		t.Error(err)
+	}
+	if tags, err := s.TagsList("repo"); err != nil || !slices.Equal(tags, []string{"latest"}) {
+		t.Errorf("expected [latest], got %v (%v)", tags, err)
+	}
+	if _, err := s.TagLastModified("repo", "latest"); err != nil {
+		t.Error(err)
+	}
+	if repos, err := s.RepositoriesList(); err != nil || !slices.Equal(repos, []string{"repo"}) {
+		t.Errorf("expected [repo], got %v (%v)", repos, err)
+	}
+	if err := s.ManifestDelete("repo", "latest"); err != nil {
+		t.Error(err)
+	}
+	if err := s.BlobsDelete("", digest); err != nil {
+		t.Error(err)
+	}
+	if err := s.RepositoryDelete("repo"); err != nil {
+		t.Error(err)
+	}
+}
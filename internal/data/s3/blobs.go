@@ -0,0 +1,216 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	d "github.com/jlsalvador/simple-registry/pkg/digest"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+func (s *S3DataStorage) blobKey(algo, hash string) string {
+	return s.key("blobs", algo, hash[0:2], hash)
+}
+
+func (s *S3DataStorage) layerLinkKey(repo, algo, hash string) string {
+	return s.key("repositories", repo, "_layers", algo, hash, "link")
+}
+
+// blobRepositoryKey returns the key indexing that repo links the blob:
+// _blob_repositories/<algo>/<hex[:2]>/<hex>/<escaped repo>
+func (s *S3DataStorage) blobRepositoryKey(repo, algo, hash string) string {
+	return s.key("_blob_repositories", algo, hash[0:2], hash, url.PathEscape(repo))
+}
+
+func (s *S3DataStorage) BlobsGet(repo, digest string) (r io.ReadCloser, size int64, err error) {
+	algo, hash, err := d.Parse(digest)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	if len(hash) < 2 {
+		return nil, -1, data.ErrHashShort
+	}
+
+	if repo != "" {
+		// Check repository link.
+		linkKey := s.layerLinkKey(repo, algo, hash)
+		link, err := s.readLink(linkKey)
+		if err != nil {
+			return nil, -1, fmt.Errorf("cannot read link %s: %w", linkKey, err)
+		}
+
+		// Verify link content matches requested digest.
+		if link != digest {
+			return nil, -1, fmt.Errorf("repository link mismatch: expected %s, got %s", digest, link)
+		}
+	}
+
+	blobKey := s.blobKey(algo, hash)
+	r, size, err = s.client.GetObject(blobKey)
+	if err != nil {
+		return nil, -1, fmt.Errorf("cannot open blob %s: %w", blobKey, err)
+	}
+
+	return r, size, nil
+}
+
+func (s *S3DataStorage) BlobsDelete(repo, digest string) error {
+	algo, hash, err := d.Parse(digest)
+	if err != nil {
+		return err
+	}
+
+	if len(hash) < 2 {
+		return data.ErrHashShort
+	}
+
+	if repo != "" {
+		// Repo is not empty, so only delete repo blob link.
+		linkKey := s.layerLinkKey(repo, algo, hash)
+		if _, err := s.client.HeadObject(linkKey); err != nil {
+			return err
+		}
+		if err := s.client.DeleteObject(linkKey); err != nil {
+			return err
+		}
+		return s.client.DeleteObject(s.blobRepositoryKey(repo, algo, hash))
+	}
+
+	// Repo is empty, so only delete the blob.
+	blobKey := s.blobKey(algo, hash)
+	if _, err := s.client.HeadObject(blobKey); err != nil {
+		return err
+	}
+	if err := s.client.DeleteObject(blobKey); err != nil {
+		return err
+	}
+	indexPrefix := s.key("_blob_repositories", algo, hash[0:2], hash) + "/"
+	if err := s.deletePrefix(indexPrefix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *S3DataStorage) BlobsList() (digests iter.Seq[string], err error) {
+	prefix := s.key("blobs") + "/"
+
+	objects, _, err := s.client.ListObjects(prefix, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return func(yield func(string) bool) {
+		for _, o := range objects {
+			// "<algo>/<hash prefix>/<hash>".
+			parts := strings.Split(strings.TrimPrefix(o.Key, prefix), "/")
+			if len(parts) != 3 {
+				continue
+			}
+
+			if !yield(parts[0] + ":" + parts[2]) {
+				return
+			}
+		}
+	}, nil
+}
+
+// BlobLastAccess returns the blob last modification time, because object
+// storages do not track access times.
+func (s *S3DataStorage) BlobLastAccess(digest string) (lastAccess time.Time, err error) {
+	algo, hash, err := d.Parse(digest)
+	if err != nil {
+		return time.Now(), err
+	}
+
+	if len(hash) < 2 {
+		return time.Now(), data.ErrDigestMismatch
+	}
+
+	o, err := s.client.HeadObject(s.blobKey(algo, hash))
+	if err != nil {
+		return time.Now(), err
+	}
+
+	return o.LastModified, nil
+}
+
+func (s *S3DataStorage) BlobsLink(repo, digest string) error {
+	if !registry.RegExprName.MatchString(repo) {
+		return data.ErrRepoInvalid
+	}
+
+	algo, hash, err := d.Parse(digest)
+	if err != nil {
+		return err
+	}
+
+	if len(hash) < 2 {
+		return data.ErrHashShort
+	}
+
+	if _, err := s.client.HeadObject(s.blobKey(algo, hash)); err != nil {
+		return err
+	}
+
+	return s.linkBlob(repo, algo, hash, digest)
+}
+
+// BlobRepositories returns the repositories indexed as linking the blob.
+//
+// Index entries whose repository link is gone are skipped.
+func (s *S3DataStorage) BlobRepositories(digest string) (repos []string, err error) {
+	algo, hash, err := d.Parse(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(hash) < 2 {
+		return nil, data.ErrHashShort
+	}
+
+	prefix := s.key("_blob_repositories", algo, hash[0:2], hash) + "/"
+	objects, _, err := s.client.ListObjects(prefix, "/")
+	if err != nil {
+		return nil, err
+	}
+
+	repos = []string{}
+	for _, o := range objects {
+		repo, err := url.PathUnescape(strings.TrimPrefix(o.Key, prefix))
+		if err != nil {
+			continue
+		}
+
+		if _, err := s.client.HeadObject(s.layerLinkKey(repo, algo, hash)); err != nil {
+			continue
+		}
+
+		repos = append(repos, repo)
+	}
+	slices.Sort(repos)
+
+	return repos, nil
+}
@@ -0,0 +1,144 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3_test
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBlobsGet(t *testing.T) {
+	s, _ := newTestStorage(t)
+
+	digest := putBlob(t, s, "repo", []byte("hello"))
+
+	r, size, err := s.BlobsGet("repo", digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	got, _ := io.ReadAll(r)
+	if string(got) != "hello" || size != 5 {
+		t.Errorf("expected %q, got %q (%d bytes)", "hello", got, size)
+	}
+
+	// Blob is not linked into other repositories.
+	if _, _, err := s.BlobsGet("other", digest); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestBlobsDelete(t *testing.T) {
+	s, _ := newTestStorage(t)
+
+	digest := putBlob(t, s, "repo", []byte("hello"))
+
+	if err := s.BlobsDelete("", digest); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.BlobsGet("repo", digest); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+	if err := s.BlobsDelete("", digest); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestBlobsList(t *testing.T) {
+	s, _ := newTestStorage(t)
+
+	want := []string{
+		putBlob(t, s, "repo", []byte("a")),
+		putBlob(t, s, "repo", []byte("b")),
+	}
+
+	seq, err := s.BlobsList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := slices.Collect(seq)
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestBlobLastAccess(t *testing.T) {
+	s, _ := newTestStorage(t)
+
+	before := time.Now().Add(-time.Second)
+	digest := putBlob(t, s, "repo", []byte("hello"))
+
+	lastAccess, err := s.BlobLastAccess(digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastAccess.Before(before) {
+		t.Errorf("expected last access after %v, got %v", before, lastAccess)
+	}
+}
+
+func TestBlobRepositories(t *testing.T) {
+	s, _ := newTestStorage(t)
+
+	digest := putBlob(t, s, "b/img", []byte("hello"))
+	putBlob(t, s, "a", []byte("hello"))
+	putBlob(t, s, "other", []byte("other"))
+
+	repos, err := s.BlobRepositories(digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b/img"}; !slices.Equal(repos, want) {
+		t.Errorf("expected %v, got %v", want, repos)
+	}
+
+	if err := s.BlobsDelete("a", digest); err != nil {
+		t.Fatal(err)
+	}
+	repos, err = s.BlobRepositories(digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"b/img"}; !slices.Equal(repos, want) {
+		t.Errorf("expected %v, got %v", want, repos)
+	}
+}
+
+func TestBlobsLink(t *testing.T) {
+	s, _ := newTestStorage(t)
+
+	digest := putBlob(t, s, "a", []byte("hello"))
+
+	if err := s.BlobsLink("b", digest); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.BlobsGet("b", digest); err != nil {
+		t.Errorf("expected linked blob, got %v", err)
+	}
+	if repos, err := s.BlobRepositories(digest); err != nil || !slices.Equal(repos, []string{"a", "b"}) {
+		t.Errorf("expected [a b], got %v (%v)", repos, err)
+	}
+
+	missing := "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	if err := s.BlobsLink("b", missing); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
@@ -0,0 +1,415 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"iter"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	d "github.com/jlsalvador/simple-registry/pkg/digest"
+	"github.com/jlsalvador/simple-registry/pkg/hasher"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+	pkgS3 "github.com/jlsalvador/simple-registry/pkg/s3"
+	u "github.com/jlsalvador/simple-registry/pkg/uuid"
+)
+
+// partSize is the size of every multipart upload part, except the last one.
+const partSize = pkgS3.MinPartSize
+
+// uploadState is stored as JSON under "_uploads/<uuid>/state".
+//
+// The uploaded data is split into full sized parts of a multipart upload,
+// plus the remaining bytes that are stored as "_uploads/<uuid>/tail" until
+// the next write or the commit.
+//
+// HashState is the hashing state of the uploaded data with
+// [d.Canonical], so the commit does not read the data again. It is empty
+// for uploads created by older versions.
+type uploadState struct {
+	UploadID  string       `json:"uploadId,omitempty"`
+	Parts     []pkgS3.Part `json:"parts,omitempty"`
+	Size      int64        `json:"size"`
+	Completed bool         `json:"completed,omitempty"`
+	HashState []byte       `json:"hashState,omitempty"`
+}
+
+func (s *S3DataStorage) uploadKey(repo, uuid string, elem ...string) string {
+	return s.key(append([]string{"repositories", repo, "_uploads", uuid}, elem...)...)
+}
+
+func (s *S3DataStorage) readUploadState(repo, uuid string) (state uploadState, err error) {
+	b, err := s.readObject(s.uploadKey(repo, uuid, "state"))
+	if err != nil {
+		return state, err
+	}
+	err = json.Unmarshal(b, &state)
+	return state, err
+}
+
+func (s *S3DataStorage) writeUploadState(repo, uuid string, state uploadState) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return s.client.PutObject(s.uploadKey(repo, uuid, "state"), b)
+}
+
+// readUploadTail returns the pending bytes that are not part of the multipart
+// upload yet.
+func (s *S3DataStorage) readUploadTail(repo, uuid string) ([]byte, error) {
+	b, err := s.readObject(s.uploadKey(repo, uuid, "tail"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return b, err
+}
+
+// BlobsUploadCreate creates a new blob upload session for the given
+// repository, and returns the upload uuid.
+func (s *S3DataStorage) BlobsUploadCreate(repo string) (uuid string, err error) {
+	if !registry.RegExprName.MatchString(repo) {
+		return "", data.ErrRepoInvalid
+	}
+
+	uuid = u.MustNew().String()
+
+	// Store metadata like "startedat".
+	startedAt := time.Now().UTC().Format(time.RFC3339Nano)
+	if err := s.client.PutObject(s.uploadKey(repo, uuid, "startedat"), []byte(startedAt)); err != nil {
+		return "", err
+	}
+
+	h, err := d.NewHasher(d.Canonical)
+	if err != nil {
+		return "", err
+	}
+	hashState, err := h.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	if err := s.writeUploadState(repo, uuid, uploadState{HashState: hashState}); err != nil {
+		return "", err
+	}
+
+	return uuid, nil
+}
+
+// BlobsUploadCancel cancels a blob upload in progress.
+func (s *S3DataStorage) BlobsUploadCancel(repo, uuid string) error {
+	if !registry.RegExprName.MatchString(repo) {
+		return data.ErrRepoInvalid
+	}
+	if !registry.RegExprUUID.MatchString(uuid) {
+		return data.ErrUUIDInvalid
+	}
+
+	state, err := s.readUploadState(repo, uuid)
+	if err != nil {
+		return err
+	}
+
+	if state.UploadID != "" && !state.Completed {
+		if err := s.client.AbortMultipartUpload(s.uploadKey(repo, uuid, "data"), state.UploadID); err != nil {
+			return err
+		}
+	}
+
+	return s.deletePrefix(s.uploadKey(repo, uuid) + "/")
+}
+
+// BlobsUploadWrite writes data to an blob upload in progress.
+//
+// If "start" is less than 0, the data will be appended to the end of the
+// upload. Otherwise, "start" must be the current upload size, because
+// multipart uploads could not be overwritten.
+func (s *S3DataStorage) BlobsUploadWrite(repo, uuid string, r io.Reader, start int64) error {
+	if !registry.RegExprName.MatchString(repo) {
+		return data.ErrRepoInvalid
+	}
+	if !registry.RegExprUUID.MatchString(uuid) {
+		return data.ErrUUIDInvalid
+	}
+
+	state, err := s.readUploadState(repo, uuid)
+	if err != nil {
+		return err
+	}
+	if state.Completed || (start >= 0 && start != state.Size) {
+		return ErrUploadOutOfOrder
+	}
+
+	tail, err := s.readUploadTail(repo, uuid)
+	if err != nil {
+		return err
+	}
+
+	// Continue the running hash with the new data. The tail was already
+	// hashed.
+	var h hasher.Hasher
+	if len(state.HashState) > 0 {
+		if h, err = d.NewHasher(d.Canonical); err != nil {
+			return err
+		}
+		if err := h.UnmarshalBinary(state.HashState); err != nil {
+			return err
+		}
+		r = io.TeeReader(r, h)
+	}
+
+	// Upload every full part, and keep the remaining bytes as the new tail.
+	dataKey := s.uploadKey(repo, uuid, "data")
+	reader := io.MultiReader(bytes.NewReader(tail), r)
+	buf := make([]byte, partSize)
+	for {
+		n, err := io.ReadFull(reader, buf)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			tail = buf[:n]
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if state.UploadID == "" {
+			if state.UploadID, err = s.client.CreateMultipartUpload(dataKey); err != nil {
+				return err
+			}
+		}
+
+		partNumber := len(state.Parts) + 1
+		etag, err := s.client.UploadPart(dataKey, state.UploadID, partNumber, buf)
+		if err != nil {
+			return err
+		}
+		state.Parts = append(state.Parts, pkgS3.Part{PartNumber: partNumber, ETag: etag})
+	}
+
+	if err := s.client.PutObject(s.uploadKey(repo, uuid, "tail"), tail); err != nil {
+		return err
+	}
+
+	state.Size = int64(len(state.Parts))*partSize + int64(len(tail))
+	if h != nil {
+		if state.HashState, err = h.MarshalBinary(); err != nil {
+			return err
+		}
+	}
+	return s.writeUploadState(repo, uuid, state)
+}
+
+// completeUpload assembles the uploaded parts and tail as the object
+// "_uploads/<uuid>/data".
+func (s *S3DataStorage) completeUpload(repo, uuid string, state uploadState) error {
+	if state.Completed {
+		return nil
+	}
+
+	tail, err := s.readUploadTail(repo, uuid)
+	if err != nil {
+		return err
+	}
+
+	dataKey := s.uploadKey(repo, uuid, "data")
+	if state.UploadID == "" {
+		// Small blobs are not uploaded by parts.
+		if err := s.client.PutObject(dataKey, tail); err != nil {
+			return err
+		}
+	} else {
+		if len(tail) > 0 {
+			partNumber := len(state.Parts) + 1
+			etag, err := s.client.UploadPart(dataKey, state.UploadID, partNumber, tail)
+			if err != nil {
+				return err
+			}
+			state.Parts = append(state.Parts, pkgS3.Part{PartNumber: partNumber, ETag: etag})
+		}
+
+		if err := s.client.CompleteMultipartUpload(dataKey, state.UploadID, state.Parts); err != nil {
+			return err
+		}
+	}
+
+	state.Completed = true
+	return s.writeUploadState(repo, uuid, state)
+}
+
+// blobsUploadCommit verifies the uploaded data hash, and copies it as the
+// blob.
+func (s *S3DataStorage) blobsUploadCommit(
+	repo,
+	uuid,
+	algo,
+	hash string,
+) error {
+	if !registry.RegExprName.MatchString(repo) {
+		return data.ErrRepoInvalid
+	}
+	if !registry.RegExprUUID.MatchString(uuid) {
+		return data.ErrUUIDInvalid
+	}
+
+	state, err := s.readUploadState(repo, uuid)
+	if err != nil {
+		return err
+	}
+	if err := s.completeUpload(repo, uuid, state); err != nil {
+		return err
+	}
+
+	// Calculate the digest of the uploaded data, from the running hash if
+	// possible.
+	dataKey := s.uploadKey(repo, uuid, "data")
+	hasher, err := d.NewHasher(algo)
+	if err != nil {
+		return err
+	}
+	if algo == d.Canonical && len(state.HashState) > 0 {
+		if err := hasher.UnmarshalBinary(state.HashState); err != nil {
+			return err
+		}
+	} else {
+		r, _, err := s.client.GetObject(dataKey)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(hasher, r); err != nil {
+			r.Close()
+			return err
+		}
+		r.Close()
+	}
+
+	// Check if the uploaded data matches the expected digest.
+	if hasher.GetHashAsString() != hash {
+		return data.ErrDigestMismatch
+	}
+
+	if err := s.client.CopyObject(dataKey, s.blobKey(algo, hash), state.Size); err != nil {
+		return err
+	}
+
+	// Delete temporal upload.
+	return s.deletePrefix(s.uploadKey(repo, uuid) + "/")
+}
+
+// BlobsUploadCommit commits an blob upload in progress as a layer.
+//
+// After check uploaded data hash, the uploaded data will be copied to the
+// final location and the repository layer's link will be created.
+func (s *S3DataStorage) BlobsUploadCommit(repo, uuid, digest string) error {
+	algo, hash, err := d.Parse(digest)
+	if err != nil {
+		return err
+	}
+	if len(hash) < 2 {
+		return data.ErrHashShort
+	}
+
+	if err := s.blobsUploadCommit(repo, uuid, algo, hash); err != nil {
+		return err
+	}
+
+	return s.linkBlob(repo, algo, hash, digest)
+}
+
+// linkBlob writes the repository link of the blob, and indexes it.
+func (s *S3DataStorage) linkBlob(repo, algo, hash, digest string) error {
+	// Write repository link:
+	// repositories/<repo>/_layers/<algo>/<hex>/link
+	if err := s.writeLink(s.layerLinkKey(repo, algo, hash), digest); err != nil {
+		return err
+	}
+
+	// Index the repository along the link, so the repositories linking the
+	// blob are found without scanning every repository.
+	return s.client.PutObject(s.blobRepositoryKey(repo, algo, hash), nil)
+}
+
+func (s *S3DataStorage) BlobsUploadSize(repo, uuid string) (size int64, err error) {
+	if !registry.RegExprName.MatchString(repo) {
+		return -1, data.ErrRepoInvalid
+	}
+	if !registry.RegExprUUID.MatchString(uuid) {
+		return -1, data.ErrUUIDInvalid
+	}
+
+	state, err := s.readUploadState(repo, uuid)
+	if err != nil {
+		return -1, err
+	}
+
+	return state.Size, nil
+}
+
+func (s *S3DataStorage) BlobsUploadStartedAt(repo, uuid string) (startedAt time.Time, err error) {
+	if !registry.RegExprName.MatchString(repo) {
+		return time.Now(), data.ErrRepoInvalid
+	}
+	if !registry.RegExprUUID.MatchString(uuid) {
+		return time.Now(), data.ErrUUIDInvalid
+	}
+
+	b, err := s.readObject(s.uploadKey(repo, uuid, "startedat"))
+	if err != nil {
+		return time.Now(), err
+	}
+
+	return time.Parse(time.RFC3339Nano, string(b))
+}
+
+// BlobsUploadsList returns the upload sessions of every repository, found by
+// their "_uploads/<uuid>/startedat" objects.
+//
+// The creation time of the object is used as the session start, so no object
+// is read.
+func (s *S3DataStorage) BlobsUploadsList() (uploads iter.Seq[data.Upload], err error) {
+	prefix := s.key("repositories") + "/"
+
+	objects, _, err := s.client.ListObjects(prefix, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var list []data.Upload
+	for _, o := range objects {
+		// "<repo>/_uploads/<uuid>/startedat".
+		rel := strings.TrimPrefix(o.Key, prefix)
+		repo, rest, ok := strings.Cut(rel, "/_uploads/")
+		if !ok {
+			continue
+		}
+		uuid, ok := strings.CutSuffix(rest, "/startedat")
+		if !ok || !registry.RegExprUUID.MatchString(uuid) {
+			continue
+		}
+
+		list = append(list, data.Upload{
+			Repo:      repo,
+			UUID:      uuid,
+			StartedAt: o.LastModified,
+		})
+	}
+
+	return slices.Values(list), nil
+}
@@ -0,0 +1,256 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3_test
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/internal/data/s3"
+	pkgS3 "github.com/jlsalvador/simple-registry/pkg/s3"
+)
+
+func TestBlobsUploadCreate(t *testing.T) {
+	s, _ := newTestStorage(t)
+
+	if _, err := s.BlobsUploadCreate("THIS IS INVALID"); !errors.Is(err, data.ErrRepoInvalid) {
+		t.Fatalf("expected ErrRepoInvalid, got %v", err)
+	}
+
+	uuid, err := s.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	size, err := s.BlobsUploadSize("repo", uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 0 {
+		t.Errorf("expected empty upload, got %d bytes", size)
+	}
+}
+
+func TestBlobsUploadCancel(t *testing.T) {
+	s, srv := newTestStorage(t)
+
+	if err := s.BlobsUploadCancel("repo", "not-uuid"); !errors.Is(err, data.ErrUUIDInvalid) {
+		t.Fatalf("expected ErrUUIDInvalid, got %v", err)
+	}
+
+	uuid, err := s.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Write more than one part, so a multipart upload is in progress.
+	chunk := bytes.Repeat([]byte("a"), 6<<20)
+	if err := s.BlobsUploadWrite("repo", uuid, bytes.NewReader(chunk), -1); err != nil {
+		t.Fatal(err)
+	}
+	if srv.Uploads() != 1 {
+		t.Fatalf("expected 1 multipart upload, got %d", srv.Uploads())
+	}
+
+	if err := s.BlobsUploadCancel("repo", uuid); err != nil {
+		t.Fatal(err)
+	}
+	if srv.Uploads() != 0 {
+		t.Errorf("expected multipart upload aborted, got %d", srv.Uploads())
+	}
+	if _, err := s.BlobsUploadSize("repo", uuid); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestBlobsUploadWriteChunks(t *testing.T) {
+	s, srv := newTestStorage(t)
+
+	uuid, err := s.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Chunks smaller and bigger than a part.
+	var blob []byte
+	for i, n := range []int{1 << 20, 7 << 20, 3 << 20} {
+		chunk := bytes.Repeat([]byte{byte('a' + i)}, n)
+		if err := s.BlobsUploadWrite("repo", uuid, bytes.NewReader(chunk), int64(len(blob))); err != nil {
+			t.Fatal(err)
+		}
+		blob = append(blob, chunk...)
+	}
+
+	size, err := s.BlobsUploadSize("repo", uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != int64(len(blob)) {
+		t.Fatalf("expected size %d, got %d", len(blob), size)
+	}
+
+	digest := sha256Digest(blob)
+	if err := s.BlobsUploadCommit("repo", uuid, digest); err != nil {
+		t.Fatal(err)
+	}
+	if srv.Uploads() != 0 {
+		t.Errorf("expected no multipart upload in progress, got %d", srv.Uploads())
+	}
+
+	r, size, err := s.BlobsGet("repo", digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != int64(len(blob)) || !bytes.Equal(got, blob) {
+		t.Error("blob content mismatch")
+	}
+}
+
+func TestBlobsUploadWriteOutOfOrder(t *testing.T) {
+	s, _ := newTestStorage(t)
+
+	uuid, err := s.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BlobsUploadWrite("repo", uuid, bytes.NewReader([]byte("abc")), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.BlobsUploadWrite("repo", uuid, bytes.NewReader([]byte("def")), 1)
+	if !errors.Is(err, s3.ErrUploadOutOfOrder) {
+		t.Errorf("expected ErrUploadOutOfOrder, got %v", err)
+	}
+}
+
+func TestBlobsUploadCommitMismatch(t *testing.T) {
+	s, _ := newTestStorage(t)
+
+	uuid, err := s.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BlobsUploadWrite("repo", uuid, bytes.NewReader([]byte("hello")), -1); err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.BlobsUploadCommit("repo", uuid, sha256Digest([]byte("bye")))
+	if !errors.Is(err, data.ErrDigestMismatch) {
+		t.Fatalf("expected ErrDigestMismatch, got %v", err)
+	}
+
+	if _, _, err := s.BlobsGet("repo", sha256Digest([]byte("bye"))); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestBlobsUploadsList(t *testing.T) {
+	s, _ := newTestStorage(t)
+
+	before := time.Now().Add(-time.Second)
+	uuidA, err := s.BlobsUploadCreate("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uuidB, err := s.BlobsUploadCreate("b/img")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	startedAt, err := s.BlobsUploadStartedAt("a", uuidA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if startedAt.Before(before) {
+		t.Errorf("expected started after %v, got %v", before, startedAt)
+	}
+	if _, err := s.BlobsUploadStartedAt("b/img", uuidA); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+
+	uploads, err := s.BlobsUploadsList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := slices.SortedFunc(uploads, func(a, b data.Upload) int {
+		return strings.Compare(a.Repo, b.Repo)
+	})
+	if len(got) != 2 ||
+		got[0].Repo != "a" || got[0].UUID != uuidA ||
+		got[1].Repo != "b/img" || got[1].UUID != uuidB {
+		t.Fatalf("expected uploads %s and %s, got %v", uuidA, uuidB, got)
+	}
+	if got[0].StartedAt.Before(before) {
+		t.Errorf("expected started after %v, got %v", before, got[0].StartedAt)
+	}
+
+	if err := s.BlobsUploadCancel("a", uuidA); err != nil {
+		t.Fatal(err)
+	}
+	uploads, err = s.BlobsUploadsList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := slices.Collect(uploads); len(got) != 1 || got[0].UUID != uuidB {
+		t.Errorf("expected upload %s, got %v", uuidB, got)
+	}
+}
+
+func TestBlobsUploadCommitRunningHash(t *testing.T) {
+	s, srv := newTestStorage(t)
+
+	uuid, err := s.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BlobsUploadWrite("repo", uuid, bytes.NewReader([]byte("hello ")), -1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Hashing continues in other replicas, or after a restart.
+	s = s3.NewS3DataStorage(pkgS3.New(srv.URL, "", "bucket", "key", "secret"), "registry")
+	if err := s.BlobsUploadWrite("repo", uuid, bytes.NewReader([]byte("world")), -1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BlobsUploadCommit("repo", uuid, sha256Digest([]byte("hello world"))); err != nil {
+		t.Fatal(err)
+	}
+
+	// Other algorithms read the whole upload.
+	uuid, err = s.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BlobsUploadWrite("repo", uuid, bytes.NewReader([]byte("hello world")), -1); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha512.Sum512([]byte("hello world"))
+	if err := s.BlobsUploadCommit("repo", uuid, "sha512:"+hex.EncodeToString(sum[:])); err != nil {
+		t.Fatal(err)
+	}
+}
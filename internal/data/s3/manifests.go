@@ -0,0 +1,238 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	pkgDigest "github.com/jlsalvador/simple-registry/pkg/digest"
+	"github.com/jlsalvador/simple-registry/pkg/mapset"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+const manifestAlgo = "sha256"
+
+func (s *S3DataStorage) manifestsKey(repo string, elem ...string) string {
+	return s.key(append([]string{"repositories", repo, "_manifests"}, elem...)...)
+}
+
+// indexReferrer verifies if the manifest has a subject, if it so, create the
+// referrers.
+func (s *S3DataStorage) indexReferrer(repo, referrerDigest string, manifestBytes []byte) error {
+	var manifest registry.ImageManifest
+
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return nil // Ignore invalid OCI 1.1.
+	}
+
+	if manifest.Subject == nil {
+		return nil
+	}
+
+	subjectDigest := manifest.Subject.Digest
+
+	algo, hash, err := pkgDigest.Parse(subjectDigest)
+	if err != nil {
+		return err
+	}
+
+	linkKey := s.manifestsKey(repo, "referrers", algo, hash, referrerDigest, "link")
+	if err := s.writeLink(linkKey, subjectDigest); err != nil {
+		return fmt.Errorf("cannot write referrer %s: %w", linkKey, err)
+	}
+
+	return nil
+}
+
+// ManifestPut stores a manifest identified by "reference" (either a tag or a
+// digest) into the repository.
+func (s *S3DataStorage) ManifestPut(repo, reference string, r io.Reader) (dgst string, err error) {
+	if !registry.RegExprName.MatchString(repo) {
+		return "", data.ErrRepoInvalid
+	}
+
+	hasher, err := pkgDigest.NewHasher(manifestAlgo)
+	if err != nil {
+		return "", err
+	}
+
+	data := bytes.NewBuffer([]byte{})
+	m := io.MultiWriter(hasher, data)
+	if _, err := io.Copy(m, r); err != nil {
+		return "", err
+	}
+
+	hash := hasher.GetHashAsString()
+	dgst = manifestAlgo + ":" + hash
+
+	// Store manifest blob.
+	uuid, err := s.BlobsUploadCreate(repo)
+	if err != nil {
+		return "", err
+	}
+	if err = s.BlobsUploadWrite(repo, uuid, bytes.NewReader(data.Bytes()), -1); err != nil {
+		return "", err
+	}
+	if err := s.BlobsUploadCommit(repo, uuid, dgst); err != nil {
+		return "", err
+	}
+
+	// Create revision link.
+	revisionLink := s.manifestsKey(repo, "revisions", manifestAlgo, hash, "link")
+	if err := s.writeLink(revisionLink, dgst); err != nil {
+		return "", err
+	}
+
+	// If reference is a tag, update tag link.
+	if registry.RegExprTag.MatchString(reference) {
+		tagLink := s.manifestsKey(repo, "tags", reference, "current", "link")
+		if err := s.writeLink(tagLink, dgst); err != nil {
+			return "", err
+		}
+	}
+
+	// Index the manifest referrer.
+	s.indexReferrer(repo, dgst, data.Bytes())
+
+	return dgst, nil
+}
+
+// ManifestGet retrieves a manifest using either a tag or a digest.
+func (s *S3DataStorage) ManifestGet(repo, reference string) (
+	r io.ReadCloser,
+	size int64,
+	digest string,
+	err error,
+) {
+	if !registry.RegExprName.MatchString(repo) {
+		return nil, -1, "", data.ErrRepoInvalid
+	}
+
+	algo, hash, err := pkgDigest.Parse(reference)
+	if err == nil {
+		// If reference is a digest, use it directly.
+	} else if registry.RegExprTag.MatchString(reference) {
+		// If reference is a tag, resolve tag to digest.
+		link, err := s.readLink(s.manifestsKey(repo, "tags", reference, "current", "link"))
+		if err != nil {
+			return nil, -1, "", err
+		}
+		algo, hash, err = pkgDigest.Parse(link)
+		if err != nil {
+			return nil, -1, "", err
+		}
+	}
+	if len(hash) < 2 {
+		return nil, -1, "", data.ErrHashShort
+	}
+
+	// Open the actual blob manifest.
+	blobKey := s.blobKey(algo, hash)
+	r, size, err = s.client.GetObject(blobKey)
+	if err != nil {
+		return nil, -1, "", fmt.Errorf("cannot open blob %s: %w", blobKey, err)
+	}
+
+	digest = algo + ":" + hash
+
+	return r, size, digest, nil
+}
+
+func (s *S3DataStorage) ManifestDelete(repo, reference string) error {
+	if !registry.RegExprName.MatchString(repo) {
+		return data.ErrRepoInvalid
+	}
+
+	// Case 1: reference is a tag.
+	if registry.RegExprTag.MatchString(reference) {
+		return s.deletePrefix(s.manifestsKey(repo, "tags", reference) + "/")
+	}
+
+	// Case 2: reference must be a digest.
+
+	// Delete the referrers.
+	r, _, _, err := s.ManifestGet(repo, reference)
+	if err == nil {
+		if data, err := io.ReadAll(r); err == nil {
+			var m registry.ImageManifest
+			if json.Unmarshal(data, &m) == nil && m.Subject != nil {
+				// It is a referrer, so remove the referrer link.
+				subjAlgo, subjHash, _ := pkgDigest.Parse(m.Subject.Digest)
+				refKey := s.manifestsKey(repo, "referrers", subjAlgo, subjHash, reference)
+				s.deletePrefix(refKey + "/")
+			}
+		}
+		r.Close()
+	}
+
+	// Delete the revision.
+	algo, hash, err := pkgDigest.Parse(reference)
+	if err != nil {
+		return errors.Join(data.ErrDigestInvalid, err)
+	}
+
+	return s.deletePrefix(s.manifestsKey(repo, "revisions", algo, hash) + "/")
+}
+
+func (s *S3DataStorage) ManifestsList(repo string) (digests iter.Seq[string], err error) {
+	digestsSet := mapset.NewMapSet[string]()
+
+	// 1. Revisions: "revisions/<algo>/<hash>/link".
+	prefix := s.manifestsKey(repo, "revisions") + "/"
+	objects, _, err := s.client.ListObjects(prefix, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range objects {
+		parts := strings.Split(strings.TrimPrefix(o.Key, prefix), "/")
+		if len(parts) == 3 {
+			digestsSet.Add(parts[0] + ":" + parts[1])
+		}
+	}
+
+	// 2. Referrers: "referrers/<algo>/<hash>/<referrer digest>/link".
+	prefix = s.manifestsKey(repo, "referrers") + "/"
+	objects, _, err = s.client.ListObjects(prefix, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range objects {
+		parts := strings.Split(strings.TrimPrefix(o.Key, prefix), "/")
+		if len(parts) == 4 {
+			digestsSet.Add(parts[2]) // Key has the algo prefix.
+		}
+	}
+
+	return func(yield func(string) bool) {
+		for digest := range digestsSet {
+			if !yield(digest) {
+				return
+			}
+		}
+	}, nil
+}
+
+func (s *S3DataStorage) ManifestLastAccess(digest string) (lastAccess time.Time, err error) {
+	// Our manifests are stored as blobs, so return its blob last access time.
+	return s.BlobLastAccess(digest)
+}
@@ -0,0 +1,125 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"testing"
+
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+func createTestManifest(subject *registry.DescriptorManifest) []byte {
+	manifest := registry.ImageManifest{
+		SchemaVersion: 2,
+		MediaType:     "application/vnd.oci.image.manifest.v1+json",
+		Config: registry.DescriptorManifest{
+			MediaType: "application/vnd.oci.image.config.v1+json",
+			Digest:    "sha256:abcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890",
+			Size:      1234,
+		},
+		Subject: subject,
+	}
+
+	data, _ := json.Marshal(manifest)
+	return data
+}
+
+func TestManifestPutGet(t *testing.T) {
+	s, _ := newTestStorage(t)
+
+	manifest := createTestManifest(nil)
+	digest, err := s.ManifestPut("repo", "latest", bytes.NewReader(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != sha256Digest(manifest) {
+		t.Fatalf("expected digest %s, got %s", sha256Digest(manifest), digest)
+	}
+
+	for _, ref := range []string{"latest", digest} {
+		r, size, gotDigest, err := s.ManifestGet("repo", ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := io.ReadAll(r)
+		r.Close()
+		if !bytes.Equal(got, manifest) || size != int64(len(manifest)) || gotDigest != digest {
+			t.Errorf("unexpected manifest for reference %q", ref)
+		}
+	}
+
+	if _, _, _, err := s.ManifestGet("repo", "unknown"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestManifestDelete(t *testing.T) {
+	s, _ := newTestStorage(t)
+
+	digest, err := s.ManifestPut("repo", "latest", bytes.NewReader(createTestManifest(nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Delete the tag only.
+	if err := s.ManifestDelete("repo", "latest"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := s.ManifestGet("repo", "latest"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+	seq, err := s.ManifestsList("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := slices.Collect(seq); !slices.Equal(got, []string{digest}) {
+		t.Errorf("expected %v, got %v", []string{digest}, got)
+	}
+
+	// Delete the revision.
+	if err := s.ManifestDelete("repo", digest); err != nil {
+		t.Fatal(err)
+	}
+	seq, err = s.ManifestsList("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := slices.Collect(seq); len(got) != 0 {
+		t.Errorf("expected no manifests, got %v", got)
+	}
+
+	if err := s.ManifestDelete("repo", digest); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestManifestLastAccess(t *testing.T) {
+	s, _ := newTestStorage(t)
+
+	digest, err := s.ManifestPut("repo", "latest", bytes.NewReader(createTestManifest(nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.ManifestLastAccess(digest); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"iter"
+	"path"
+
+	pkgDigest "github.com/jlsalvador/simple-registry/pkg/digest"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+func (s *S3DataStorage) ReferrersGet(
+	repo,
+	manifestDigest string,
+) (digests iter.Seq[string], err error) {
+	algo, hash, err := pkgDigest.Parse(manifestDigest)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := s.manifestsKey(repo, "referrers", algo, hash) + "/"
+
+	_, prefixes, err := s.client.ListObjects(prefix, "/")
+	if err != nil {
+		return nil, err
+	}
+	if len(prefixes) == 0 {
+		return nil, errNotExist(prefix)
+	}
+
+	return func(yield func(string) bool) {
+		for _, p := range prefixes {
+			name := path.Base(p)
+
+			if !registry.RegExprDigest.MatchString(name) {
+				continue
+			}
+
+			if !yield(name) {
+				return
+			}
+		}
+	}, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3_test
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+func TestReferrersGet(t *testing.T) {
+	s, _ := newTestStorage(t)
+
+	subject, err := s.ManifestPut("repo", "latest", bytes.NewReader(createTestManifest(nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.ReferrersGet("repo", subject); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+
+	referrer, err := s.ManifestPut("repo", "sig", bytes.NewReader(createTestManifest(&registry.DescriptorManifest{
+		MediaType: "application/vnd.oci.image.manifest.v1+json",
+		Digest:    subject,
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seq, err := s.ReferrersGet("repo", subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := slices.Collect(seq); !slices.Equal(got, []string{referrer}) {
+		t.Errorf("expected %v, got %v", []string{referrer}, got)
+	}
+
+	// Referrers are listed as manifests too.
+	seq, err = s.ManifestsList("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := slices.Collect(seq); !slices.Contains(got, referrer) {
+		t.Errorf("expected %v in %v", referrer, got)
+	}
+
+	// Deleting the referrer removes the index.
+	if err := s.ManifestDelete("repo", referrer); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ReferrersGet("repo", subject); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestReferrersGetInvalidDigest(t *testing.T) {
+	s, _ := newTestStorage(t)
+
+	if _, err := s.ReferrersGet("repo", "invalid"); err == nil {
+		t.Error("expected error for invalid digest")
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+	"strings"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/pkg/mapset"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+func (s *S3DataStorage) RepositoriesList() ([]string, error) {
+	prefix := s.key("repositories") + "/"
+
+	objects, _, err := s.client.ListObjects(prefix, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// Any key with a "_manifests" element belongs to a repository.
+	repos := mapset.NewMapSet[string]()
+	for _, o := range objects {
+		rel := strings.TrimPrefix(o.Key, prefix)
+		if i := strings.Index(rel, "/_manifests/"); i > 0 {
+			repos.Add(rel[:i])
+		}
+	}
+
+	var out []string
+	for repo := range repos {
+		out = append(out, repo)
+	}
+	slices.Sort(out)
+
+	return out, nil
+}
+
+func (s *S3DataStorage) RepositoryDelete(repo string) error {
+	if !registry.RegExprName.MatchString(repo) {
+		return data.ErrRepoInvalid
+	}
+
+	prefix := s.key("repositories", repo) + "/"
+
+	objects, _, err := s.client.ListObjects(prefix, "")
+	if err != nil {
+		return err
+	}
+
+	var keys []string
+	uuids := mapset.NewMapSet[string]()
+	for _, o := range objects {
+		rel := strings.TrimPrefix(o.Key, prefix)
+		elem, rest, _ := strings.Cut(rel, "/")
+
+		switch elem {
+		case "_manifests":
+			// Tags, revisions and referrers.
+
+		case "_layers":
+			// "<algo>/<hash>/link".
+			parts := strings.Split(rest, "/")
+			if len(parts) == 3 && parts[2] == "link" && len(parts[1]) >= 2 {
+				keys = append(keys, s.blobRepositoryKey(repo, parts[0], parts[1]))
+			}
+
+		case "_uploads":
+			if uuid, _, ok := strings.Cut(rest, "/"); ok {
+				uuids.Add(uuid)
+			}
+
+		default:
+			// Keys of nested repositories.
+			continue
+		}
+
+		keys = append(keys, o.Key)
+	}
+	if len(keys) == 0 {
+		return errNotExist(prefix)
+	}
+
+	// Abort the multipart uploads in progress.
+	for uuid := range uuids {
+		err := s.BlobsUploadCancel(repo, uuid)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, data.ErrUUIDInvalid) {
+			return err
+		}
+	}
+
+	for _, key := range keys {
+		if err := s.client.DeleteObject(key); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3_test
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+)
+
+func TestRepositoriesList(t *testing.T) {
+	s, _ := newTestStorage(t)
+
+	repos, err := s.RepositoriesList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 0 {
+		t.Fatalf("expected no repositories, got %v", repos)
+	}
+
+	for _, repo := range []string{"library/nginx", "alpine", "library/nginx"} {
+		if _, err := s.ManifestPut(repo, "latest", bytes.NewReader(createTestManifest(nil))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Blob only repositories are not listed.
+	putBlob(t, s, "blobs-only", []byte("hello"))
+
+	repos, err = s.RepositoriesList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"alpine", "library/nginx"}; !slices.Equal(repos, want) {
+		t.Errorf("expected %v, got %v", want, repos)
+	}
+}
+
+func TestRepositoryDelete(t *testing.T) {
+	s, srv := newTestStorage(t)
+
+	digest := putBlob(t, s, "org/app", []byte("hello"))
+	manifest := createTestManifest(nil)
+	for _, repo := range []string{"org/app", "org/app/nested"} {
+		if _, err := s.ManifestPut(repo, "latest", bytes.NewReader(manifest)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Write more than one part, so a multipart upload is in progress.
+	uuid, err := s.BlobsUploadCreate("org/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BlobsUploadWrite("org/app", uuid, bytes.NewReader(bytes.Repeat([]byte("a"), 6<<20)), -1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.RepositoryDelete("org/app"); err != nil {
+		t.Fatal(err)
+	}
+
+	repos, err := s.RepositoriesList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"org/app/nested"}; !slices.Equal(repos, want) {
+		t.Errorf("expected %v, got %v", want, repos)
+	}
+	if _, _, _, err := s.ManifestGet("org/app", "latest"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+	if repos, err := s.BlobRepositories(digest); err != nil || len(repos) != 0 {
+		t.Errorf("expected no repositories, got %v (%v)", repos, err)
+	}
+	if srv.Uploads() != 0 {
+		t.Errorf("expected multipart upload aborted, got %d", srv.Uploads())
+	}
+
+	// The blob is kept for the garbage collector.
+	if _, _, err := s.BlobsGet("", digest); err != nil {
+		t.Errorf("expected blob kept, got %v", err)
+	}
+
+	if err := s.RepositoryDelete("org/app"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package s3 is a DataStorage implementation using a S3-compatible object
+// storage.
+//
+// Objects keys follow the same layout as the filesystem DataStorage, so many
+// registry replicas could share the same bucket.
+package s3
+
+import (
+	"bytes"
+	"io"
+	"path"
+	"strings"
+
+	pkgS3 "github.com/jlsalvador/simple-registry/pkg/s3"
+)
+
+type S3DataStorage struct {
+	client *pkgS3.Client
+	prefix string
+}
+
+// NewS3DataStorage returns a DataStorage that stores every object in the
+// client bucket, under the given key prefix.
+func NewS3DataStorage(client *pkgS3.Client, prefix string) *S3DataStorage {
+	return &S3DataStorage{
+		client: client,
+		prefix: strings.Trim(prefix, "/"),
+	}
+}
+
+// key returns the object key for the given path elements.
+func (s *S3DataStorage) key(elem ...string) string {
+	return path.Join(append([]string{s.prefix}, elem...)...)
+}
+
+// readObject returns the whole content of the object key.
+func (s *S3DataStorage) readObject(key string) ([]byte, error) {
+	r, _, err := s.client.GetObject(key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// deletePrefix removes every object whose key starts with prefix.
+//
+// It returns [fs.ErrNotExist] if there is no object to remove.
+func (s *S3DataStorage) deletePrefix(prefix string) error {
+	objects, _, err := s.client.ListObjects(prefix, "")
+	if err != nil {
+		return err
+	}
+	if len(objects) == 0 {
+		return errNotExist(prefix)
+	}
+
+	for _, o := range objects {
+		if err := s.client.DeleteObject(o.Key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hasPrefix returns if there is at least one object whose key starts with
+// prefix.
+func (s *S3DataStorage) hasPrefix(prefix string) (bool, error) {
+	objects, prefixes, err := s.client.ListObjects(prefix, "/")
+	if err != nil {
+		return false, err
+	}
+	return len(objects) > 0 || len(prefixes) > 0, nil
+}
+
+// writeLink stores the digest as the link object key.
+func (s *S3DataStorage) writeLink(key, digest string) error {
+	return s.client.PutObject(key, []byte(digest))
+}
+
+// readLink returns the digest stored by the link object key.
+func (s *S3DataStorage) readLink(key string) (string, error) {
+	b, err := s.readObject(key)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSpace(b)), nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/jlsalvador/simple-registry/internal/data/s3"
+	pkgS3 "github.com/jlsalvador/simple-registry/pkg/s3"
+	"github.com/jlsalvador/simple-registry/pkg/s3/s3test"
+)
+
+func newTestStorage(t *testing.T) (*s3.S3DataStorage, *s3test.Server) {
+	t.Helper()
+
+	srv := s3test.NewServer()
+	t.Cleanup(srv.Close)
+
+	client := pkgS3.New(srv.URL, "", "bucket", "key", "secret")
+	return s3.NewS3DataStorage(client, "registry"), srv
+}
+
+func sha256Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// putBlob uploads data as a blob into repo and returns its digest.
+func putBlob(t *testing.T, s *s3.S3DataStorage, repo string, data []byte) string {
+	t.Helper()
+
+	uuid, err := s.BlobsUploadCreate(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BlobsUploadWrite(repo, uuid, bytes.NewReader(data), -1); err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256Digest(data)
+	if err := s.BlobsUploadCommit(repo, uuid, digest); err != nil {
+		t.Fatal(err)
+	}
+	return digest
+}
+
+func TestKeysPrefix(t *testing.T) {
+	s, srv := newTestStorage(t)
+
+	putBlob(t, s, "repo", []byte("hello"))
+
+	keys := srv.Keys("bucket")
+	if len(keys) == 0 {
+		t.Fatal("expected stored objects")
+	}
+	for _, k := range keys {
+		if !bytes.HasPrefix([]byte(k), []byte("registry/")) {
+			t.Errorf("expected key %q to have the storage prefix", k)
+		}
+	}
+}
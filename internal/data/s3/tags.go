@@ -0,0 +1,69 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+func (s *S3DataStorage) TagsList(repo string) ([]string, error) {
+	prefix := s.manifestsKey(repo, "tags") + "/"
+
+	_, prefixes, err := s.client.ListObjects(prefix, "/")
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	for _, p := range prefixes {
+		tags = append(tags, strings.TrimSuffix(strings.TrimPrefix(p, prefix), "/"))
+	}
+
+	if len(tags) == 0 {
+		// Object storages have no directories, so an unknown repository
+		// must be reported explicitly.
+		exists, err := s.hasPrefix(s.manifestsKey(repo) + "/")
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, errNotExist(path.Dir(prefix))
+		}
+		return nil, nil
+	}
+
+	return tags, nil
+}
+
+func (s *S3DataStorage) TagLastModified(repo, tag string) (lastModified time.Time, err error) {
+	if !registry.RegExprName.MatchString(repo) {
+		return time.Now(), data.ErrRepoInvalid
+	}
+	if !registry.RegExprTag.MatchString(tag) {
+		return time.Now(), data.ErrTagInvalid
+	}
+
+	o, err := s.client.HeadObject(s.manifestsKey(repo, "tags", tag, "current", "link"))
+	if err != nil {
+		return time.Now(), err
+	}
+
+	return o.LastModified, nil
+}
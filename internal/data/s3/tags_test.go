@@ -0,0 +1,87 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3_test
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTagsList(t *testing.T) {
+	s, _ := newTestStorage(t)
+
+	if _, err := s.TagsList("unknown"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+
+	for _, tag := range []string{"v1", "latest", "v2"} {
+		if _, err := s.ManifestPut("repo", tag, bytes.NewReader(createTestManifest(nil))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tags, err := s.TagsList("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(tags)
+	if want := []string{"latest", "v1", "v2"}; !slices.Equal(tags, want) {
+		t.Errorf("expected %v, got %v", want, tags)
+	}
+
+	// Repository without tags.
+	for _, tag := range tags {
+		if err := s.ManifestDelete("repo", tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tags, err = s.TagsList("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 0 {
+		t.Errorf("expected no tags, got %v", tags)
+	}
+}
+
+func TestTagLastModified(t *testing.T) {
+	s, _ := newTestStorage(t)
+
+	if _, err := s.TagLastModified("repo", "latest"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+
+	// Object storages only store seconds.
+	before := time.Now().Add(-time.Second)
+	if _, err := s.ManifestPut("repo", "latest", bytes.NewReader(createTestManifest(nil))); err != nil {
+		t.Fatal(err)
+	}
+
+	lastModified, err := s.TagLastModified("repo", "latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastModified.Before(before) || lastModified.After(time.Now()) {
+		t.Errorf("expected last modified after %v, got %v", before, lastModified)
+	}
+
+	if _, err := s.TagLastModified("repo", "unknown"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
@@ -0,0 +1,325 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/version"
+	"github.com/jlsalvador/simple-registry/pkg/log"
+	"github.com/jlsalvador/simple-registry/pkg/mapset"
+	"github.com/jlsalvador/simple-registry/pkg/uuid"
+)
+
+// maxRuns is the number of runs kept by a [Collector].
+const maxRuns = 10
+
+var (
+	ErrRunning           = errors.New("garbage collection already running")
+	ErrRunNotFound       = errors.New("garbage collection run not found")
+	ErrRunNotConfirmable = errors.New("garbage collection run is not a succeeded dry run")
+)
+
+type RunStatus string
+
+const (
+	RunStatusRunning   RunStatus = "running"
+	RunStatusSucceeded RunStatus = "succeeded"
+	RunStatusFailed    RunStatus = "failed"
+)
+
+// RunResult is a [Result] with sorted slices instead of sets.
+type RunResult struct {
+	DeletedBlobs     []string      `json:"deletedBlobs"`
+	DeletedManifests []ManifestRef `json:"deletedManifests"`
+	ExpiredTags      []TagRef      `json:"expiredTags"`
+	MarkedBlobs      []string      `json:"markedBlobs"`
+	MarkedManifests  []string      `json:"markedManifests"`
+}
+
+// Run is a garbage collection started by a [Collector].
+type Run struct {
+	ID             string        `json:"id"`
+	Status         RunStatus     `json:"status"`
+	Phase          Phase         `json:"phase,omitempty"`
+	DryRun         bool          `json:"dryRun"`
+	DeleteUntagged bool          `json:"deleteUntagged"`
+	LastAccess     time.Duration `json:"lastAccess"`         // Nanoseconds.
+	Confirms       string        `json:"confirms,omitempty"` // ID of the confirmed dry run.
+	StartedAt      time.Time     `json:"startedAt"`
+	FinishedAt     *time.Time    `json:"finishedAt,omitempty"`
+	Error          string        `json:"error,omitempty"`
+	Result         *RunResult    `json:"result,omitempty"`
+}
+
+// Collector runs garbage collections in background, one at a time, and keeps
+// the latest runs.
+type Collector struct {
+	cfg config.Config
+
+	mu      sync.Mutex
+	running bool
+	runs    []*Run // Oldest first.
+}
+
+func NewCollector(cfg config.Config) *Collector {
+	return &Collector{cfg: cfg}
+}
+
+func sortedSet[T comparable](set mapset.MapSet[T], cmp func(a, b T) int) []T {
+	s := make([]T, 0, len(set))
+	for e := range set {
+		s = append(s, e)
+	}
+	slices.SortFunc(s, cmp)
+	return s
+}
+
+func newRunResult(res Result) *RunResult {
+	return &RunResult{
+		DeletedBlobs: sortedSet(res.DeletedBlobs, strings.Compare),
+		DeletedManifests: sortedSet(res.DeletedManifests, func(a, b ManifestRef) int {
+			return strings.Compare(a.Repo+"@"+a.Digest, b.Repo+"@"+b.Digest)
+		}),
+		ExpiredTags: sortedSet(res.ExpiredTags, func(a, b TagRef) int {
+			return strings.Compare(a.Repo+":"+a.Tag, b.Repo+":"+b.Tag)
+		}),
+		MarkedBlobs:     sortedSet(res.MarkedBlobs, strings.Compare),
+		MarkedManifests: sortedSet(res.MarkedManifests, strings.Compare),
+	}
+}
+
+// Start starts a garbage collection in background.
+//
+// It returns [ErrRunning] if there is a garbage collection running.
+func (c *Collector) Start(dryRun bool, lastAccess time.Duration, deleteUntagged bool) (Run, error) {
+	return c.start(&Run{
+		DryRun:         dryRun,
+		DeleteUntagged: deleteUntagged,
+		LastAccess:     lastAccess,
+	}, Options{
+		DryRun:         dryRun,
+		LastAccess:     lastAccess,
+		DeleteUntagged: deleteUntagged,
+	})
+}
+
+// Confirm starts a garbage collection with the same settings as the dry run
+// id, deleting only what the dry run reported as eligible for deletion.
+//
+// It returns [ErrRunNotFound] if there is no such run,
+// [ErrRunNotConfirmable] if it is not a succeeded dry run, or [ErrRunning].
+func (c *Collector) Confirm(id string) (Run, error) {
+	dry, ok := c.Get(id)
+	if !ok {
+		return Run{}, ErrRunNotFound
+	}
+	if !dry.DryRun || dry.Status != RunStatusSucceeded {
+		return Run{}, ErrRunNotConfirmable
+	}
+
+	return c.start(&Run{
+		DeleteUntagged: dry.DeleteUntagged,
+		LastAccess:     dry.LastAccess,
+		Confirms:       dry.ID,
+	}, Options{
+		LastAccess:     dry.LastAccess,
+		DeleteUntagged: dry.DeleteUntagged,
+		OnlyBlobs:      mapset.NewMapSet[string]().Add(dry.Result.DeletedBlobs...),
+		OnlyManifests:  mapset.NewMapSet[ManifestRef]().Add(dry.Result.DeletedManifests...),
+		OnlyTags:       mapset.NewMapSet[TagRef]().Add(dry.Result.ExpiredTags...),
+	})
+}
+
+// Collect runs a garbage collection and waits for it, keeping it in the latest
+// runs like the ones started in background.
+//
+// If not nil, prepare runs first, once no other garbage collection could
+// start, so it could create the garbage to collect and restrict opts to it.
+// The run is not kept if prepare fails.
+//
+// It returns [ErrRunning] if there is a garbage collection running.
+func (c *Collector) Collect(opts Options, prepare func(opts *Options) error) (Run, error) {
+	c.mu.Lock()
+	if c.running {
+		c.mu.Unlock()
+		return Run{}, ErrRunning
+	}
+	c.running = true
+	c.mu.Unlock()
+
+	if prepare != nil {
+		if err := prepare(&opts); err != nil {
+			c.mu.Lock()
+			c.running = false
+			c.mu.Unlock()
+			return Run{}, err
+		}
+	}
+
+	run := &Run{
+		DryRun:         opts.DryRun,
+		DeleteUntagged: opts.DeleteUntagged,
+		LastAccess:     opts.LastAccess,
+	}
+	c.mu.Lock()
+	c.record(run, &opts)
+	c.mu.Unlock()
+
+	err := c.run(run, opts)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return *run, err
+}
+
+func (c *Collector) start(run *Run, opts Options) (Run, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return Run{}, ErrRunning
+	}
+	c.running = true
+
+	c.record(run, &opts)
+
+	go c.run(run, opts)
+
+	return *run, nil
+}
+
+// record adds the run to the latest runs, tracking its phase through opts.
+//
+// c.mu must be held.
+func (c *Collector) record(run *Run, opts *Options) {
+	run.ID = uuid.MustNew().String()
+	run.Status = RunStatusRunning
+	run.StartedAt = time.Now().UTC()
+
+	c.runs = append(c.runs, run)
+	if len(c.runs) > maxRuns {
+		c.runs = c.runs[len(c.runs)-maxRuns:]
+	}
+
+	opts.OnPhase = func(phase Phase) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		run.Phase = phase
+	}
+}
+
+func (c *Collector) run(run *Run, opts Options) error {
+	res, err := Collect(c.cfg, opts)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	finishedAt := time.Now().UTC()
+	run.FinishedAt = &finishedAt
+	run.Phase = ""
+	c.running = false
+
+	if err != nil {
+		run.Status = RunStatusFailed
+		run.Error = err.Error()
+
+		log.Error(
+			"service.name", version.AppName,
+			"service.version", version.AppVersion,
+			"event.dataset", "cmd.garbage_collect",
+			"error.message", err.Error(),
+			"message", fmt.Sprintf("garbage collection %s failed", run.ID),
+		).Print()
+		return err
+	}
+
+	run.Status = RunStatusSucceeded
+	run.Result = newRunResult(res)
+
+	LogResult(opts.DryRun, res)
+	return nil
+}
+
+// Get returns the run id.
+func (c *Collector) Get(id string) (Run, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, run := range c.runs {
+		if run.ID == id {
+			return *run, true
+		}
+	}
+	return Run{}, false
+}
+
+// List returns the latest runs, newest first.
+func (c *Collector) List() []Run {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	runs := make([]Run, 0, len(c.runs))
+	for _, run := range slices.Backward(c.runs) {
+		runs = append(runs, *run)
+	}
+	return runs
+}
+
+// Schedule starts a garbage collection every
+// [config.GarbageCollect.Interval] with the [config.Config.GarbageCollect]
+// settings, until ctx is done.
+//
+// It returns immediately if the interval is not positive.
+func (c *Collector) Schedule(ctx context.Context) {
+	gc := c.cfg.GarbageCollect
+	if gc.Interval <= 0 {
+		return
+	}
+
+	log.Info(
+		"service.name", version.AppName,
+		"service.version", version.AppVersion,
+		"event.dataset", "cmd.garbage_collect",
+		"message", fmt.Sprintf("garbage collection scheduled every %s", gc.Interval),
+	).Print()
+
+	ticker := time.NewTicker(gc.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := c.Start(gc.DryRun, gc.LastAccess, gc.DeleteUntagged); err != nil {
+				log.Warn(
+					"service.name", version.AppName,
+					"service.version", version.AppVersion,
+					"event.dataset", "cmd.garbage_collect",
+					"error.message", err.Error(),
+					"message", "scheduled garbage collection skipped",
+				).Print()
+			}
+		}
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright 2026 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gc_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/data/guard"
+	"github.com/jlsalvador/simple-registry/internal/gc"
+	"github.com/jlsalvador/simple-registry/pkg/mapset"
+)
+
+func newMemoryConfig(t *testing.T, opts ...config.Option) *config.Config {
+	t.Helper()
+
+	cfg, err := config.New(append([]config.Option{
+		config.WithAdminName("test"),
+		config.WithAdminPwd([]byte("test")),
+		config.WithDataDir("mem://"),
+	}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+// putGarbageBlob stores an unreferenced blob.
+func putGarbageBlob(t *testing.T, cfg *config.Config, blob []byte) string {
+	t.Helper()
+
+	digest := getDigest(t, blob)
+	uuid, err := cfg.Data.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Data.BlobsUploadWrite("repo", uuid, bytes.NewReader(blob), -1); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Data.BlobsUploadCommit("repo", uuid, digest); err != nil {
+		t.Fatal(err)
+	}
+	return digest
+}
+
+func waitRun(t *testing.T, c *gc.Collector, id string) gc.Run {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		run, ok := c.Get(id)
+		if !ok {
+			t.Fatalf("run %s not found", id)
+		}
+		if run.Status != gc.RunStatusRunning {
+			return run
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("run %s still running", id)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCollectorDryRunAndConfirm(t *testing.T) {
+	cfg := newMemoryConfig(t)
+	c := gc.NewCollector(*cfg)
+
+	reviewed := putGarbageBlob(t, cfg, []byte("reviewed"))
+
+	dry, err := c.Start(true, time.Nanosecond, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dry = waitRun(t, c, dry.ID)
+	if dry.Status != gc.RunStatusSucceeded {
+		t.Fatalf("expected succeeded, got %s (%s)", dry.Status, dry.Error)
+	}
+	if !slices.Equal(dry.Result.DeletedBlobs, []string{reviewed}) {
+		t.Fatalf("expected %v eligible for deletion, got %v", []string{reviewed}, dry.Result.DeletedBlobs)
+	}
+	if _, err := cfg.Data.BlobLastAccess(reviewed); err != nil {
+		t.Fatalf("expected blob kept by dry run, got %v", err)
+	}
+
+	// Garbage created after the review is not deleted by the confirmation.
+	notReviewed := putGarbageBlob(t, cfg, []byte("not reviewed"))
+
+	run, err := c.Confirm(dry.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run = waitRun(t, c, run.ID)
+	if run.Status != gc.RunStatusSucceeded || run.DryRun || run.Confirms != dry.ID {
+		t.Fatalf("unexpected run %+v", run)
+	}
+	if !slices.Equal(run.Result.DeletedBlobs, []string{reviewed}) {
+		t.Errorf("expected %v deleted, got %v", []string{reviewed}, run.Result.DeletedBlobs)
+	}
+	if _, err := cfg.Data.BlobLastAccess(reviewed); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected blob deleted, got %v", err)
+	}
+	if _, err := cfg.Data.BlobLastAccess(notReviewed); err != nil {
+		t.Errorf("expected blob kept, got %v", err)
+	}
+
+	// Only dry runs could be confirmed.
+	if _, err := c.Confirm(run.ID); !errors.Is(err, gc.ErrRunNotConfirmable) {
+		t.Errorf("expected ErrRunNotConfirmable, got %v", err)
+	}
+	if _, err := c.Confirm("unknown"); !errors.Is(err, gc.ErrRunNotFound) {
+		t.Errorf("expected ErrRunNotFound, got %v", err)
+	}
+
+	// Newest first.
+	runs := c.List()
+	if len(runs) != 2 || runs[0].ID != run.ID || runs[1].ID != dry.ID {
+		t.Errorf("unexpected runs %+v", runs)
+	}
+}
+
+func TestCollectorRunning(t *testing.T) {
+	cfg := newMemoryConfig(t)
+	c := gc.NewCollector(*cfg)
+
+	// Block the sweep.
+	g := cfg.Data.(*guard.GuardDataStorage)
+	err := g.Exclusive(nil, func(mapset.MapSet[string]) error {
+		run, err := c.Start(false, time.Nanosecond, false)
+		if err != nil {
+			return err
+		}
+
+		if _, err := c.Start(false, time.Nanosecond, false); !errors.Is(err, gc.ErrRunning) {
+			t.Errorf("expected ErrRunning, got %v", err)
+		}
+
+		if run, _ := c.Get(run.ID); run.Status != gc.RunStatusRunning {
+			t.Errorf("expected running, got %s", run.Status)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runs := c.List()
+	if len(runs) != 1 {
+		t.Fatalf("expected 1 run, got %d", len(runs))
+	}
+	waitRun(t, c, runs[0].ID)
+}
+
+func TestCollectorCollect(t *testing.T) {
+	cfg := newMemoryConfig(t)
+	c := gc.NewCollector(*cfg)
+
+	kept := putGarbageBlob(t, cfg, []byte("kept"))
+	var digest string
+	run, err := c.Collect(gc.Options{}, func(opts *gc.Options) error {
+		digest = putGarbageBlob(t, cfg, []byte("garbage"))
+		opts.OnlyBlobs = mapset.NewMapSet[string]().Add(digest)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if run.Status != gc.RunStatusSucceeded {
+		t.Errorf("expected succeeded, got %s", run.Status)
+	}
+	if !slices.Equal(run.Result.DeletedBlobs, []string{digest}) {
+		t.Errorf("expected [%s] deleted, got %v", digest, run.Result.DeletedBlobs)
+	}
+	if _, err := cfg.Data.BlobLastAccess(kept); err != nil {
+		t.Errorf("expected blob kept, got %v", err)
+	}
+	if runs := c.List(); len(runs) != 1 || runs[0].ID != run.ID {
+		t.Errorf("expected the run listed, got %v", runs)
+	}
+}
+
+func TestCollectorCollectPrepareFails(t *testing.T) {
+	c := gc.NewCollector(*newMemoryConfig(t))
+
+	errPrepare := errors.New("prepare")
+	if _, err := c.Collect(gc.Options{}, func(*gc.Options) error { return errPrepare }); !errors.Is(err, errPrepare) {
+		t.Errorf("expected prepare error, got %v", err)
+	}
+	if runs := c.List(); len(runs) != 0 {
+		t.Errorf("expected no runs, got %d", len(runs))
+	}
+
+	// The collector is released.
+	if _, err := c.Collect(gc.Options{}, nil); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+}
+
+func TestCollectorSchedule(t *testing.T) {
+	cfg := newMemoryConfig(t,
+		config.WithGarbageCollectInterval(10*time.Millisecond),
+		config.WithGarbageCollectLastAccess(time.Nanosecond),
+	)
+	c := gc.NewCollector(*cfg)
+
+	digest := putGarbageBlob(t, cfg, []byte("garbage"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.Schedule(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := cfg.Data.BlobLastAccess(digest); errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected blob deleted by the scheduled garbage collection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected Schedule to return after cancel")
+	}
+}
+
+func TestCollectorScheduleDisabled(t *testing.T) {
+	c := gc.NewCollector(*newMemoryConfig(t))
+
+	done := make(chan struct{})
+	go func() {
+		c.Schedule(context.Background())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected Schedule to return when disabled")
+	}
+}
@@ -0,0 +1,627 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gc is the mark and sweep garbage collector of the registry storage,
+// run by the garbage-collect command and scheduled or requested through the
+// admin HTTP API.
+package gc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/internal/data/guard"
+	"github.com/jlsalvador/simple-registry/internal/data/immutable"
+	"github.com/jlsalvador/simple-registry/internal/data/proxy"
+	"github.com/jlsalvador/simple-registry/internal/data/quota"
+	"github.com/jlsalvador/simple-registry/pkg/mapset"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+type ManifestRef struct {
+	Repo   string `json:"repo"`
+	Digest string `json:"digest"`
+}
+
+// withoutQuota will return the underlying [quota.QuotaDataStorage.Next] if it
+// is a [quota.QuotaDataStorage], otherwise it will return the same
+// [data.DataStorage].
+func withoutQuota(ds data.DataStorage) data.DataStorage {
+	if d, ok := ds.(*quota.QuotaDataStorage); ok {
+		return d.Next
+	}
+	return ds
+}
+
+// withoutPolicies will return the underlying data storage of the
+// [quota.QuotaDataStorage] and [immutable.ImmutableDataStorage] decorators, if
+// any, otherwise it will return the same [data.DataStorage].
+//
+// The garbage collector only deletes unreferenced manifests and blobs, so push
+// policies do not apply. Immutable tags are kept by [planRetention].
+func withoutPolicies(ds data.DataStorage) data.DataStorage {
+	ds = withoutQuota(ds)
+	if d, ok := ds.(*immutable.ImmutableDataStorage); ok {
+		return d.Next
+	}
+	return ds
+}
+
+// withoutProxy will return the underlying [proxy.ProxyDataStorage.Next] if it
+// is a [proxy.ProxyDataStorage], otherwise it will return the same
+// [data.DataStorage].
+//
+// The reason for this function is to avoid mirroring upstream while we are
+// marking manifests and blobs.
+func withoutProxy(data data.DataStorage) data.DataStorage {
+	if d, ok := data.(*proxy.ProxyDataStorage); ok {
+		return d.Next
+	}
+	return data
+}
+
+func markManifestByReferrers(
+	ds data.DataStorage,
+	repo string,
+	digest string,
+	seenManifests mapset.MapSet[string],
+	seenBlobs mapset.MapSet[string],
+) error {
+	referrers, err := ds.ReferrersGet(repo, digest)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if referrers == nil {
+		return nil
+	}
+	for ref := range referrers {
+		if err := markManifest(ds, repo, ref, seenManifests, seenBlobs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markManifest marks a manifest as seen and recursively marks all manifests and
+// blobs it references.
+func markManifest(
+	ds data.DataStorage,
+	repo string,
+	digest string,
+	seenManifests mapset.MapSet[string],
+	seenBlobs mapset.MapSet[string],
+) error {
+	if seenManifests.Contains(digest) {
+		return nil
+	}
+	seenManifests.Add(digest)
+
+	r, _, _, err := ds.ManifestGet(repo, digest)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			// Manifest file not found, maybe because belongs to a proxy.
+			return nil
+		}
+		return err
+	}
+	defer r.Close()
+
+	payload, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	r.Close()
+
+	var raw map[string]any
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return err
+	}
+
+	_, hasSignatures := raw["signatures"]
+	_, hasHistory := raw["history"]
+	if hasSignatures || hasHistory {
+		raw["mediaType"] = registry.MediaTypeDockerImageManifestV1
+	}
+
+	mediaType, _ := raw["mediaType"].(string)
+
+	switch mediaType {
+
+	case registry.MediaTypeOCIImageManifest,
+		registry.MediaTypeDockerImageManifest:
+		if err := markImageManifest(payload, seenBlobs); err != nil {
+			return err
+		}
+
+	case registry.MediaTypeOCIImageIndex,
+		registry.MediaTypeDockerManifestList:
+		if err := markIndexManifest(payload, ds, repo, seenManifests, seenBlobs); err != nil {
+			return err
+		}
+
+	case registry.MediaTypeDockerImageManifestV1:
+		if err := markDockerV1Manifest(payload, seenBlobs); err != nil {
+			return err
+		}
+
+	default:
+		return fmt.Errorf("unsupported media type: %s", mediaType)
+	}
+
+	if err := markManifestByReferrers(ds, repo, digest, seenManifests, seenBlobs); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func markImageManifest(payload []byte, seenBlobs mapset.MapSet[string]) error {
+	var manifest registry.ImageManifest
+	if err := json.Unmarshal(payload, &manifest); err != nil {
+		return err
+	}
+
+	if manifest.Config.Digest != "" {
+		seenBlobs.Add(manifest.Config.Digest)
+	}
+
+	for _, layer := range manifest.Layers {
+		seenBlobs.Add(layer.Digest)
+	}
+	return nil
+}
+
+func markIndexManifest(
+	payload []byte,
+	ds data.DataStorage,
+	repo string,
+	seenManifests mapset.MapSet[string],
+	seenBlobs mapset.MapSet[string],
+) error {
+	var index registry.ImageIndexManifest
+	if err := json.Unmarshal(payload, &index); err != nil {
+		return err
+	}
+
+	for _, m := range index.Manifests {
+		if err := markManifest(ds, repo, m.Digest, seenManifests, seenBlobs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func markDockerV1Manifest(payload []byte, seenBlobs mapset.MapSet[string]) error {
+	var manifest registry.DockerManifestV1
+	if err := json.Unmarshal(payload, &manifest); err != nil {
+		return err
+	}
+
+	for _, layer := range manifest.FSLayers {
+		if layer.BlobSum != "" {
+			seenBlobs.Add(layer.BlobSum)
+		}
+	}
+	return nil
+}
+
+// collectRootManifests returns a map of repository names to their root manifest
+// digests.
+//
+// If `deleteUntagged` is true, only root manifests that have at least one tag
+// are included.
+func collectRootManifests(
+	ds data.DataStorage,
+	deleteUntagged bool,
+	expiredTags mapset.MapSet[TagRef],
+) (
+	map[string][]string,
+	error,
+) {
+	roots := map[string][]string{}
+
+	repos, err := ds.RepositoriesList()
+	if err != nil {
+		return nil, err
+	}
+	for _, repo := range repos {
+
+		if !deleteUntagged {
+			// Because we are not delete untagged manifests, include all the
+			// manifests from this repo.
+
+			manifests, err := ds.ManifestsList(repo)
+			if err != nil {
+				return nil, err
+			}
+			for digest := range manifests {
+				roots[repo] = append(roots[repo], digest)
+			}
+			continue
+		}
+
+		// We are going to delete untagged manifests, so only include the
+		// manifests that have tags.
+		tags, err := ds.TagsList(repo)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range tags {
+			r, _, digest, err := ds.ManifestGet(repo, tag)
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					// Manifest file not found, maybe because belongs to a proxy.
+					continue
+				}
+				return nil, err
+			}
+			r.Close()
+
+			if expiredTags.Contains(TagRef{repo, tag, digest}) {
+				continue
+			}
+
+			roots[repo] = append(roots[repo], digest)
+		}
+
+	}
+
+	return roots, nil
+}
+
+func planSweepManifests(
+	ds data.DataStorage,
+	seenManifests mapset.MapSet[string],
+	lastAccess time.Duration,
+) (
+	mapset.MapSet[ManifestRef],
+	error,
+) {
+	toDelete := mapset.NewMapSet[ManifestRef]()
+
+	repos, err := ds.RepositoriesList()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, repo := range repos {
+		digests, err := ds.ManifestsList(repo)
+		if err != nil {
+			return nil, err
+		}
+
+		for digest := range digests {
+			manifestLastAccess, err := ds.ManifestLastAccess(digest)
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					// Manifest file not found, maybe because belongs to a proxy.
+					continue
+				}
+				return nil, err
+			}
+
+			if time.Since(manifestLastAccess) > lastAccess && !seenManifests.Contains(digest) {
+				toDelete.Add(ManifestRef{repo, digest})
+			}
+		}
+	}
+
+	return toDelete, nil
+}
+
+func planSweepBlobs(
+	ds data.DataStorage,
+	seenBlobs mapset.MapSet[string],
+	seenManifests mapset.MapSet[string],
+	lastAccess time.Duration,
+) (
+	mapset.MapSet[string],
+	error,
+) {
+	toDelete := mapset.NewMapSet[string]()
+
+	blobs, err := ds.BlobsList()
+	if err != nil {
+		return nil, err
+	}
+
+	for blob := range blobs {
+		blobLastAccess, err := ds.BlobLastAccess(blob)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				// Blob file not found, maybe because belongs to a proxy.
+				continue
+			}
+			return nil, err
+		}
+
+		if time.Since(blobLastAccess) > lastAccess && !seenBlobs.Contains(blob) && !seenManifests.Contains(blob) {
+			toDelete.Add(blob)
+		}
+	}
+
+	return toDelete, nil
+}
+
+// sweep deletes the planned manifests and blobs, except the touched ones.
+//
+// Touched digests are removed from the plan, so the plan reports what was
+// really deleted.
+func sweep(
+	ds data.DataStorage,
+	manifestsToDelete mapset.MapSet[ManifestRef],
+	blobsToDelete mapset.MapSet[string],
+	touched mapset.MapSet[string],
+) error {
+	for m := range manifestsToDelete {
+		if touched.Contains(m.Digest) {
+			delete(manifestsToDelete, m)
+			continue
+		}
+		if err := ds.ManifestDelete(m.Repo, m.Digest); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	for blob := range blobsToDelete {
+		if touched.Contains(blob) {
+			delete(blobsToDelete, blob)
+			continue
+		}
+		if err := ds.BlobsDelete("", blob); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Phase is the current step of a garbage collection.
+type Phase string
+
+const (
+	PhaseRetention Phase = "retention" // Expiring tags by retention policies.
+	PhaseRoots     Phase = "roots"     // Collecting the root manifests.
+	PhaseMark      Phase = "mark"      // Marking the referenced manifests and blobs.
+	PhasePlan      Phase = "plan"      // Planning what to delete.
+	PhaseSweep     Phase = "sweep"     // Deleting.
+)
+
+// Options are the settings of a garbage collection.
+type Options struct {
+	DryRun         bool
+	LastAccess     time.Duration
+	DeleteUntagged bool
+
+	// OnlyBlobs, OnlyManifests and OnlyTags, if not nil, restrict the
+	// deletions to them, like the result of a reviewed dry run.
+	OnlyBlobs     mapset.MapSet[string]
+	OnlyManifests mapset.MapSet[ManifestRef]
+	OnlyTags      mapset.MapSet[TagRef]
+
+	// OnPhase, if not nil, is called when the collection enters a phase.
+	OnPhase func(Phase)
+}
+
+// Result are the deleted, or eligible for deletion if dry run, and the marked
+// manifests and blobs of a garbage collection, and the tags expired by the
+// retention policies.
+type Result struct {
+	DeletedBlobs     mapset.MapSet[string]
+	DeletedManifests mapset.MapSet[ManifestRef]
+	ExpiredTags      mapset.MapSet[TagRef]
+	MarkedBlobs      mapset.MapSet[string]
+	MarkedManifests  mapset.MapSet[string]
+}
+
+// restrict removes from set the elements not in only, if only is not nil.
+func restrict[T comparable](set mapset.MapSet[T], only mapset.MapSet[T]) {
+	if only == nil {
+		return
+	}
+	for e := range set {
+		if !only.Contains(e) {
+			delete(set, e)
+		}
+	}
+}
+
+// mark marks all manifests and blobs that are referenced by the roots.
+func mark(ds data.DataStorage, roots map[string][]string) (
+	markedManifests mapset.MapSet[string],
+	markedBlobs mapset.MapSet[string],
+	err error,
+) {
+	markedManifests = mapset.NewMapSet[string]()
+	markedBlobs = mapset.NewMapSet[string]()
+	for repo, digests := range roots {
+		for _, d := range digests {
+			if err = markManifest(ds, repo, d, markedManifests, markedBlobs); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return markedManifests, markedBlobs, nil
+}
+
+// Local returns the data storage under the [quota.QuotaDataStorage],
+// [immutable.ImmutableDataStorage], [proxy.ProxyDataStorage] and
+// [guard.GuardDataStorage] decorators, if any, so it could be walked without
+// mirroring upstream nor tracking the accessed digests.
+func Local(ds data.DataStorage) data.DataStorage {
+	ds = withoutProxy(withoutPolicies(ds))
+	if g, ok := ds.(*guard.GuardDataStorage); ok {
+		return g.Next
+	}
+	return ds
+}
+
+// IsImmutable returns if the tag of the repository is protected by the
+// [immutable.ImmutableDataStorage] decorator, if any.
+func IsImmutable(ds data.DataStorage, repo, tag string) bool {
+	im, ok := withoutQuota(ds).(*immutable.ImmutableDataStorage)
+	return ok && im.IsImmutable(repo, tag)
+}
+
+// Exclusive runs fn with the [Local] data storage while client operations are
+// blocked by the [guard.GuardDataStorage], if any, like the sweep of [Collect].
+//
+// The quotas do not see the changes made by fn.
+func Exclusive(ds data.DataStorage, fn func(local data.DataStorage) error) error {
+	ds = withoutProxy(withoutPolicies(ds))
+	g, ok := ds.(*guard.GuardDataStorage)
+	if !ok {
+		return fn(ds)
+	}
+	return g.Exclusive(nil, func(mapset.MapSet[string]) error {
+		return fn(g.Next)
+	})
+}
+
+// Referenced returns the manifests and blobs referenced by the given manifests
+// of a repository, walking image indexes and referrers as [Collect] does.
+func Referenced(ds data.DataStorage, repo string, digests []string) (
+	manifests mapset.MapSet[string],
+	blobs mapset.MapSet[string],
+	err error,
+) {
+	return mark(ds, map[string][]string{repo: digests})
+}
+
+// Collect deletes unreferrenced blobs (includes manifests blobs).
+//
+// If the data storage is guarded by a [guard.GuardDataStorage], the registry
+// could keep serving requests while collecting: blobs and manifests used by
+// clients during the collection are kept, and the sweep blocks any write.
+func Collect(cfg config.Config, opts Options) (res Result, err error) {
+	onPhase := opts.OnPhase
+	if onPhase == nil {
+		onPhase = func(Phase) {}
+	}
+
+	isImmutable := func(repo, tag string) bool { return false }
+	if im, ok := withoutQuota(cfg.Data).(*immutable.ImmutableDataStorage); ok {
+		isImmutable = im.IsImmutable
+	}
+
+	ds := withoutProxy(withoutPolicies(cfg.Data))
+
+	g, guarded := ds.(*guard.GuardDataStorage)
+	var tracker *guard.Tracker
+	if guarded {
+		ds = g.Next
+		tracker = g.StartTracking()
+		defer g.StopTracking(tracker)
+	}
+
+	// Expire the tags not kept by the retention policies.
+	onPhase(PhaseRetention)
+	var pushedAt map[TagRef]time.Time
+	res.ExpiredTags, pushedAt, err = planRetention(ds, cfg.GarbageCollect.Retention, isImmutable)
+	if err != nil {
+		return Result{}, err
+	}
+	restrict(res.ExpiredTags, opts.OnlyTags)
+
+	// Collect all the root manifests from all the repositories.
+	onPhase(PhaseRoots)
+	roots, err := collectRootManifests(ds, opts.DeleteUntagged, res.ExpiredTags)
+	if err != nil {
+		return Result{}, err
+	}
+
+	onPhase(PhaseMark)
+	res.MarkedManifests, res.MarkedBlobs, err = mark(ds, roots)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// Walk through all the manifests in the data store, and removes any that
+	// is not referenced and is older than the last access time.
+	onPhase(PhasePlan)
+	res.DeletedManifests, err = planSweepManifests(ds, res.MarkedManifests, opts.LastAccess)
+	if err != nil {
+		return Result{}, err
+	}
+	restrict(res.DeletedManifests, opts.OnlyManifests)
+
+	// Walk through all the blobs in the data store, and removes any that is not
+	// referenced and is older than the last access time.
+	res.DeletedBlobs, err = planSweepBlobs(ds, res.MarkedBlobs, res.MarkedManifests, opts.LastAccess)
+	if err != nil {
+		return Result{}, err
+	}
+	restrict(res.DeletedBlobs, opts.OnlyBlobs)
+
+	if opts.DryRun {
+		return res, nil
+	}
+
+	onPhase(PhaseSweep)
+	fn := func(touched mapset.MapSet[string]) error {
+		if err := sweepTags(ds, res.ExpiredTags, pushedAt); err != nil {
+			return err
+		}
+		return sweep(ds, res.DeletedManifests, res.DeletedBlobs, touched)
+	}
+	if guarded {
+		err = g.Exclusive(tracker, fn)
+	} else {
+		err = fn(mapset.NewMapSet[string]())
+	}
+
+	// The quotas do not see the deletions made under them.
+	if q, ok := cfg.Data.(*quota.QuotaDataStorage); ok {
+		q.Reset()
+	}
+
+	if err != nil {
+		return Result{}, err
+	}
+
+	return res, nil
+}
+
+// GarbageCollect deletes unreferrenced blobs (includes manifests blobs).
+//
+// See [Collect].
+func GarbageCollect(
+	cfg config.Config,
+	dryRun bool,
+	lastAccess time.Duration,
+	deleteUntagged bool,
+) (
+	mapset.MapSet[string],
+	mapset.MapSet[ManifestRef],
+	mapset.MapSet[string],
+	mapset.MapSet[string],
+	error,
+) {
+	res, err := Collect(cfg, Options{
+		DryRun:         dryRun,
+		LastAccess:     lastAccess,
+		DeleteUntagged: deleteUntagged,
+	})
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	return res.DeletedBlobs, res.DeletedManifests, res.MarkedBlobs, res.MarkedManifests, nil
+}
@@ -1,15 +1,32 @@
-package garbagecollect_test
+// Copyright 2026 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gc_test
 
 import (
 	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"iter"
 	"slices"
 	"testing"
 
-	garbagecollect "github.com/jlsalvador/simple-registry/internal/cmd/garbage_collect"
 	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/internal/data/guard"
+	"github.com/jlsalvador/simple-registry/internal/gc"
 	"github.com/jlsalvador/simple-registry/pkg/digest"
 	"github.com/jlsalvador/simple-registry/pkg/mapset"
 	"github.com/jlsalvador/simple-registry/pkg/registry"
@@ -254,7 +271,7 @@ func TestGarbageCollect(t *testing.T) {
 		len(blobs)+len(indexes)+len(attestations)+len(images),
 	)
 
-	gotDeletedBlobs, gotDeletedManifests, _, _, err := garbagecollect.GarbageCollect(*cfg, false, 1, false)
+	gotDeletedBlobs, gotDeletedManifests, _, _, err := gc.GarbageCollect(*cfg, false, 1, false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -291,7 +308,7 @@ func TestGarbageCollectUntaggedManifests(t *testing.T) {
 		len(blobs)+len(indexes)+len(attestations)+len(images),
 	)
 
-	gotDeletedBlobs, gotDeletedManifests, _, _, err := garbagecollect.GarbageCollect(*cfg, false, 1, true)
+	gotDeletedBlobs, gotDeletedManifests, _, _, err := gc.GarbageCollect(*cfg, false, 1, true)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -325,3 +342,48 @@ func TestGarbageCollectUntaggedManifests(t *testing.T) {
 		t.Errorf("expected %v, got %v", wantDeletedBlobs, gotDeletedBlobs)
 	}
 }
+
+// touchingDataStorage uses a blob through the guard while the garbage collector
+// is planning the sweep, as a registry client would do.
+type touchingDataStorage struct {
+	data.DataStorage
+
+	guard  *guard.GuardDataStorage
+	repo   string
+	digest string
+}
+
+func (s *touchingDataStorage) BlobsList() (iter.Seq[string], error) {
+	if r, _, err := s.guard.BlobsGet(s.repo, s.digest); err == nil {
+		r.Close()
+	}
+	return s.DataStorage.BlobsList()
+}
+
+func TestGarbageCollectKeepsTouchedBlobs(t *testing.T) {
+	cfg, repo, blobs, _, _, _, err := setupTestEnvironment(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The fourth blob is not referenced, but it is used by a client meanwhile.
+	g := cfg.Data.(*guard.GuardDataStorage)
+	g.Next = &touchingDataStorage{
+		DataStorage: g.Next,
+		guard:       g,
+		repo:        repo,
+		digest:      blobs[3].Digest,
+	}
+
+	gotDeletedBlobs, _, _, _, err := gc.GarbageCollect(*cfg, false, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gotDeletedBlobs.Contains(blobs[3].Digest) {
+		t.Errorf("expected %s kept, got deleted %v", blobs[3].Digest, gotDeletedBlobs)
+	}
+	if _, err := cfg.Data.BlobLastAccess(blobs[3].Digest); err != nil {
+		t.Errorf("expected blob kept, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gc
+
+import (
+	"fmt"
+
+	"github.com/jlsalvador/simple-registry/internal/version"
+	"github.com/jlsalvador/simple-registry/pkg/log"
+)
+
+// LogResult logs the expired tags, the deleted, or eligible for deletion if
+// dryRun, manifests and blobs, and a summary.
+func LogResult(dryRun bool, res Result) {
+	nTagsExpired := 0
+	for t := range res.ExpiredTags {
+		nTagsExpired++
+		if dryRun {
+			log.Debug(
+				"service.name", version.AppName,
+				"service.version", version.AppVersion,
+				"event.dataset", "cmd.garbage_collect",
+				"message", fmt.Sprintf("tag eligible for expiration: %s:%s", t.Repo, t.Tag),
+			).Print()
+		} else {
+			log.Debug(
+				"service.name", version.AppName,
+				"service.version", version.AppVersion,
+				"event.dataset", "cmd.garbage_collect",
+				"message", fmt.Sprintf("tag expired: %s:%s", t.Repo, t.Tag),
+			).Print()
+		}
+	}
+
+	nManifestsDeleted := 0
+	for digest := range res.DeletedManifests {
+		nManifestsDeleted++
+		if dryRun {
+			log.Debug(
+				"service.name", version.AppName,
+				"service.version", version.AppVersion,
+				"event.dataset", "cmd.garbage_collect",
+				"message", fmt.Sprintf("manifest eligible for deletion: %s", digest),
+			).Print()
+		} else {
+			log.Debug(
+				"service.name", version.AppName,
+				"service.version", version.AppVersion,
+				"event.dataset", "cmd.garbage_collect",
+				"message", fmt.Sprintf("manifest deleted: %s", digest),
+			).Print()
+		}
+	}
+
+	nBlobsDeleted := 0
+	for digest := range res.DeletedBlobs {
+		nBlobsDeleted++
+		if dryRun {
+			log.Debug(
+				"service.name", version.AppName,
+				"service.version", version.AppVersion,
+				"event.dataset", "cmd.garbage_collect",
+				"message", fmt.Sprintf("blob eligible for deletion: %s", digest),
+			).Print()
+		} else {
+			log.Debug(
+				"service.name", version.AppName,
+				"service.version", version.AppVersion,
+				"event.dataset", "cmd.garbage_collect",
+				"message", fmt.Sprintf("blob deleted: %s", digest),
+			).Print()
+		}
+	}
+	if dryRun {
+		log.Info(
+			"service.name", version.AppName,
+			"service.version", version.AppVersion,
+			"event.dataset", "cmd.garbage_collect",
+			"message", fmt.Sprintf(
+				"%d manifests marked, %d blobs marked, %d tags eligible for expiration, %d manifests eligible for deletion, %d blobs eligible for deletion",
+				len(res.MarkedManifests), len(res.MarkedBlobs), nTagsExpired, nManifestsDeleted, nBlobsDeleted,
+			),
+		).Print()
+
+	} else {
+		log.Info(
+			"service.name", version.AppName,
+			"service.version", version.AppVersion,
+			"event.dataset", "cmd.garbage_collect",
+			"message", fmt.Sprintf(
+				"%d manifests marked, %d blobs marked, %d tags expired, %d manifests deleted, %d blobs deleted",
+				len(res.MarkedManifests), len(res.MarkedBlobs), nTagsExpired, nManifestsDeleted, nBlobsDeleted,
+			),
+		).Print()
+	}
+
+}
@@ -0,0 +1,229 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gc
+
+import (
+	"errors"
+	"io/fs"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/pkg/mapset"
+)
+
+// TagRef is a tag of a repository, and the manifest it points to.
+type TagRef struct {
+	Repo   string `json:"repo"`
+	Tag    string `json:"tag"`
+	Digest string `json:"digest"`
+}
+
+type pushedTag struct {
+	TagRef
+	pushedAt time.Time
+}
+
+func matchAny(exprs []regexp.Regexp, s string) bool {
+	for i := range exprs {
+		if exprs[i].MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// retains returns if the policy keeps the tag, being rank its position in the
+// repository by push time, newest first.
+func retains(p config.RetentionPolicy, tag string, rank int, age time.Duration) bool {
+	if p.KeepLast == 0 && p.ExpireAfter == 0 {
+		return true
+	}
+	if matchAny(p.KeepTags, tag) {
+		return true
+	}
+	if p.KeepLast > 0 && rank < p.KeepLast {
+		return true
+	}
+	if p.ExpireAfter > 0 && age < p.ExpireAfter {
+		return true
+	}
+	return false
+}
+
+func hasReferrers(ds data.DataStorage, repo, digest string) (bool, error) {
+	referrers, err := ds.ReferrersGet(repo, digest)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	if referrers == nil {
+		return false, nil
+	}
+	for range referrers {
+		return true, nil
+	}
+	return false, nil
+}
+
+// pushedTags returns the tags of the repository, newest first.
+func pushedTags(ds data.DataStorage, repo string) ([]pushedTag, error) {
+	tags, err := ds.TagsList(repo)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	pushed := make([]pushedTag, 0, len(tags))
+	for _, tag := range tags {
+		r, _, digest, err := ds.ManifestGet(repo, tag)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				// Manifest file not found, maybe because belongs to a proxy.
+				continue
+			}
+			return nil, err
+		}
+		r.Close()
+
+		pushedAt, err := ds.TagLastModified(repo, tag)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		pushed = append(pushed, pushedTag{TagRef{repo, tag, digest}, pushedAt})
+	}
+
+	slices.SortFunc(pushed, func(a, b pushedTag) int {
+		return b.pushedAt.Compare(a.pushedAt)
+	})
+	return pushed, nil
+}
+
+// planRetention returns the tags expired by the retention policies, and when
+// every one of them was pushed.
+//
+// A tag is kept if any policy matching its repository keeps it. Tags with
+// referrers, like signatures, and immutable tags are never expired.
+func planRetention(
+	ds data.DataStorage,
+	policies []config.RetentionPolicy,
+	isImmutable func(repo, tag string) bool,
+) (
+	expired mapset.MapSet[TagRef],
+	pushedAt map[TagRef]time.Time,
+	err error,
+) {
+	expired = mapset.NewMapSet[TagRef]()
+	pushedAt = map[TagRef]time.Time{}
+	if len(policies) == 0 {
+		return expired, pushedAt, nil
+	}
+
+	repos, err := ds.RepositoriesList()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, repo := range repos {
+		var matched []config.RetentionPolicy
+		for _, p := range policies {
+			if matchAny(p.Scopes, repo) {
+				matched = append(matched, p)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+
+		tags, err := pushedTags(ds, repo)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for rank, tag := range tags {
+			kept := slices.ContainsFunc(matched, func(p config.RetentionPolicy) bool {
+				return retains(p, tag.Tag, rank, time.Since(tag.pushedAt))
+			})
+			if kept || isImmutable(repo, tag.Tag) {
+				continue
+			}
+
+			referred, err := hasReferrers(ds, repo, tag.Digest)
+			if err != nil {
+				return nil, nil, err
+			}
+			if referred {
+				continue
+			}
+
+			expired.Add(tag.TagRef)
+			pushedAt[tag.TagRef] = tag.pushedAt
+		}
+	}
+
+	return expired, pushedAt, nil
+}
+
+// sweepTags deletes the expired tags.
+//
+// Tags pushed again since planned, even to the same manifest, are kept and
+// removed from expired, so it reports what was really deleted.
+func sweepTags(ds data.DataStorage, expired mapset.MapSet[TagRef], pushedAt map[TagRef]time.Time) error {
+	for t := range expired {
+		r, _, digest, err := ds.ManifestGet(t.Repo, t.Tag)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				delete(expired, t)
+				continue
+			}
+			return err
+		}
+		r.Close()
+
+		if digest != t.Digest {
+			delete(expired, t)
+			continue
+		}
+
+		lastModified, err := ds.TagLastModified(t.Repo, t.Tag)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				delete(expired, t)
+				continue
+			}
+			return err
+		}
+		if !lastModified.Equal(pushedAt[t]) {
+			delete(expired, t)
+			continue
+		}
+
+		if err := ds.ManifestDelete(t.Repo, t.Tag); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
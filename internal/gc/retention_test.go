@@ -0,0 +1,247 @@
+// Copyright 2026 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gc_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/data/immutable"
+	"github.com/jlsalvador/simple-registry/internal/gc"
+	"github.com/jlsalvador/simple-registry/pkg/mapset"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+// putTaggedManifest pushes a distinct manifest for each tag, optionally
+// referring to subject.
+func putTaggedManifest(t *testing.T, cfg *config.Config, repo, tag string, subject *registry.DescriptorManifest) string {
+	t.Helper()
+
+	payload, err := json.Marshal(registry.ImageManifest{
+		SchemaVersion: 2,
+		MediaType:     registry.MediaTypeOCIImageManifest,
+		Subject:       subject,
+		Annotations:   map[string]string{"tag": tag},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest, err := cfg.Data.ManifestPut(repo, tag, bytes.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Push times must differ.
+	time.Sleep(2 * time.Millisecond)
+	return digest
+}
+
+func keepLastPolicy(keepLast int, keepTags ...string) config.RetentionPolicy {
+	p := config.RetentionPolicy{
+		Name:     "ci",
+		Scopes:   []regexp.Regexp{*regexp.MustCompile("^app$")},
+		KeepLast: keepLast,
+	}
+	for _, tag := range keepTags {
+		p.KeepTags = append(p.KeepTags, *regexp.MustCompile(tag))
+	}
+	return p
+}
+
+func TestRetentionKeepLast(t *testing.T) {
+	cfg := newMemoryConfig(t,
+		config.WithGarbageCollectRetention([]config.RetentionPolicy{keepLastPolicy(2, "^latest$")}),
+	)
+
+	digests := map[string]string{}
+	for _, tag := range []string{"latest", "v1", "v2", "v3", "v4"} {
+		digests[tag] = putTaggedManifest(t, cfg, "app", tag, nil)
+	}
+	// Other repositories are not affected.
+	putTaggedManifest(t, cfg, "other", "stable", nil)
+
+	want := mapset.NewMapSet[gc.TagRef]().Add(
+		gc.TagRef{Repo: "app", Tag: "v1", Digest: digests["v1"]},
+		gc.TagRef{Repo: "app", Tag: "v2", Digest: digests["v2"]},
+	)
+	wantManifests := mapset.NewMapSet[gc.ManifestRef]().Add(
+		gc.ManifestRef{Repo: "app", Digest: digests["v1"]},
+		gc.ManifestRef{Repo: "app", Digest: digests["v2"]},
+	)
+
+	// Dry runs report the expired tags, and the manifests they free.
+	res, err := gc.Collect(*cfg, gc.Options{
+		DryRun:         true,
+		LastAccess:     time.Nanosecond,
+		DeleteUntagged: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.ExpiredTags.Equal(want) {
+		t.Errorf("expected expired tags %v, got %v", want, res.ExpiredTags)
+	}
+	if !res.DeletedManifests.Equal(wantManifests) {
+		t.Errorf("expected deleted manifests %v, got %v", wantManifests, res.DeletedManifests)
+	}
+	if _, _, _, err := cfg.Data.ManifestGet("app", "v1"); err != nil {
+		t.Errorf("expected tag kept by dry run, got %v", err)
+	}
+
+	res, err = gc.Collect(*cfg, gc.Options{
+		LastAccess:     time.Nanosecond,
+		DeleteUntagged: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.ExpiredTags.Equal(want) {
+		t.Errorf("expected expired tags %v, got %v", want, res.ExpiredTags)
+	}
+	for _, tag := range []string{"v1", "v2"} {
+		if _, _, _, err := cfg.Data.ManifestGet("app", tag); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected tag %s expired, got %v", tag, err)
+		}
+		if _, _, _, err := cfg.Data.ManifestGet("app", digests[tag]); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected manifest of %s deleted, got %v", tag, err)
+		}
+	}
+	for _, tag := range []string{"latest", "v3", "v4"} {
+		if _, _, _, err := cfg.Data.ManifestGet("app", tag); err != nil {
+			t.Errorf("expected tag %s kept, got %v", tag, err)
+		}
+	}
+	if _, _, _, err := cfg.Data.ManifestGet("other", "stable"); err != nil {
+		t.Errorf("expected other repository kept, got %v", err)
+	}
+}
+
+func TestRetentionKeepsTagsPushedAgain(t *testing.T) {
+	cfg := newMemoryConfig(t,
+		config.WithGarbageCollectRetention([]config.RetentionPolicy{keepLastPolicy(1)}),
+	)
+
+	putTaggedManifest(t, cfg, "app", "v1", nil)
+	putTaggedManifest(t, cfg, "app", "v2", nil)
+
+	// v1 is pushed again, with the same manifest, once planned expired.
+	res, err := gc.Collect(*cfg, gc.Options{
+		OnPhase: func(p gc.Phase) {
+			if p == gc.PhaseSweep {
+				putTaggedManifest(t, cfg, "app", "v1", nil)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.ExpiredTags) != 0 {
+		t.Errorf("expected no expired tags, got %v", res.ExpiredTags)
+	}
+	if _, _, _, err := cfg.Data.ManifestGet("app", "v1"); err != nil {
+		t.Errorf("expected tag v1 kept, got %v", err)
+	}
+}
+
+func TestRetentionExpireAfter(t *testing.T) {
+	cfg := newMemoryConfig(t,
+		config.WithGarbageCollectRetention([]config.RetentionPolicy{{
+			Name:        "old",
+			Scopes:      []regexp.Regexp{*regexp.MustCompile("^app$")},
+			ExpireAfter: time.Hour,
+		}, {
+			Name:        "now",
+			Scopes:      []regexp.Regexp{*regexp.MustCompile("^app$")},
+			ExpireAfter: time.Nanosecond,
+		}}),
+	)
+
+	putTaggedManifest(t, cfg, "app", "v1", nil)
+
+	// A tag is kept if any policy keeps it.
+	res, err := gc.Collect(*cfg, gc.Options{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.ExpiredTags) != 0 {
+		t.Errorf("expected no expired tags, got %v", res.ExpiredTags)
+	}
+}
+
+func TestRetentionKeepsReferredAndImmutableTags(t *testing.T) {
+	cfg := newMemoryConfig(t,
+		config.WithGarbageCollectRetention([]config.RetentionPolicy{keepLastPolicy(1)}),
+		config.WithImmutableTags([]immutable.Rule{{
+			Name:   "releases",
+			Scopes: []regexp.Regexp{*regexp.MustCompile("^app$")},
+			Tags:   []regexp.Regexp{*regexp.MustCompile(`^v[0-9]+$`)},
+		}}),
+	)
+
+	putTaggedManifest(t, cfg, "app", "v1", nil)
+	signed := putTaggedManifest(t, cfg, "app", "signed", nil)
+	ci1 := putTaggedManifest(t, cfg, "app", "ci-1", nil)
+	ci2 := putTaggedManifest(t, cfg, "app", "ci-2", nil)
+
+	// A signature refers to "signed".
+	r, size, _, err := cfg.Data.ManifestGet("app", signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	putTaggedManifest(t, cfg, "app", "signature", &registry.DescriptorManifest{
+		MediaType: registry.MediaTypeOCIImageManifest,
+		Digest:    signed,
+		Size:      size,
+	})
+
+	res, err := gc.Collect(*cfg, gc.Options{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only "signature" is kept by keepLast; "v1" is immutable and "signed"
+	// has a referrer.
+	want := mapset.NewMapSet[gc.TagRef]().Add(
+		gc.TagRef{Repo: "app", Tag: "ci-1", Digest: ci1},
+		gc.TagRef{Repo: "app", Tag: "ci-2", Digest: ci2},
+	)
+	if !res.ExpiredTags.Equal(want) {
+		t.Errorf("expected expired tags %v, got %v", want, res.ExpiredTags)
+	}
+}
+
+func TestRetentionWithoutLimits(t *testing.T) {
+	cfg := newMemoryConfig(t,
+		config.WithGarbageCollectRetention([]config.RetentionPolicy{keepLastPolicy(0)}),
+	)
+
+	putTaggedManifest(t, cfg, "app", "v1", nil)
+
+	res, err := gc.Collect(*cfg, gc.Options{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.ExpiredTags) != 0 {
+		t.Errorf("expected no expired tags, got %v", res.ExpiredTags)
+	}
+}
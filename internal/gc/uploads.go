@@ -0,0 +1,145 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/internal/version"
+	"github.com/jlsalvador/simple-registry/pkg/log"
+)
+
+// uploadsJanitorInterval is the maximum time between two runs of the uploads
+// janitor.
+const uploadsJanitorInterval = time.Hour
+
+// ExpireUploads deletes, or just returns if dryRun, the upload sessions
+// started more than maxAge ago. Sessions do not expire if maxAge is not
+// positive.
+func ExpireUploads(ds data.DataStorage, maxAge time.Duration, dryRun bool) (expired []data.Upload, err error) {
+	if maxAge <= 0 {
+		return nil, nil
+	}
+
+	uploads, err := ds.BlobsUploadsList()
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(-maxAge)
+	for up := range uploads {
+		if !up.StartedAt.Before(deadline) {
+			continue
+		}
+
+		if !dryRun {
+			// The upload could be committed or canceled meanwhile.
+			err := ds.BlobsUploadCancel(up.Repo, up.UUID)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return expired, err
+			}
+		}
+		expired = append(expired, up)
+	}
+
+	return expired, nil
+}
+
+// LogExpiredUploads logs the deleted, or eligible for deletion if dryRun,
+// upload sessions, and a summary if there is any.
+func LogExpiredUploads(dryRun bool, expired []data.Upload) {
+	for _, up := range expired {
+		if dryRun {
+			log.Debug(
+				"service.name", version.AppName,
+				"service.version", version.AppVersion,
+				"event.dataset", "cmd.garbage_collect",
+				"message", fmt.Sprintf("upload eligible for deletion: %s/%s started at %s", up.Repo, up.UUID, up.StartedAt.Format(time.RFC3339)),
+			).Print()
+		} else {
+			log.Debug(
+				"service.name", version.AppName,
+				"service.version", version.AppVersion,
+				"event.dataset", "cmd.garbage_collect",
+				"message", fmt.Sprintf("upload deleted: %s/%s started at %s", up.Repo, up.UUID, up.StartedAt.Format(time.RFC3339)),
+			).Print()
+		}
+	}
+
+	if len(expired) == 0 {
+		return
+	}
+	if dryRun {
+		log.Info(
+			"service.name", version.AppName,
+			"service.version", version.AppVersion,
+			"event.dataset", "cmd.garbage_collect",
+			"message", fmt.Sprintf("%d uploads eligible for deletion", len(expired)),
+		).Print()
+	} else {
+		log.Info(
+			"service.name", version.AppName,
+			"service.version", version.AppVersion,
+			"event.dataset", "cmd.garbage_collect",
+			"message", fmt.Sprintf("%d uploads deleted", len(expired)),
+		).Print()
+	}
+}
+
+// ScheduleUploadsJanitor deletes the upload sessions older than
+// [config.Uploads.MaxAge] now, and then every hour, or every MaxAge if it is
+// shorter, until ctx is done.
+//
+// It returns immediately if MaxAge is not positive.
+func ScheduleUploadsJanitor(ctx context.Context, cfg config.Config) {
+	maxAge := cfg.Uploads.MaxAge
+	if maxAge <= 0 {
+		return
+	}
+
+	expire := func() {
+		expired, err := ExpireUploads(cfg.Data, maxAge, false)
+		LogExpiredUploads(false, expired)
+		if err != nil {
+			log.Warn(
+				"service.name", version.AppName,
+				"service.version", version.AppVersion,
+				"event.dataset", "cmd.garbage_collect",
+				"error.message", err.Error(),
+				"message", "cannot delete expired uploads",
+			).Print()
+		}
+	}
+
+	expire()
+
+	ticker := time.NewTicker(min(maxAge, uploadsJanitorInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expire()
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2026 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gc_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/gc"
+)
+
+func TestExpireUploadsDisabled(t *testing.T) {
+	cfg := newMemoryConfig(t)
+
+	uuid, err := cfg.Data.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, maxAge := range []time.Duration{0, -time.Hour} {
+		expired, err := gc.ExpireUploads(cfg.Data, maxAge, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(expired) != 0 {
+			t.Errorf("expected no expired uploads with max age %s, got %v", maxAge, expired)
+		}
+	}
+	if _, err := cfg.Data.BlobsUploadSize("repo", uuid); err != nil {
+		t.Errorf("expected upload kept, got %v", err)
+	}
+}
+
+func TestExpireUploads(t *testing.T) {
+	cfg := newMemoryConfig(t)
+
+	old, err := cfg.Data.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	recent, err := cfg.Data.BlobsUploadCreate("other/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Dry run only reports the old upload.
+	expired, err := gc.ExpireUploads(cfg.Data, 10*time.Millisecond, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 1 || expired[0].UUID != old || expired[0].Repo != "repo" {
+		t.Fatalf("expected upload %s, got %v", old, expired)
+	}
+	if _, err := cfg.Data.BlobsUploadSize("repo", old); err != nil {
+		t.Errorf("expected upload kept by dry run, got %v", err)
+	}
+
+	expired, err = gc.ExpireUploads(cfg.Data, 10*time.Millisecond, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 1 || expired[0].UUID != old {
+		t.Fatalf("expected upload %s, got %v", old, expired)
+	}
+	if _, err := cfg.Data.BlobsUploadSize("repo", old); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+	if _, err := cfg.Data.BlobsUploadSize("other/repo", recent); err != nil {
+		t.Errorf("expected recent upload kept, got %v", err)
+	}
+}
+
+func TestScheduleUploadsJanitor(t *testing.T) {
+	cfg := newMemoryConfig(t, config.WithUploadsMaxAge(10*time.Millisecond))
+
+	uuid, err := cfg.Data.BlobsUploadCreate("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go gc.ScheduleUploadsJanitor(ctx, *cfg)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, err := cfg.Data.BlobsUploadSize("repo", uuid)
+		if errors.Is(err, fs.ErrNotExist) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected upload deleted by the janitor, got %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"encoding/json"
+	netHttp "net/http"
+	"strconv"
+
+	"github.com/jlsalvador/simple-registry/internal/storageops"
+)
+
+// defaultDuTop is the amount of largest blobs reported by default.
+const defaultDuTop = 10
+
+// AdminDu returns the storage used by each repository, and the largest blobs.
+//
+// The amount of largest blobs could be set with the "top" query parameter.
+//
+// # Route pattern:
+//
+//	"GET /admin/du"
+//
+// # HTTP status codes:
+//   - 200 OK
+//   - 400 Bad Request
+//   - 401 Unauthorized
+//   - 403 Forbidden
+//   - 500 Internal Server Error
+func (m *ServeMux) AdminDu(
+	w netHttp.ResponseWriter,
+	r *netHttp.Request,
+) {
+	if !m.IsRequestAllowed(r, "du", "", netHttp.MethodGet) {
+		ChallengeRequest(w, r)
+		return
+	}
+
+	top := defaultDuTop
+	if s := r.URL.Query().Get("top"); s != "" {
+		var err error
+		top, err = strconv.Atoi(s)
+		if err != nil || top < 0 {
+			w.WriteHeader(netHttp.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := storageops.Usage(m.cfg.Data, top)
+	if err != nil {
+		LogError(err)
+		w.WriteHeader(netHttp.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(netHttp.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/http/handler"
+	"github.com/jlsalvador/simple-registry/internal/storageops"
+)
+
+func TestAdminDu(t *testing.T) {
+	cfg, err := config.New(
+		config.WithAdminName(testUser),
+		config.WithAdminPwd([]byte(testPwd)),
+		config.WithDataDir("mem://"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handler.NewHandler(*cfg)
+
+	for _, blob := range [][]byte{[]byte("first blob"), []byte("second blob")} {
+		sum := sha256.Sum256(blob)
+		digest := "sha256:" + hex.EncodeToString(sum[:])
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v2/app/blobs/uploads/?digest="+digest, bytes.NewReader(blob))
+		r.SetBasicAuth(testUser, testPwd)
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/du", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/admin/du?top=invalid", nil)
+	r.SetBasicAuth(testUser, testPwd)
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/admin/du?top=1", nil)
+	r.SetBasicAuth(testUser, testPwd)
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var report storageops.UsageReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+
+	// Blobs without manifests are not referenced by their repository.
+	if report.Blobs != 2 || report.UnreferencedBytes != 21 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if len(report.LargestBlobs) != 1 || report.LargestBlobs[0].Size != 11 {
+		t.Errorf("unexpected largest blobs %+v", report.LargestBlobs)
+	}
+}
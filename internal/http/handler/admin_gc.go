@@ -0,0 +1,206 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	netHttp "net/http"
+	"strconv"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/gc"
+)
+
+func writeRun(w netHttp.ResponseWriter, status int, run gc.Run) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(run)
+}
+
+// AdminGCList returns the latest garbage collection runs, newest first,
+// without their results.
+//
+// # Route pattern:
+//
+//	"GET /admin/gc"
+//
+// # HTTP status codes:
+//   - 200 OK
+//   - 401 Unauthorized
+//   - 403 Forbidden
+func (m *ServeMux) AdminGCList(
+	w netHttp.ResponseWriter,
+	r *netHttp.Request,
+) {
+	if !m.IsRequestAllowed(r, "gc", "", netHttp.MethodGet) {
+		ChallengeRequest(w, r)
+		return
+	}
+
+	runs := m.collector.List()
+	for i := range runs {
+		runs[i].Result = nil
+	}
+
+	response := map[string]any{
+		"runs": runs,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(netHttp.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// AdminGCStart starts a garbage collection run in background.
+//
+// Query parameters "dryrun" (defaults to true), "deleteUntagged" and
+// "lastAccess" (a duration like "6h") override the configured ones.
+//
+// # Route pattern:
+//
+//	"POST /admin/gc"
+//
+// # HTTP status codes:
+//   - 202 Accepted     - The run started, see the Location header.
+//   - 400 Bad Request  - Invalid query parameters.
+//   - 401 Unauthorized
+//   - 403 Forbidden
+//   - 409 Conflict     - Another run is in progress.
+func (m *ServeMux) AdminGCStart(
+	w netHttp.ResponseWriter,
+	r *netHttp.Request,
+) {
+	if !m.IsRequestAllowed(r, "gc", "", netHttp.MethodPost) {
+		ChallengeRequest(w, r)
+		return
+	}
+
+	var err error
+	query := r.URL.Query()
+
+	dryRun := true
+	if v := query.Get("dryrun"); v != "" {
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			w.WriteHeader(netHttp.StatusBadRequest)
+			return
+		}
+	}
+
+	deleteUntagged := m.cfg.GarbageCollect.DeleteUntagged
+	if v := query.Get("deleteUntagged"); v != "" {
+		if deleteUntagged, err = strconv.ParseBool(v); err != nil {
+			w.WriteHeader(netHttp.StatusBadRequest)
+			return
+		}
+	}
+
+	lastAccess := m.cfg.GarbageCollect.LastAccess
+	if v := query.Get("lastAccess"); v != "" {
+		if lastAccess, err = time.ParseDuration(v); err != nil || lastAccess < 0 {
+			w.WriteHeader(netHttp.StatusBadRequest)
+			return
+		}
+	}
+
+	run, err := m.collector.Start(dryRun, lastAccess, deleteUntagged)
+	if err != nil {
+		if errors.Is(err, gc.ErrRunning) {
+			w.WriteHeader(netHttp.StatusConflict)
+			return
+		}
+
+		LogError(err)
+		w.WriteHeader(netHttp.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", "/admin/gc/"+run.ID)
+	writeRun(w, netHttp.StatusAccepted, run)
+}
+
+// AdminGCGet returns a garbage collection run, including its result once
+// finished: the blobs and manifests deleted (or to delete, for dry runs) and
+// the marked ones.
+//
+// # Route pattern:
+//
+//	"GET /admin/gc/<id>"
+//
+// # HTTP status codes:
+//   - 200 OK
+//   - 401 Unauthorized
+//   - 403 Forbidden
+//   - 404 Not Found
+func (m *ServeMux) AdminGCGet(
+	w netHttp.ResponseWriter,
+	r *netHttp.Request,
+) {
+	if !m.IsRequestAllowed(r, "gc", "", netHttp.MethodGet) {
+		ChallengeRequest(w, r)
+		return
+	}
+
+	run, ok := m.collector.Get(r.PathValue("id"))
+	if !ok {
+		w.WriteHeader(netHttp.StatusNotFound)
+		return
+	}
+
+	writeRun(w, netHttp.StatusOK, run)
+}
+
+// AdminGCConfirm starts a run that deletes the blobs and manifests reviewed
+// in a succeeded dry run, if they are still garbage.
+//
+// # Route pattern:
+//
+//	"POST /admin/gc/<id>/confirm"
+//
+// # HTTP status codes:
+//   - 202 Accepted     - The run started, see the Location header.
+//   - 400 Bad Request  - The run is not a succeeded dry run.
+//   - 401 Unauthorized
+//   - 403 Forbidden
+//   - 404 Not Found
+//   - 409 Conflict     - Another run is in progress.
+func (m *ServeMux) AdminGCConfirm(
+	w netHttp.ResponseWriter,
+	r *netHttp.Request,
+) {
+	if !m.IsRequestAllowed(r, "gc", "", netHttp.MethodPost) {
+		ChallengeRequest(w, r)
+		return
+	}
+
+	run, err := m.collector.Confirm(r.PathValue("id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, gc.ErrRunNotFound):
+			w.WriteHeader(netHttp.StatusNotFound)
+		case errors.Is(err, gc.ErrRunNotConfirmable):
+			w.WriteHeader(netHttp.StatusBadRequest)
+		case errors.Is(err, gc.ErrRunning):
+			w.WriteHeader(netHttp.StatusConflict)
+		default:
+			LogError(err)
+			w.WriteHeader(netHttp.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Location", "/admin/gc/"+run.ID)
+	writeRun(w, netHttp.StatusAccepted, run)
+}
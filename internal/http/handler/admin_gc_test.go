@@ -0,0 +1,160 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/gc"
+	"github.com/jlsalvador/simple-registry/internal/http/handler"
+)
+
+func testSetupAdminGC(t *testing.T) http.Handler {
+	t.Helper()
+
+	cfg, err := config.New(
+		config.WithAdminName(testUser),
+		config.WithAdminPwd([]byte(testPwd)),
+		config.WithDataDir("mem://"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return handler.NewHandler(*cfg, handler.WithGarbageCollector(gc.NewCollector(*cfg)))
+}
+
+func testAdminGCRequest(t *testing.T, h http.Handler, method, target string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := httptest.NewRequest(method, target, nil)
+	r.SetBasicAuth(testUser, testPwd)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func testAdminGCWait(t *testing.T, h http.Handler, location string) gc.Run {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		w := testAdminGCRequest(t, h, http.MethodGet, location)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var run gc.Run
+		if err := json.NewDecoder(w.Body).Decode(&run); err != nil {
+			t.Fatal(err)
+		}
+		if run.Status != gc.RunStatusRunning {
+			return run
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("run %s still running", run.ID)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAdminGC(t *testing.T) {
+	h := testSetupAdminGC(t)
+
+	// Anonymous users are challenged.
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/gc", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	if w := testAdminGCRequest(t, h, http.MethodPost, "/admin/gc?dryrun=maybe"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := testAdminGCRequest(t, h, http.MethodPost, "/admin/gc?lastAccess=-1h"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	// Dry run by default.
+	w = testAdminGCRequest(t, h, http.MethodPost, "/admin/gc?lastAccess=1s")
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+	dry := testAdminGCWait(t, h, w.Header().Get("Location"))
+	if dry.Status != gc.RunStatusSucceeded || !dry.DryRun || dry.LastAccess != time.Second {
+		t.Fatalf("unexpected run %+v", dry)
+	}
+	if dry.Result == nil {
+		t.Fatal("expected run result")
+	}
+
+	w = testAdminGCRequest(t, h, http.MethodPost, "/admin/gc/"+dry.ID+"/confirm")
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+	run := testAdminGCWait(t, h, w.Header().Get("Location"))
+	if run.Status != gc.RunStatusSucceeded || run.DryRun || run.Confirms != dry.ID {
+		t.Fatalf("unexpected run %+v", run)
+	}
+
+	// Only dry runs are confirmable.
+	if w := testAdminGCRequest(t, h, http.MethodPost, "/admin/gc/"+run.ID+"/confirm"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	const unknown = "/admin/gc/00000000-0000-0000-0000-000000000000"
+	if w := testAdminGCRequest(t, h, http.MethodGet, unknown); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := testAdminGCRequest(t, h, http.MethodPost, unknown+"/confirm"); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w = testAdminGCRequest(t, h, http.MethodGet, "/admin/gc")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var list struct {
+		Runs []gc.Run `json:"runs"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Runs) != 2 || list.Runs[0].ID != run.ID || list.Runs[1].ID != dry.ID {
+		t.Errorf("unexpected runs %+v", list.Runs)
+	}
+	for _, r := range list.Runs {
+		if r.Result != nil {
+			t.Errorf("expected run %s listed without result", r.ID)
+		}
+	}
+}
+
+func TestAdminGCDisabled(t *testing.T) {
+	h := testSetupTestServeMux(t)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/admin/gc", nil)
+	r.SetBasicAuth(testUser, testPwd)
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
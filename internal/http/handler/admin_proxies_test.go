@@ -0,0 +1,82 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/data/memory"
+	"github.com/jlsalvador/simple-registry/internal/data/proxy"
+	"github.com/jlsalvador/simple-registry/internal/http/handler"
+)
+
+func TestAdminProxies(t *testing.T) {
+	cfg, err := config.New(
+		config.WithAdminName(testUser),
+		config.WithAdminPwd([]byte(testPwd)),
+		config.WithDataDir("mem://"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := proxy.NewProxyDataStorage(memory.NewMemoryDataStorage(0), []proxy.Proxy{{
+		Name:    "docker-io",
+		Url:     "https://registry-1.docker.io",
+		Mirrors: []proxy.Mirror{{Url: "https://mirror.gcr.io", Priority: 10}},
+	}})
+	h := handler.NewHandler(*cfg, handler.WithProxy(p))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/proxies", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/admin/proxies", nil)
+	r.SetBasicAuth(testUser, testPwd)
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var response struct {
+		Upstreams []proxy.UpstreamStatus `json:"upstreams"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Upstreams) != 2 ||
+		response.Upstreams[0].Url != "https://registry-1.docker.io" ||
+		response.Upstreams[1].Url != "https://mirror.gcr.io" ||
+		response.Upstreams[1].Proxy != "docker-io" {
+		t.Errorf("unexpected upstreams %+v", response.Upstreams)
+	}
+}
+
+func TestAdminProxiesDisabled(t *testing.T) {
+	h := testSetupTestServeMux(t)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/admin/proxies", nil)
+	r.SetBasicAuth(testUser, testPwd)
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
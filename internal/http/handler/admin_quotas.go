@@ -0,0 +1,62 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"encoding/json"
+	netHttp "net/http"
+
+	"github.com/jlsalvador/simple-registry/internal/data/quota"
+)
+
+// AdminQuotasList returns the configured quotas with their current usage.
+//
+// # Route pattern:
+//
+//	"GET /admin/quotas"
+//
+// # HTTP status codes:
+//   - 200 OK
+//   - 401 Unauthorized
+//   - 403 Forbidden
+//   - 500 Internal Server Error
+func (m *ServeMux) AdminQuotasList(
+	w netHttp.ResponseWriter,
+	r *netHttp.Request,
+) {
+	if !m.IsRequestAllowed(r, "quotas", "", netHttp.MethodGet) {
+		ChallengeRequest(w, r)
+		return
+	}
+
+	usages := []quota.Usage{}
+	if q, ok := m.cfg.Data.(*quota.QuotaDataStorage); ok {
+		var err error
+		usages, err = q.Usage()
+		if err != nil {
+			LogError(err)
+			w.WriteHeader(netHttp.StatusInternalServerError)
+			return
+		}
+	}
+
+	response := map[string]any{
+		"quotas": usages,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(netHttp.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
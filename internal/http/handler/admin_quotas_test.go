@@ -0,0 +1,98 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/data/quota"
+	"github.com/jlsalvador/simple-registry/internal/http/handler"
+)
+
+func TestAdminQuotas(t *testing.T) {
+	cfg, err := config.New(
+		config.WithAdminName(testUser),
+		config.WithAdminPwd([]byte(testPwd)),
+		config.WithDataDir("mem://"),
+		config.WithQuotas([]quota.Quota{{
+			Name:     "team",
+			Scopes:   []regexp.Regexp{*regexp.MustCompile("^team/.+$")},
+			MaxBytes: 10,
+		}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handler.NewHandler(*cfg)
+
+	blob := []byte("more than ten bytes")
+	sum := sha256.Sum256(blob)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/v2/team/app/blobs/uploads/?digest="+digest, bytes.NewReader(blob))
+	r.SetBasicAuth(testUser, testPwd)
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+	var ociErr handler.ErrorOCI
+	if err := json.NewDecoder(w.Body).Decode(&ociErr); err != nil {
+		t.Fatal(err)
+	}
+	if ociErr != handler.ErrorQuotaExceeded {
+		t.Errorf("expected %v, got %v", handler.ErrorQuotaExceeded, ociErr)
+	}
+
+	// Other repositories are not limited.
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/v2/other/blobs/uploads/?digest="+digest, bytes.NewReader(blob))
+	r.SetBasicAuth(testUser, testPwd)
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/quotas", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/admin/quotas", nil)
+	r.SetBasicAuth(testUser, testPwd)
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var response struct {
+		Quotas []quota.Usage `json:"quotas"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Quotas) != 1 || response.Quotas[0].Name != "team" || response.Quotas[0].MaxBytes != 10 {
+		t.Errorf("unexpected quotas %+v", response.Quotas)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"encoding/json"
+	netHttp "net/http"
+)
+
+// AdminReplicationList returns the replication status of every downstream
+// registry.
+//
+// # Route pattern:
+//
+//	"GET /admin/replication"
+//
+// # HTTP status codes:
+//   - 200 OK
+//   - 401 Unauthorized
+//   - 403 Forbidden
+func (m *ServeMux) AdminReplicationList(
+	w netHttp.ResponseWriter,
+	r *netHttp.Request,
+) {
+	if !m.IsRequestAllowed(r, "replication", "", netHttp.MethodGet) {
+		ChallengeRequest(w, r)
+		return
+	}
+
+	response := map[string]any{
+		"targets": m.replicator.Status(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(netHttp.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
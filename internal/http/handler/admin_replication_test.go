@@ -0,0 +1,96 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/http/handler"
+	"github.com/jlsalvador/simple-registry/internal/replication"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+func TestAdminReplication(t *testing.T) {
+	cfg, err := config.New(
+		config.WithAdminName(testUser),
+		config.WithAdminPwd([]byte(testPwd)),
+		config.WithDataDir("mem://"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replicator, err := replication.NewReplicator(cfg.Data, []replication.Target{{
+		Name:   "site-b",
+		Url:    "http://registry-b.example.com",
+		Scopes: []regexp.Regexp{*regexp.MustCompile("^team/.+$")},
+	}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handler.NewHandler(*cfg, handler.WithReplicator(replicator))
+
+	// Pushed manifests are enqueued, the replicator is not running.
+	for _, repo := range []string{"team/app", "other/app"} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPut, "/v2/"+repo+"/manifests/v1", bytes.NewBufferString(`{"schemaVersion":2}`))
+		r.SetBasicAuth(testUser, testPwd)
+		r.Header.Set("Content-Type", registry.MediaTypeOCIImageManifest)
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/replication", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/admin/replication", nil)
+	r.SetBasicAuth(testUser, testPwd)
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var response struct {
+		Targets []replication.Status `json:"targets"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Targets) != 1 || response.Targets[0].Name != "site-b" || response.Targets[0].Pending != 1 {
+		t.Errorf("unexpected targets %+v", response.Targets)
+	}
+}
+
+func TestAdminReplicationDisabled(t *testing.T) {
+	h := testSetupTestServeMux(t)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/admin/replication", nil)
+	r.SetBasicAuth(testUser, testPwd)
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
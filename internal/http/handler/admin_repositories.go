@@ -0,0 +1,334 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	netHttp "net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/internal/gc"
+	"github.com/jlsalvador/simple-registry/internal/storageops"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+// AdminRepositoriesDelete deletes a repository with its tags, manifests,
+// referrers, layer links and upload sessions.
+//
+// Query parameter "gc" (defaults to false) garbage collects right away the
+// blobs only referenced by the repository, which are returned.
+//
+// # Route pattern:
+//
+//	"DELETE /admin/repositories/<name>"
+//
+// # HTTP status codes:
+//   - 202 Accepted
+//   - 400 Bad Request  - Invalid repository name or query parameters.
+//   - 401 Unauthorized
+//   - 403 Forbidden
+//   - 404 Not Found    - The repository does not exist.
+//   - 409 Conflict     - The repository has immutable tags, or "gc" is set
+//     while a garbage collection is running.
+//   - 500 Internal Server Error
+func (m *ServeMux) AdminRepositoriesDelete(
+	w netHttp.ResponseWriter,
+	r *netHttp.Request,
+) {
+	// "repo" must be a valid repository name.
+	repo := r.PathValue("name")
+	if !registry.RegExprName.MatchString(repo) {
+		w.WriteHeader(netHttp.StatusBadRequest)
+		return
+	}
+
+	if !m.IsRequestAllowed(r, "repositories", repo, netHttp.MethodDelete) {
+		ChallengeRequest(w, r)
+		return
+	}
+
+	collect := false
+	if v := r.URL.Query().Get("gc"); v != "" {
+		var err error
+		if collect, err = strconv.ParseBool(v); err != nil {
+			w.WriteHeader(netHttp.StatusBadRequest)
+			return
+		}
+	}
+
+	deleted, err := storageops.DeleteRepository(m.cfg, m.collector, repo, collect)
+	if err != nil {
+		if errors.Is(err, data.ErrRepoInvalid) {
+			w.WriteHeader(netHttp.StatusBadRequest)
+			return
+		}
+
+		if errors.Is(err, fs.ErrNotExist) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(netHttp.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorNameUnknown)
+			return
+		}
+
+		if errors.Is(err, data.ErrTagImmutable) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(netHttp.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorTagImmutable)
+			return
+		}
+
+		if errors.Is(err, gc.ErrRunning) {
+			w.WriteHeader(netHttp.StatusConflict)
+			return
+		}
+
+		LogError(err)
+		w.WriteHeader(netHttp.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]any{
+		"repository":   repo,
+		"deletedBlobs": slices.Sorted(maps.Keys(deleted)),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(netHttp.StatusAccepted)
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseImageReference splits "<name>:<tag>" or "<name>@<digest>".
+func parseImageReference(s string) (repo, reference string, ok bool) {
+	if repo, reference, ok = strings.Cut(s, "@"); ok {
+		ok = registry.RegExprDigest.MatchString(reference)
+	} else if i := strings.LastIndex(s, ":"); i >= 0 {
+		repo, reference = s[:i], s[i+1:]
+		ok = registry.RegExprTag.MatchString(reference)
+	}
+	return repo, reference, ok && registry.RegExprName.MatchString(repo)
+}
+
+// writeCopyError writes the response of a failed copy or rename.
+func writeCopyError(w netHttp.ResponseWriter, err error) {
+	if errors.Is(err, fs.ErrNotExist) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(netHttp.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorManifestUnknown)
+		return
+	}
+
+	if errors.Is(err, data.ErrQuotaExceeded) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(netHttp.StatusForbidden)
+		json.NewEncoder(w).Encode(ErrorQuotaExceeded)
+		return
+	}
+
+	if errors.Is(err, data.ErrTagImmutable) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(netHttp.StatusConflict)
+		json.NewEncoder(w).Encode(ErrorTagImmutable)
+		return
+	}
+
+	LogError(err)
+	w.WriteHeader(netHttp.StatusInternalServerError)
+}
+
+// AdminRepositoriesCopy copies a manifest, with the manifests and blobs it
+// references and its referrers, from another repository by linking them.
+//
+// Query parameter "from" is the source, like "<name>:<tag>" or
+// "<name>@<digest>". Query parameter "tag" is the tag to set in the
+// repository, which defaults to the source tag, if any.
+//
+// # Route pattern:
+//
+//	"POST /admin/repositories/<name>/copy"
+//
+// # HTTP status codes:
+//   - 201 Created      - See the Location and Docker-Content-Digest headers.
+//   - 400 Bad Request  - Invalid repository name or query parameters.
+//   - 401 Unauthorized
+//   - 403 Forbidden    - The repository quota is exceeded.
+//   - 404 Not Found    - The source manifest, or its content, does not exist.
+//   - 409 Conflict     - The tag is immutable.
+//   - 500 Internal Server Error
+func (m *ServeMux) AdminRepositoriesCopy(
+	w netHttp.ResponseWriter,
+	r *netHttp.Request,
+) {
+	// "dst" must be a valid repository name.
+	dst := r.PathValue("name")
+	if !registry.RegExprName.MatchString(dst) {
+		w.WriteHeader(netHttp.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+
+	src, reference, ok := parseImageReference(query.Get("from"))
+	if !ok {
+		w.WriteHeader(netHttp.StatusBadRequest)
+		return
+	}
+	rbacSrc := src
+	tag := query.Get("tag")
+	if !registry.RegExprDigest.MatchString(reference) {
+		rbacSrc += ":" + reference
+		if tag == "" {
+			tag = reference
+		}
+	}
+	rbacDst := dst
+	if tag != "" {
+		if !registry.RegExprTag.MatchString(tag) {
+			w.WriteHeader(netHttp.StatusBadRequest)
+			return
+		}
+		rbacDst += ":" + tag
+	}
+
+	// Check if the user can pull from the source, and push to the
+	// destination.
+	if !m.IsRequestAllowed(r, "manifests", rbacSrc, netHttp.MethodGet) ||
+		!m.IsRequestAllowed(r, "manifests", rbacDst, netHttp.MethodPut) {
+		ChallengeRequest(w, r)
+		return
+	}
+
+	digest, err := storageops.CopyManifest(m.cfg, src, reference, dst, tag)
+	if err != nil {
+		writeCopyError(w, err)
+		return
+	}
+
+	target := digest
+	if tag != "" {
+		target = tag
+	}
+
+	// Replicate the manifest to the downstream registries, if any.
+	if m.replicator != nil {
+		if err := m.replicator.Enqueue(dst, target, digest); err != nil {
+			LogError(err)
+		}
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/v2/%s/manifests/%s", dst, target))
+	w.Header().Set("Docker-Content-Digest", digest)
+	w.WriteHeader(netHttp.StatusCreated)
+}
+
+// AdminRepositoriesRename moves every manifest and tag of a repository to
+// another one, which must not exist, by linking their blobs. Then the
+// repository is deleted. If the copy fails, the new repository is deleted.
+//
+// Query parameter "to" is the new repository name.
+//
+// # Route pattern:
+//
+//	"POST /admin/repositories/<name>/rename"
+//
+// # HTTP status codes:
+//   - 201 Created      - See the Location header.
+//   - 400 Bad Request  - Invalid repository names.
+//   - 401 Unauthorized
+//   - 403 Forbidden    - The repository quota is exceeded.
+//   - 404 Not Found    - The repository does not exist.
+//   - 409 Conflict     - The new repository exists, or a tag is immutable.
+//   - 500 Internal Server Error
+func (m *ServeMux) AdminRepositoriesRename(
+	w netHttp.ResponseWriter,
+	r *netHttp.Request,
+) {
+	src := r.PathValue("name")
+	dst := r.URL.Query().Get("to")
+	if !registry.RegExprName.MatchString(src) || !registry.RegExprName.MatchString(dst) || src == dst {
+		w.WriteHeader(netHttp.StatusBadRequest)
+		return
+	}
+
+	tags, err := gc.Local(m.cfg.Data).TagsList(src)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		LogError(err)
+		w.WriteHeader(netHttp.StatusInternalServerError)
+		return
+	}
+
+	// Check if the user can pull and delete the repository, and push every
+	// tag to the new one.
+	allowed := m.IsRequestAllowed(r, "manifests", src, netHttp.MethodGet) &&
+		m.IsRequestAllowed(r, "repositories", src, netHttp.MethodDelete) &&
+		m.IsRequestAllowed(r, "manifests", dst, netHttp.MethodPut)
+	for _, tag := range tags {
+		allowed = allowed &&
+			m.IsRequestAllowed(r, "manifests", src+":"+tag, netHttp.MethodGet) &&
+			m.IsRequestAllowed(r, "manifests", dst+":"+tag, netHttp.MethodPut)
+	}
+	if !allowed {
+		ChallengeRequest(w, r)
+		return
+	}
+
+	manifests, tagDigests, err := storageops.RenameRepository(m.cfg, src, dst)
+	if err != nil {
+		if errors.Is(err, storageops.ErrRepoUnknown) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(netHttp.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorNameUnknown)
+			return
+		}
+
+		if errors.Is(err, storageops.ErrRepoExists) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(netHttp.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorNameExists)
+			return
+		}
+
+		writeCopyError(w, err)
+		return
+	}
+
+	// Replicate the tags to the downstream registries, if any.
+	// Tags pushed while renaming are moved too.
+	tags = slices.Sorted(maps.Keys(tagDigests))
+	if m.replicator != nil {
+		for _, tag := range tags {
+			if err := m.replicator.Enqueue(dst, tag, tagDigests[tag]); err != nil {
+				LogError(err)
+			}
+		}
+	}
+
+	response := map[string]any{
+		"repository": dst,
+		"manifests":  manifests,
+		"tags":       tags,
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/v2/%s/tags/list", dst))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(netHttp.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,272 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/data/quota"
+	"github.com/jlsalvador/simple-registry/internal/http/handler"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+// testSetupAdminRepositories returns a handler with an image pushed as
+// "<repo>:<tag>", a function to send requests as the admin, and the digest of
+// its layer.
+func testSetupAdminRepositories(t *testing.T, repo, tag string, opts ...config.Option) (
+	h http.Handler,
+	request func(method, target string, body []byte) *httptest.ResponseRecorder,
+	layer string,
+) {
+	t.Helper()
+
+	cfg, err := config.New(append([]config.Option{
+		config.WithAdminName(testUser),
+		config.WithAdminPwd([]byte(testPwd)),
+		config.WithDataDir("mem://"),
+	}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h = handler.NewHandler(*cfg)
+
+	request = func(method, target string, body []byte) *httptest.ResponseRecorder {
+		t.Helper()
+
+		r := httptest.NewRequest(method, target, bytes.NewReader(body))
+		r.SetBasicAuth(testUser, testPwd)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	blob := []byte("layer")
+	sum := sha256.Sum256(blob)
+	layer = "sha256:" + hex.EncodeToString(sum[:])
+	if w := request(http.MethodPost, "/v2/"+repo+"/blobs/uploads/?digest="+layer, blob); w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	manifest, err := json.Marshal(registry.ImageManifest{
+		SchemaVersion: 2,
+		MediaType:     registry.MediaTypeOCIImageManifest,
+		Layers: []registry.DescriptorManifest{{
+			MediaType: "application/vnd.oci.image.layer.v1.tar",
+			Digest:    layer,
+			Size:      int64(len(blob)),
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := request(http.MethodPut, "/v2/"+repo+"/manifests/"+tag, manifest); w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	return h, request, layer
+}
+
+func TestAdminRepositoriesDelete(t *testing.T) {
+	h, request, layer := testSetupAdminRepositories(t, "team/app", "latest")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/admin/repositories/team/app", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	if w := request(http.MethodDelete, "/admin/repositories/team/app?gc=invalid", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	w = request(http.MethodDelete, "/admin/repositories/team/app?gc=true", nil)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+	var response struct {
+		Repository   string   `json:"repository"`
+		DeletedBlobs []string `json:"deletedBlobs"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Repository != "team/app" || !slices.Contains(response.DeletedBlobs, layer) {
+		t.Errorf("unexpected response %+v", response)
+	}
+
+	w = request(http.MethodGet, "/v2/_catalog", nil)
+	var catalog struct {
+		Repositories []string `json:"repositories"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&catalog); err != nil {
+		t.Fatal(err)
+	}
+	if len(catalog.Repositories) != 0 {
+		t.Errorf("expected empty catalog, got %v", catalog.Repositories)
+	}
+
+	if w := request(http.MethodGet, "/v2/team/app/blobs/"+layer, nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w = request(http.MethodDelete, "/admin/repositories/team/app", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+	var ociErr handler.ErrorOCI
+	if err := json.NewDecoder(w.Body).Decode(&ociErr); err != nil {
+		t.Fatal(err)
+	}
+	if ociErr != handler.ErrorNameUnknown {
+		t.Errorf("expected %v, got %v", handler.ErrorNameUnknown, ociErr)
+	}
+}
+
+func TestAdminRepositoriesCopy(t *testing.T) {
+	h, request, layer := testSetupAdminRepositories(t, "staging/app", "v1")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/repositories/prod/app/copy?from=staging/app:v1", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	for _, from := range []string{"", "staging/app", "staging/app@invalid", "Staging:v1"} {
+		if w := request(http.MethodPost, "/admin/repositories/prod/app/copy?from="+from, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected status %d, got %d", from, http.StatusBadRequest, w.Code)
+		}
+	}
+
+	if w := request(http.MethodPost, "/admin/repositories/prod/app/copy?from=staging/app:missing", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w = request(http.MethodPost, "/admin/repositories/prod/app/copy?from=staging/app:v1", nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	if location := w.Header().Get("Location"); location != "/v2/prod/app/manifests/v1" {
+		t.Errorf("expected location /v2/prod/app/manifests/v1, got %s", location)
+	}
+	digest := w.Header().Get("Docker-Content-Digest")
+
+	if w := request(http.MethodGet, "/v2/prod/app/manifests/v1", nil); w.Code != http.StatusOK || w.Header().Get("Docker-Content-Digest") != digest {
+		t.Errorf("expected status %d with digest %s, got %d", http.StatusOK, digest, w.Code)
+	}
+	if w := request(http.MethodGet, "/v2/prod/app/blobs/"+layer, nil); w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	// Copy by digest, with another tag.
+	w = request(http.MethodPost, "/admin/repositories/prod/other/copy?from=staging/app@"+digest+"&tag=stable", nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	if w := request(http.MethodGet, "/v2/prod/other/manifests/stable", nil); w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestAdminRepositoriesRename(t *testing.T) {
+	h, request, layer := testSetupAdminRepositories(t, "staging/app", "v1")
+	if w := request(http.MethodPost, "/admin/repositories/taken/app/copy?from=staging/app:v1", nil); w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/repositories/staging/app/rename?to=prod/app", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	if w := request(http.MethodPost, "/admin/repositories/staging/app/rename?to=staging/app", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := request(http.MethodPost, "/admin/repositories/missing/rename?to=prod/app", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := request(http.MethodPost, "/admin/repositories/staging/app/rename?to=taken/app", nil); w.Code != http.StatusConflict {
+		t.Errorf("expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+
+	w = request(http.MethodPost, "/admin/repositories/staging/app/rename?to=prod/app", nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	if w := request(http.MethodGet, "/v2/prod/app/manifests/v1", nil); w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if w := request(http.MethodGet, "/v2/prod/app/blobs/"+layer, nil); w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if w := request(http.MethodGet, "/v2/staging/app/manifests/v1", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w = request(http.MethodGet, "/v2/_catalog", nil)
+	var catalog struct {
+		Repositories []string `json:"repositories"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&catalog); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"prod/app", "taken/app"}; !slices.Equal(catalog.Repositories, want) {
+		t.Errorf("expected %v, got %v", want, catalog.Repositories)
+	}
+}
+
+func TestAdminRepositoriesRename_CopyFails(t *testing.T) {
+	_, request, layer := testSetupAdminRepositories(t, "staging/app", "v1",
+		config.WithQuotas([]quota.Quota{{
+			Name:     "full",
+			Scopes:   []regexp.Regexp{*regexp.MustCompile("^full/.+$")},
+			MaxBytes: 1,
+		}}),
+	)
+
+	w := request(http.MethodPost, "/admin/repositories/staging/app/rename?to=full/app", nil)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+
+	// The source is kept, and what was copied is deleted.
+	if w := request(http.MethodGet, "/v2/staging/app/manifests/v1", nil); w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if w := request(http.MethodGet, "/v2/full/app/blobs/"+layer, nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w = request(http.MethodGet, "/v2/_catalog", nil)
+	var catalog struct {
+		Repositories []string `json:"repositories"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&catalog); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"staging/app"}; !slices.Equal(catalog.Repositories, want) {
+		t.Errorf("expected %v, got %v", want, catalog.Repositories)
+	}
+}
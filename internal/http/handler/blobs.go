@@ -79,7 +79,10 @@ func (m *ServeMux) BlobsGet(
 	defer blob.Close()
 
 	w.Header().Set("Docker-Content-Digest", digest)
-	w.Header().Set("Content-Length", fmt.Sprintf("%d", size))
+	// A blob streamed from an upstream could be of unknown size.
+	if size >= 0 {
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", size))
+	}
 	w.WriteHeader(netHttp.StatusOK)
 	_, _ = io.Copy(w, blob)
 }
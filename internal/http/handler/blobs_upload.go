@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"io/fs"
 	netHttp "net/http"
+	"slices"
+	"time"
 
 	"github.com/jlsalvador/simple-registry/internal/config"
 	"github.com/jlsalvador/simple-registry/internal/data"
@@ -27,11 +29,36 @@ import (
 	"github.com/jlsalvador/simple-registry/pkg/registry"
 )
 
+// uploadExpired returns if the upload session was started more than
+// [config.Uploads.MaxAge] ago. Expired sessions are deleted right away, as the
+// uploads janitor would do later.
+//
+// Unknown sessions are not expired, so they are reported by the caller.
+func (m *ServeMux) uploadExpired(repo, uuid string) bool {
+	maxAge := m.cfg.Uploads.MaxAge
+	if maxAge <= 0 {
+		return false
+	}
+
+	startedAt, err := m.cfg.Data.BlobsUploadStartedAt(repo, uuid)
+	if err != nil || time.Since(startedAt) <= maxAge {
+		return false
+	}
+
+	if err := m.cfg.Data.BlobsUploadCancel(repo, uuid); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		LogError(err)
+	}
+	return true
+}
+
 // blobsUploadsPostMount mounts blob from other repository.
 // ACL must be already checked.
 //
 // `mount` must be a valid digest.
-// `from` could be empty.
+// `from` must be a repository linking the blob.
+//
+// A blob stored in `from` is linked, without copying its content. Otherwise,
+// like a blob of a pull-through cache, it is read from `from` and uploaded.
 func blobsUploadsPostMount(
 	cfg config.Config,
 	repo string,
@@ -39,6 +66,29 @@ func blobsUploadsPostMount(
 	mount string,
 	w netHttp.ResponseWriter,
 ) {
+	repos, err := cfg.Data.BlobRepositories(mount)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		LogError(err)
+		w.WriteHeader(netHttp.StatusInternalServerError)
+		return
+	}
+	if slices.Contains(repos, from) {
+		err := cfg.Data.BlobsLink(repo, mount)
+		if err == nil {
+			location := fmt.Sprintf("/v2/%s/blobs/%s", repo, mount)
+			w.Header().Set("Location", location)
+			w.Header().Set("Docker-Content-Digest", mount)
+			w.WriteHeader(netHttp.StatusCreated)
+			return
+		}
+		// The blob could be deleted meanwhile, then it is read as any other.
+		if !errors.Is(err, fs.ErrNotExist) {
+			LogError(err)
+			w.WriteHeader(netHttp.StatusInternalServerError)
+			return
+		}
+	}
+
 	f, _, err := cfg.Data.BlobsGet(from, mount)
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
@@ -72,6 +122,13 @@ func blobsUploadsPostMount(
 	}
 
 	if err := cfg.Data.BlobsUploadCommit(repo, uuid, mount); err != nil {
+		if errors.Is(err, data.ErrQuotaExceeded) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(netHttp.StatusForbidden)
+			json.NewEncoder(w).Encode(ErrorQuotaExceeded)
+			return
+		}
+
 		LogError(err)
 		w.WriteHeader(netHttp.StatusInternalServerError)
 		return
@@ -116,6 +173,13 @@ func blobsUploadsPostSingle(
 			return
 		}
 
+		if errors.Is(err, data.ErrQuotaExceeded) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(netHttp.StatusForbidden)
+			json.NewEncoder(w).Encode(ErrorQuotaExceeded)
+			return
+		}
+
 		LogError(err)
 		w.WriteHeader(netHttp.StatusInternalServerError)
 		return
@@ -213,6 +277,33 @@ func (m *ServeMux) BlobsUploadsPost(
 	}
 	// Check if the user can pull the other repository.
 	// `from` could be empty if automatic content discovery is enabled.
+	if mount != "" && from == "" {
+		// Automatic content discovery: mount the blob from the first
+		// repository linking it that the user can pull.
+		repos, err := m.cfg.Data.BlobRepositories(mount)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			LogError(err)
+			w.WriteHeader(netHttp.StatusInternalServerError)
+			return
+		}
+		for _, other := range repos {
+			if m.IsRequestAllowed(r, "blobs", other, netHttp.MethodGet) {
+				from = other
+				break
+			}
+		}
+
+		if from == "" {
+			// The blob is unknown, or the user cannot pull it from any
+			// repository, so fallback to a regular upload session.
+			blobsUploadsPostThenPut(
+				m.cfg,
+				repo,
+				w,
+			)
+			return
+		}
+	}
 	if mount != "" {
 		if !m.IsRequestAllowed(r, "blobs", from, netHttp.MethodGet) {
 			w.Header().Set("Content-Type", "application/json")
@@ -287,8 +378,22 @@ func (m *ServeMux) BlobsUploadsGet(
 		return
 	}
 
+	if m.uploadExpired(repo, uuid) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(netHttp.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorBlobUploadUnknown)
+		return
+	}
+
 	size, err := m.cfg.Data.BlobsUploadSize(repo, uuid)
 	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(netHttp.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorBlobUploadUnknown)
+			return
+		}
+
 		LogError(err)
 		w.WriteHeader(netHttp.StatusInternalServerError)
 		return
@@ -359,6 +464,13 @@ func (m *ServeMux) BlobsUploadsPatch(
 		return
 	}
 
+	if m.uploadExpired(repo, uuid) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(netHttp.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorBlobUploadUnknown)
+		return
+	}
+
 	size, err := m.cfg.Data.BlobsUploadSize(repo, uuid)
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
@@ -469,6 +581,13 @@ func (m *ServeMux) BlobsUploadsPut(
 		return
 	}
 
+	if m.uploadExpired(repo, uuid) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(netHttp.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorBlobUploadUnknown)
+		return
+	}
+
 	if r.Header.Get("Content-Type") == "application/octet-stream" && r.Header.Get("Content-Length") != "" {
 		// Optionally, PUT can upload the last blob chunk data.
 
@@ -510,6 +629,13 @@ func (m *ServeMux) BlobsUploadsPut(
 			return
 		}
 
+		if errors.Is(err, data.ErrQuotaExceeded) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(netHttp.StatusForbidden)
+			json.NewEncoder(w).Encode(ErrorQuotaExceeded)
+			return
+		}
+
 		LogError(err)
 		w.WriteHeader(netHttp.StatusInternalServerError)
 		return
@@ -16,13 +16,17 @@ package handler_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"maps"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/http/handler"
 	"github.com/jlsalvador/simple-registry/pkg/digest"
 )
 
@@ -342,6 +346,66 @@ func TestBlobsUploads(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "successful mount without from",
+			requests: []testRequestBuilder{
+				successUUID,
+				{
+					func(prev *http.Response) *http.Request {
+						uuid := prev.Header.Get(testHeaderDockerUploadUUID)
+						url := fmt.Sprintf("/v2/myrepo/myimage/blobs/uploads/%s", uuid)
+						return newReq(
+							http.MethodPut,
+							url,
+							map[string]string{"Authorization": testAuthHeader},
+							map[string]string{"digest": "sha256:" + testBlobDigest},
+							testBlob,
+						)(prev)
+					},
+					http.StatusCreated,
+				},
+				{
+					newReq(
+						http.MethodPost,
+						"/v2/anotherrepo/otherimage/blobs/uploads/",
+						map[string]string{"Authorization": testAuthHeader},
+						map[string]string{"mount": "sha256:" + testBlobDigest},
+						nil,
+					),
+					http.StatusCreated,
+				},
+			},
+		},
+		{
+			name: "mount without from nor permissions",
+			requests: []testRequestBuilder{
+				successUUID,
+				{
+					func(prev *http.Response) *http.Request {
+						uuid := prev.Header.Get(testHeaderDockerUploadUUID)
+						url := fmt.Sprintf("/v2/myrepo/myimage/blobs/uploads/%s", uuid)
+						return newReq(
+							http.MethodPut,
+							url,
+							map[string]string{"Authorization": testAuthHeader},
+							map[string]string{"digest": "sha256:" + testBlobDigest},
+							testBlob,
+						)(prev)
+					},
+					http.StatusCreated,
+				},
+				{
+					newReq(
+						http.MethodPost,
+						"/v2/public/from_myrepo_myimage/blobs/uploads/",
+						nil,
+						map[string]string{"mount": "sha256:" + testBlobDigest},
+						nil,
+					),
+					http.StatusAccepted,
+				},
+			},
+		},
 		{
 			name: "mount not found",
 			requests: []testRequestBuilder{
@@ -709,3 +773,105 @@ func TestBlobsUploads(t *testing.T) {
 		})
 	}
 }
+
+func TestBlobsUploads_ResumeAfterRestart(t *testing.T) {
+	dataDir := t.TempDir()
+	newHandler := func() http.Handler {
+		cfg, err := config.New(
+			config.WithAdminName(testUser),
+			config.WithAdminPwd([]byte(testPwd)),
+			config.WithDataDir(dataDir),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return handler.NewHandler(*cfg)
+	}
+
+	h := newHandler()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/v2/myrepo/blobs/uploads/", nil)
+	r.SetBasicAuth(testUser, testPwd)
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+	location := w.Header().Get("Location")
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPatch, location, bytes.NewReader(testBlob[:5]))
+	r.SetBasicAuth(testUser, testPwd)
+	r.Header.Set("Content-Type", "application/octet-stream")
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+
+	// Restart the server, with the same data directory.
+	h = newHandler()
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, location, nil)
+	r.SetBasicAuth(testUser, testPwd)
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	if got := w.Header().Get("Range"); got != "0-4" {
+		t.Errorf("expected range 0-4, got %s", got)
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPut, location+"?digest=sha256:"+testBlobDigest, bytes.NewReader(testBlob[5:]))
+	r.SetBasicAuth(testUser, testPwd)
+	r.Header.Set("Content-Type", "application/octet-stream")
+	r.Header.Set("Content-Length", fmt.Sprint(len(testBlob[5:])))
+	r.Header.Set("Content-Range", fmt.Sprintf("5-%d", len(testBlob)-1))
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+}
+
+func TestBlobsUploads_Expired(t *testing.T) {
+	cfg, err := config.New(
+		config.WithAdminName(testUser),
+		config.WithAdminPwd([]byte(testPwd)),
+		config.WithDataDir("mem://"),
+		config.WithUploadsMaxAge(time.Millisecond),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handler.NewHandler(*cfg)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/v2/myrepo/blobs/uploads/", nil)
+	r.SetBasicAuth(testUser, testPwd)
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+	location := w.Header().Get("Location")
+
+	time.Sleep(10 * time.Millisecond)
+
+	for _, method := range []string{http.MethodGet, http.MethodPatch, http.MethodPut} {
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(method, location+"?digest=sha256:"+testBlobDigest, bytes.NewReader(testBlob))
+		r.SetBasicAuth(testUser, testPwd)
+		r.Header.Set("Content-Type", "application/octet-stream")
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("%s: expected status %d, got %d", method, http.StatusNotFound, w.Code)
+		}
+		var ociErr handler.ErrorOCI
+		if err := json.NewDecoder(w.Body).Decode(&ociErr); err != nil {
+			t.Fatal(err)
+		}
+		if ociErr != handler.ErrorBlobUploadUnknown {
+			t.Errorf("%s: expected %v, got %v", method, handler.ErrorBlobUploadUnknown, ociErr)
+		}
+	}
+}
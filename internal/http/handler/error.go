@@ -43,6 +43,9 @@ var (
 	ErrorDenied              = ErrorOCI{"DENIED", "requested access to the resource is denied"}
 	ErrorUnsupported         = ErrorOCI{"UNSUPPORTED", "the operation is unsupported"}
 	ErrorTooManyRequests     = ErrorOCI{"TOOMANYREQUESTS", "too many requests"}
+	ErrorQuotaExceeded       = ErrorOCI{"DENIED", "repository quota exceeded"}
+	ErrorTagImmutable        = ErrorOCI{"DENIED", "tag is immutable"}
+	ErrorNameExists          = ErrorOCI{"DENIED", "repository already exists"}
 )
 
 func LogError(err error) {
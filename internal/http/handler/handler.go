@@ -22,6 +22,9 @@ import (
 	"strings"
 
 	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/data/proxy"
+	"github.com/jlsalvador/simple-registry/internal/gc"
+	"github.com/jlsalvador/simple-registry/internal/replication"
 	"github.com/jlsalvador/simple-registry/pkg/http/log"
 	"github.com/jlsalvador/simple-registry/pkg/http/route"
 	"github.com/jlsalvador/simple-registry/pkg/mapset"
@@ -31,6 +34,38 @@ import (
 type ServeMux struct {
 	cfg config.Config
 	mux *http.ServeMux
+
+	collector  *gc.Collector
+	replicator *replication.Replicator
+	proxy      *proxy.ProxyDataStorage
+}
+
+// Option configures optional features of the HTTP handler.
+type Option func(*ServeMux)
+
+// WithGarbageCollector enables the "/admin/gc" endpoints, which start and
+// inspect garbage collection runs through the given collector.
+func WithGarbageCollector(c *gc.Collector) Option {
+	return func(m *ServeMux) {
+		m.collector = c
+	}
+}
+
+// WithReplicator enables the push replication of the manifests to the
+// downstream registries of the given replicator, and the "/admin/replication"
+// endpoint to inspect it.
+func WithReplicator(r *replication.Replicator) Option {
+	return func(m *ServeMux) {
+		m.replicator = r
+	}
+}
+
+// WithProxy enables the "/admin/proxies" endpoint, which inspects the health
+// of the upstreams of the given pull-through cache.
+func WithProxy(p *proxy.ProxyDataStorage) Option {
+	return func(m *ServeMux) {
+		m.proxy = p
+	}
 }
 
 // IsValidAuth returns if the request is authenticated.
@@ -180,6 +215,78 @@ func (m *ServeMux) registerRoutes() {
 			"^/token/?$",
 			m.Token,
 		),
+
+		// Admin:
+		route.NewRoute(
+			http.MethodGet,
+			"^/admin/quotas/?$",
+			m.AdminQuotasList,
+		),
+		route.NewRoute(
+			http.MethodGet,
+			"^/admin/du/?$",
+			m.AdminDu,
+		),
+		route.NewRoute(
+			http.MethodDelete,
+			"^/admin/repositories/(?P<name>"+exprName+")/?$",
+			m.AdminRepositoriesDelete,
+		),
+		route.NewRoute(
+			http.MethodPost,
+			"^/admin/repositories/(?P<name>"+exprName+")/copy/?$",
+			m.AdminRepositoriesCopy,
+		),
+		route.NewRoute(
+			http.MethodPost,
+			"^/admin/repositories/(?P<name>"+exprName+")/rename/?$",
+			m.AdminRepositoriesRename,
+		),
+	}
+
+	if m.collector != nil {
+		routes = append(routes,
+			route.NewRoute(
+				http.MethodGet,
+				"^/admin/gc/?$",
+				m.AdminGCList,
+			),
+			route.NewRoute(
+				http.MethodPost,
+				"^/admin/gc/?$",
+				m.AdminGCStart,
+			),
+			route.NewRoute(
+				http.MethodGet,
+				"^/admin/gc/(?P<id>"+exprUUID+")/?$",
+				m.AdminGCGet,
+			),
+			route.NewRoute(
+				http.MethodPost,
+				"^/admin/gc/(?P<id>"+exprUUID+")/confirm/?$",
+				m.AdminGCConfirm,
+			),
+		)
+	}
+
+	if m.replicator != nil {
+		routes = append(routes,
+			route.NewRoute(
+				http.MethodGet,
+				"^/admin/replication/?$",
+				m.AdminReplicationList,
+			),
+		)
+	}
+
+	if m.proxy != nil {
+		routes = append(routes,
+			route.NewRoute(
+				http.MethodGet,
+				"^/admin/proxies/?$",
+				m.AdminProxiesList,
+			),
+		)
 	}
 
 	if m.cfg.Web.UI {
@@ -237,11 +344,14 @@ func (m *ServeMux) registerRoutes() {
 // [Docker Registry API v2.0 specification].
 //
 // [Docker Registry API v2.0 specification]: https://github.com/opencontainers/distribution-spec/blob/v1.1.1/spec.md
-func NewHandler(cfg config.Config) http.Handler {
+func NewHandler(cfg config.Config, opts ...Option) http.Handler {
 	mux := &ServeMux{
 		cfg: cfg,
 		mux: http.NewServeMux(),
 	}
+	for _, opt := range opts {
+		opt(mux)
+	}
 	mux.registerRoutes()
 
 	return log.LoggingMiddleware(mux.mux)
@@ -60,7 +60,7 @@ func testSetupTestServeMux(t *testing.T) http.Handler {
 	cfg, err := config.New(
 		config.WithAdminName(testUser),
 		config.WithAdminPwd([]byte(testPwd)),
-		config.WithDataDir(t.TempDir()),
+		config.WithDataDir("mem://"),
 		config.WithHttpUI(true),
 	)
 	if err != nil {
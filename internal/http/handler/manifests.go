@@ -24,6 +24,9 @@ import (
 	netHttp "net/http"
 
 	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/internal/data/proxy"
+	"github.com/jlsalvador/simple-registry/internal/version"
+	"github.com/jlsalvador/simple-registry/pkg/log"
 	"github.com/jlsalvador/simple-registry/pkg/registry"
 )
 
@@ -51,7 +54,7 @@ func getContentType(manifest []byte) string {
 //   - {reference}	must be a digest or a tag name.
 //
 // # HTTP status codes:
-//   - 200 OK
+//   - 200 OK                 - With a "Warning" header if the manifest is stale.
 //   - 403 Forbidden
 //   - 404 Not Found
 //   - 401 Unauthorized
@@ -98,6 +101,20 @@ func (m *ServeMux) ManifestsGet(
 	}
 	defer blob.Close()
 
+	// The pull-through cache could not confirm the manifest is up to date.
+	if stale, ok := blob.(*proxy.StaleReadCloser); ok {
+		w.Header().Set("Warning", `110 - "Response is Stale"`)
+		log.Warn(
+			"service.name", version.AppName,
+			"service.version", version.AppVersion,
+			"event.dataset", "http.access",
+			"url.original", r.URL.String(),
+			"cache.stale", true,
+			"error.message", stale.Err.Error(),
+			"message", fmt.Sprintf("serving stale manifest %s:%s", repo, reference),
+		).Print()
+	}
+
 	manifest, err := io.ReadAll(blob)
 	if err != nil {
 		w.WriteHeader(netHttp.StatusInternalServerError)
@@ -128,7 +145,9 @@ func (m *ServeMux) ManifestsGet(
 //   - 201 Created
 //   - 400 Bad Request
 //   - 401 Unauthorized
+//   - 403 Forbidden          - The repository quota is exceeded.
 //   - 404 Not Found
+//   - 409 Conflict           - The tag is immutable.
 //   - 413 Payload Too Large
 //   - 500 Internal Server Error
 func (m *ServeMux) ManifestsPut(
@@ -170,10 +189,31 @@ func (m *ServeMux) ManifestsPut(
 			return
 		}
 
+		if errors.Is(err, data.ErrQuotaExceeded) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(netHttp.StatusForbidden)
+			json.NewEncoder(w).Encode(ErrorQuotaExceeded)
+			return
+		}
+
+		if errors.Is(err, data.ErrTagImmutable) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(netHttp.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorTagImmutable)
+			return
+		}
+
 		w.WriteHeader(netHttp.StatusInternalServerError)
 		return
 	}
 
+	// Replicate the manifest to the downstream registries, if any.
+	if m.replicator != nil {
+		if err := m.replicator.Enqueue(repo, reference, dgst); err != nil {
+			LogError(err)
+		}
+	}
+
 	// Re-read the just written manifest.
 	f, _, _, err := m.cfg.Data.ManifestGet(repo, reference)
 	if err != nil {
@@ -220,6 +260,7 @@ func (m *ServeMux) ManifestsPut(
 //   - 403 Forbidden
 //   - 404 Not Found
 //   - 405 Method Not Allowed
+//   - 409 Conflict           - The tag is immutable.
 func (m *ServeMux) ManifestsDelete(
 	w netHttp.ResponseWriter,
 	r *netHttp.Request,
@@ -261,6 +302,13 @@ func (m *ServeMux) ManifestsDelete(
 			return
 		}
 
+		if errors.Is(err, data.ErrTagImmutable) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(netHttp.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorTagImmutable)
+			return
+		}
+
 		w.WriteHeader(netHttp.StatusInternalServerError)
 		return
 	}
@@ -21,8 +21,13 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"testing"
 
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/data/immutable"
+	"github.com/jlsalvador/simple-registry/internal/data/proxy"
+	"github.com/jlsalvador/simple-registry/internal/http/handler"
 	"github.com/jlsalvador/simple-registry/pkg/digest"
 	"github.com/jlsalvador/simple-registry/pkg/registry"
 )
@@ -226,3 +231,85 @@ func TestManifests(t *testing.T) {
 		})
 	}
 }
+
+func TestManifestsImmutableTag(t *testing.T) {
+	cfg, err := config.New(
+		config.WithAdminName(testUser),
+		config.WithAdminPwd([]byte(testPwd)),
+		config.WithDataDir("mem://"),
+		config.WithImmutableTags([]immutable.Rule{{
+			Name:   "releases",
+			Scopes: []regexp.Regexp{*regexp.MustCompile("^.+$")},
+			Tags:   []regexp.Regexp{*regexp.MustCompile(`^v[0-9]+$`)},
+		}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handler.NewHandler(*cfg)
+
+	do := func(method string, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, "/v2/app/manifests/v1", bytes.NewBufferString(body))
+		r.SetBasicAuth(testUser, testPwd)
+		r.Header.Set("Content-Type", registry.MediaTypeOCIImageManifest)
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := do(http.MethodPut, `{"schemaVersion":2}`); w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	for _, req := range []struct{ method, body string }{
+		{http.MethodPut, `{"schemaVersion":2,"annotations":{"retry":"1"}}`},
+		{http.MethodDelete, ""},
+	} {
+		w := do(req.method, req.body)
+		if w.Code != http.StatusConflict {
+			t.Fatalf("%s: expected status %d, got %d", req.method, http.StatusConflict, w.Code)
+		}
+		var ociErr handler.ErrorOCI
+		if err := json.NewDecoder(w.Body).Decode(&ociErr); err != nil {
+			t.Fatal(err)
+		}
+		if ociErr != handler.ErrorTagImmutable {
+			t.Errorf("%s: expected %v, got %v", req.method, handler.ErrorTagImmutable, ociErr)
+		}
+	}
+}
+
+func TestManifestsGetStale(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer upstream.Close()
+
+	cfg, err := config.New(
+		config.WithAdminName(testUser),
+		config.WithAdminPwd([]byte(testPwd)),
+		config.WithDataDir("mem://"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.Data.ManifestPut("library/app", "latest", bytes.NewBufferString(`{"schemaVersion":2}`)); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Data = proxy.NewProxyDataStorage(cfg.Data, []proxy.Proxy{{
+		Url:    upstream.URL,
+		Scopes: []string{"^library/.+$"},
+	}})
+	h := handler.NewHandler(*cfg)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/v2/library/app/manifests/latest", nil)
+	r.SetBasicAuth(testUser, testPwd)
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if got := w.Header().Get("Warning"); got != `110 - "Response is Stale"` {
+		t.Errorf("expected stale warning, got %q", got)
+	}
+}
@@ -20,8 +20,9 @@ import (
 	"io/fs"
 	netHttp "net/http"
 	"slices"
+	"strconv"
 
-	"github.com/jlsalvador/simple-registry/pkg/http"
+	"github.com/jlsalvador/simple-registry/internal/data"
 	"github.com/jlsalvador/simple-registry/pkg/registry"
 )
 
@@ -36,7 +37,10 @@ import (
 //
 // # Url query params:
 //   - "n" optional. Must be an int.
-//   - "last" optional. Must be a tag name.
+//   - "last" optional. Must be a tag name. Tags are listed after it.
+//
+// The tags not allowed to the user are filtered out of the page, so it could
+// have less than "n" tags.
 //
 // # HTTP status codes:
 //   - 200 OK
@@ -60,7 +64,14 @@ func (m *ServeMux) TagsList(
 		return
 	}
 
-	tags, err := m.cfg.Data.TagsList(repo)
+	// The page is asked to the data storage, so pull-through caches forward
+	// it to their upstream instead of listing all of its tags.
+	query := r.URL.Query()
+	n, err := strconv.Atoi(query.Get("n"))
+	if err != nil {
+		n = -1
+	}
+	tags, err := data.TagsListPage(m.cfg.Data, repo, n, query.Get("last"))
 	if err != nil {
 		// Some repos may not exist, Docker expects 404
 		if errors.Is(err, fs.ErrNotExist) {
@@ -78,10 +89,6 @@ func (m *ServeMux) TagsList(
 		return !m.IsRequestAllowed(r, "tags", resource, netHttp.MethodGet)
 	})
 
-	slices.Sort(tags)
-
-	tags = http.PaginateString(tags, r)
-
 	response := map[string]any{
 		"name": repo,
 		"tags": tags,
@@ -0,0 +1,251 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replication
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/internal/data/proxy"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+// client does requests to a target, authenticating them with basic auth or
+// with a bearer token negotiated like the pull-through cache does.
+type client struct {
+	target *Target
+	proxy  *proxy.Proxy // Credentials and connection of the target.
+}
+
+func newClient(target *Target) (*client, error) {
+	c, err := proxy.NewClient(proxy.ClientConfig{
+		Timeout:   target.Timeout,
+		CAFile:    target.CAFile,
+		CertFile:  target.CertFile,
+		KeyFile:   target.KeyFile,
+		HTTPProxy: target.HTTPProxy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &client{
+		target: target,
+		proxy: &proxy.Proxy{
+			Url:      target.Url,
+			Username: target.Username,
+			Password: target.Password,
+			Client:   c,
+		},
+	}, nil
+}
+
+func (c *client) url(format string, a ...any) string {
+	return strings.TrimRight(c.target.Url, "/") + fmt.Sprintf(format, a...)
+}
+
+// do sends the request, negotiating a bearer token if the target challenges
+// it. Tokens are cached by repository, the scope they are issued for.
+// Requests with body must be rewindable by req.GetBody.
+func (c *client) do(req *http.Request) (*http.Response, error) {
+	if c.target.Username != "" {
+		req.SetBasicAuth(c.target.Username, c.target.Password)
+	}
+
+	resp, err := c.proxy.Client.Do(c.proxy, req)
+	if err != nil {
+		return nil, errors.Join(ErrDownstreamError, err)
+	}
+	return resp, nil
+}
+
+// exists returns if the target already has the blob or the manifest at path.
+func (c *client) exists(path string) (bool, error) {
+	req, err := http.NewRequest(http.MethodHead, c.url("%s", path), nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("%w: HEAD %s: %s", ErrDownstreamError, path, resp.Status)
+}
+
+// references are the blobs and manifests referenced by a manifest.
+type references struct {
+	MediaType string                        `json:"mediaType"`
+	Config    *registry.DescriptorManifest  `json:"config"`
+	Layers    []registry.DescriptorManifest `json:"layers"`
+	Manifests []registry.DescriptorManifest `json:"manifests"`
+	FSLayers  []struct {
+		BlobSum string `json:"blobSum"`
+	} `json:"fsLayers"`
+}
+
+func (refs *references) blobs() []string {
+	digests := []string{}
+	if refs.Config != nil && refs.Config.Digest != "" {
+		digests = append(digests, refs.Config.Digest)
+	}
+	for _, l := range refs.Layers {
+		digests = append(digests, l.Digest)
+	}
+	for _, l := range refs.FSLayers {
+		digests = append(digests, l.BlobSum)
+	}
+	return digests
+}
+
+// pushManifest pushes the manifest digest as reference to the target, after
+// the blobs and manifests it references.
+func pushManifest(
+	ds data.DataStorage,
+	c *client,
+	repo, reference, digest string,
+) error {
+	r, _, _, err := ds.ManifestGet(repo, digest)
+	if err != nil {
+		return err
+	}
+	manifest, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return err
+	}
+
+	refs := references{}
+	if err := json.Unmarshal(manifest, &refs); err != nil {
+		return err
+	}
+
+	for _, d := range refs.blobs() {
+		if err := pushBlob(ds, c, repo, d); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range refs.Manifests {
+		ok, err := c.exists(fmt.Sprintf("/v2/%s/manifests/%s", repo, m.Digest))
+		if err != nil {
+			return err
+		}
+		if ok {
+			continue
+		}
+		if err := pushManifest(ds, c, repo, m.Digest, m.Digest); err != nil {
+			return err
+		}
+	}
+
+	contentType := refs.MediaType
+	if contentType == "" {
+		contentType = registry.MediaTypeOCIImageManifest
+	}
+
+	req, err := http.NewRequest(
+		http.MethodPut,
+		c.url("/v2/%s/manifests/%s", repo, reference),
+		bytes.NewReader(manifest),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("%w: PUT manifest %s:%s: %s", ErrDownstreamError, repo, reference, resp.Status)
+	}
+	return nil
+}
+
+// pushBlob uploads the blob to the target, in a single request, unless the
+// target already has it.
+func pushBlob(ds data.DataStorage, c *client, repo, digest string) error {
+	ok, err := c.exists(fmt.Sprintf("/v2/%s/blobs/%s", repo, digest))
+	if err != nil || ok {
+		return err
+	}
+
+	// Start the upload.
+	req, err := http.NewRequest(http.MethodPost, c.url("/v2/%s/blobs/uploads/", repo), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("%w: POST blob upload %s: %s", ErrDownstreamError, repo, resp.Status)
+	}
+
+	location, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return err
+	}
+	q := location.Query()
+	q.Set("digest", digest)
+	location.RawQuery = q.Encode()
+
+	// Upload the blob and commit it.
+	blob, size, err := ds.BlobsGet(repo, digest)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+
+	req, err = http.NewRequest(http.MethodPut, location.String(), blob)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.GetBody = func() (io.ReadCloser, error) {
+		r, _, err := ds.BlobsGet(repo, digest)
+		return r, err
+	}
+
+	resp, err = c.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("%w: PUT blob %s@%s: %s", ErrDownstreamError, repo, digest, resp.Status)
+	}
+	return nil
+}
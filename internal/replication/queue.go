@@ -0,0 +1,202 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replication
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// task is a pending replication of a manifest to a target.
+type task struct {
+	ID          string    `json:"id"`
+	Target      string    `json:"target"`
+	Repo        string    `json:"repo"`
+	Reference   string    `json:"reference"`
+	Digest      string    `json:"digest"`
+	Attempts    int       `json:"attempts"`
+	CreatedAt   time.Time `json:"createdAt"`
+	NextAttempt time.Time `json:"nextAttempt"`
+}
+
+// queue holds the pending tasks, ordered by creation. Every task is persisted
+// as a JSON file in dir, unless dir is empty.
+type queue struct {
+	dir string
+
+	mu    sync.Mutex
+	tasks []task
+}
+
+func openQueue(dir string) (*queue, error) {
+	q := &queue{dir: dir}
+	if dir == "" {
+		return q, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		t := task{}
+		if err := json.Unmarshal(b, &t); err != nil {
+			return nil, err
+		}
+		q.tasks = append(q.tasks, t)
+	}
+
+	slices.SortFunc(q.tasks, func(a, b task) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return q, nil
+}
+
+func (q *queue) path(id string) string {
+	return filepath.Join(q.dir, id+".json")
+}
+
+// save persists the task, atomically.
+func (q *queue) save(t task) error {
+	if q.dir == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	tmp := q.path(t.ID) + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, q.path(t.ID))
+}
+
+func (q *queue) remove(id string) error {
+	if q.dir == "" {
+		return nil
+	}
+
+	err := os.Remove(q.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// push appends the task, removing the pending tasks it supersedes.
+func (q *queue) push(t task) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.save(t); err != nil {
+		return err
+	}
+
+	errs := []error{}
+	q.tasks = slices.DeleteFunc(q.tasks, func(p task) bool {
+		if p.Target != t.Target || p.Repo != t.Repo || p.Reference != t.Reference {
+			return false
+		}
+		errs = append(errs, q.remove(p.ID))
+		return true
+	})
+	q.tasks = append(q.tasks, t)
+
+	return errors.Join(errs...)
+}
+
+// next returns the oldest task of the target due at now. Otherwise, it returns
+// when the next task of the target is due, or a zero time if there is none.
+func (q *queue) next(target string, now time.Time) (t task, ok bool, due time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, p := range q.tasks {
+		if p.Target != target {
+			continue
+		}
+		if !p.NextAttempt.After(now) {
+			return p, true, time.Time{}
+		}
+		if due.IsZero() || p.NextAttempt.Before(due) {
+			due = p.NextAttempt
+		}
+	}
+	return task{}, false, due
+}
+
+// done removes the task.
+func (q *queue) done(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.tasks = slices.DeleteFunc(q.tasks, func(p task) bool {
+		return p.ID == id
+	})
+	return q.remove(id)
+}
+
+// retry updates the task, unless it was superseded meanwhile.
+func (q *queue) retry(t task) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	i := slices.IndexFunc(q.tasks, func(p task) bool {
+		return p.ID == t.ID
+	})
+	if i < 0 {
+		return nil
+	}
+
+	q.tasks[i] = t
+	return q.save(t)
+}
+
+// pending returns the number of tasks of the target.
+func (q *queue) pending(target string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n := 0
+	for _, p := range q.tasks {
+		if p.Target == target {
+			n++
+		}
+	}
+	return n
+}
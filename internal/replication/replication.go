@@ -0,0 +1,280 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package replication pushes the manifests stored in the registry, and the
+// blobs they reference, to downstream registries.
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/internal/version"
+	"github.com/jlsalvador/simple-registry/pkg/log"
+	"github.com/jlsalvador/simple-registry/pkg/uuid"
+)
+
+// Target is a downstream registry receiving the manifests pushed to the
+// repositories matched by any of Scopes.
+type Target struct {
+	Name     string
+	Url      string
+	Timeout  time.Duration // Disabled if zero.
+	Username string
+	Password string
+	Scopes   []regexp.Regexp // Regular expressions matching the repository path.
+
+	CAFile    string // PEM bundle of the CAs trusted besides the system ones.
+	CertFile  string // PEM client certificate, along with KeyFile.
+	KeyFile   string
+	HTTPProxy string // Like "http://proxy.example.com:3128". From the environment if empty.
+}
+
+// Match returns if the target replicates the repository.
+func (t *Target) Match(repo string) bool {
+	for _, s := range t.Scopes {
+		if s.MatchString(repo) {
+			return true
+		}
+	}
+	return false
+}
+
+// Status is the replication state of a target.
+type Status struct {
+	Name        string    `json:"name"`
+	Url         string    `json:"url"`
+	Pending     int       `json:"pending"`    // Manifests waiting to be replicated.
+	Replicated  int       `json:"replicated"` // Manifests replicated since start.
+	Failures    int       `json:"failures"`   // Failed attempts since start.
+	LastSuccess time.Time `json:"lastSuccess,omitzero"`
+	LastError   string    `json:"lastError,omitempty"`
+	LastErrorAt time.Time `json:"lastErrorAt,omitzero"`
+}
+
+// Replicator replicates, in background, the manifests enqueued after being
+// pushed.
+type Replicator struct {
+	// MinRetryDelay is the delay before retrying a failed replication.
+	// It doubles on each failed attempt, up to MaxRetryDelay.
+	MinRetryDelay time.Duration
+	MaxRetryDelay time.Duration
+
+	ds      data.DataStorage
+	targets []Target
+	clients map[string]*client // By target name.
+	queue   *queue
+
+	mu     sync.Mutex
+	status map[string]*Status
+	wake   map[string]chan struct{}
+}
+
+// NewReplicator returns a Replicator reading manifests and blobs from ds.
+//
+// Pending replications are persisted in queueDir, so they survive restarts.
+// They are kept in memory if queueDir is empty.
+func NewReplicator(
+	ds data.DataStorage,
+	targets []Target,
+	queueDir string,
+) (*Replicator, error) {
+	q, err := openQueue(queueDir)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Replicator{
+		MinRetryDelay: time.Second,
+		MaxRetryDelay: 5 * time.Minute,
+		ds:            ds,
+		targets:       targets,
+		clients:       map[string]*client{},
+		queue:         q,
+		status:        map[string]*Status{},
+		wake:          map[string]chan struct{}{},
+	}
+	for _, t := range targets {
+		if _, ok := r.status[t.Name]; ok {
+			return nil, fmt.Errorf("%w: %q", ErrTargetDuplicated, t.Name)
+		}
+		r.clients[t.Name], err = newClient(&t)
+		if err != nil {
+			return nil, fmt.Errorf("invalid replication target %q: %w", t.Name, err)
+		}
+		r.status[t.Name] = &Status{Name: t.Name, Url: t.Url}
+		r.wake[t.Name] = make(chan struct{}, 1)
+	}
+
+	return r, nil
+}
+
+// Enqueue schedules the replication of the manifest digest, pushed as
+// reference, to every target matching the repository.
+//
+// A pending replication of the same reference is superseded, so a tag is never
+// replicated pointing to an older manifest.
+func (r *Replicator) Enqueue(repo, reference, digest string) error {
+	errs := []error{}
+	for _, t := range r.targets {
+		if !t.Match(repo) {
+			continue
+		}
+
+		now := time.Now()
+		err := r.queue.push(task{
+			ID:          uuid.MustNew().String(),
+			Target:      t.Name,
+			Repo:        repo,
+			Reference:   reference,
+			Digest:      digest,
+			CreatedAt:   now,
+			NextAttempt: now,
+		})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		select {
+		case r.wake[t.Name] <- struct{}{}:
+		default:
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Status returns the replication state of every target.
+func (r *Replicator) Status() []Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	statuses := make([]Status, 0, len(r.targets))
+	for _, t := range r.targets {
+		s := *r.status[t.Name]
+		s.Pending = r.queue.pending(t.Name)
+		statuses = append(statuses, s)
+	}
+	return statuses
+}
+
+// Run replicates the enqueued manifests until ctx is done.
+// Every target is replicated concurrently.
+func (r *Replicator) Run(ctx context.Context) {
+	wg := sync.WaitGroup{}
+	for _, t := range r.targets {
+		wg.Go(func() {
+			r.runTarget(ctx, &t)
+		})
+	}
+	wg.Wait()
+}
+
+func (r *Replicator) runTarget(ctx context.Context, target *Target) {
+	c := r.clients[target.Name]
+
+	for {
+		t, ok, due := r.queue.next(target.Name, time.Now())
+		if ok {
+			r.replicate(c, t)
+			continue
+		}
+
+		var timer <-chan time.Time
+		if !due.IsZero() {
+			timer = time.After(time.Until(due))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.wake[target.Name]:
+		case <-timer:
+		}
+	}
+}
+
+func (r *Replicator) replicate(c *client, t task) {
+	err := pushManifest(r.ds, c, t.Repo, t.Reference, t.Digest)
+
+	// Nothing to replicate if the manifest was deleted meanwhile.
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Warn(
+			"service.name", version.AppName,
+			"service.version", version.AppVersion,
+			"event.dataset", "internal.replication",
+			"message", fmt.Sprintf("manifest %s@%s not found, skipping replication to %s", t.Repo, t.Digest, t.Target),
+		).Print()
+		err = r.queue.done(t.ID)
+		if err != nil {
+			r.logError(t, err)
+		}
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := r.status[t.Target]
+
+	if err != nil {
+		status.Failures++
+		status.LastError = err.Error()
+		status.LastErrorAt = time.Now()
+		r.logError(t, err)
+
+		t.Attempts++
+		t.NextAttempt = time.Now().Add(r.retryDelay(t.Attempts))
+		if err := r.queue.retry(t); err != nil {
+			r.logError(t, err)
+		}
+		return
+	}
+
+	status.Replicated++
+	status.LastSuccess = time.Now()
+	log.Debug(
+		"service.name", version.AppName,
+		"service.version", version.AppVersion,
+		"event.dataset", "internal.replication",
+		"message", fmt.Sprintf("manifest %s:%s replicated to %s", t.Repo, t.Reference, t.Target),
+	).Print()
+
+	if err := r.queue.done(t.ID); err != nil {
+		r.logError(t, err)
+	}
+}
+
+func (r *Replicator) retryDelay(attempts int) time.Duration {
+	delay := r.MinRetryDelay
+	for i := 1; i < attempts && delay < r.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, r.MaxRetryDelay)
+}
+
+func (r *Replicator) logError(t task, err error) {
+	log.Error(
+		"service.name", version.AppName,
+		"service.version", version.AppVersion,
+		"event.dataset", "internal.replication",
+		"error.message", err.Error(),
+		"message", fmt.Sprintf("replication of %s:%s to %s failed", t.Repo, t.Reference, t.Target),
+	).Print()
+}
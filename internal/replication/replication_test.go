@@ -0,0 +1,357 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replication_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/internal/http/handler"
+	"github.com/jlsalvador/simple-registry/internal/replication"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+const (
+	testUser = "admin"
+	testPwd  = "password"
+)
+
+func newMemoryConfig(t *testing.T) *config.Config {
+	t.Helper()
+
+	cfg, err := config.New(
+		config.WithAdminName(testUser),
+		config.WithAdminPwd([]byte(testPwd)),
+		config.WithDataDir("mem://"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+// newDownstream returns a registry, and its storage, requiring bearer tokens
+// for the registry API.
+func newDownstream(t *testing.T) (*httptest.Server, data.DataStorage) {
+	t.Helper()
+
+	cfg := newMemoryConfig(t)
+	h := handler.NewHandler(*cfg)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v2/") && strings.HasPrefix(r.Header.Get("Authorization"), "Basic ") {
+			r.Header.Del("Authorization")
+		}
+		h.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, cfg.Data
+}
+
+func putBlob(t *testing.T, ds data.DataStorage, repo string, blob []byte) registry.DescriptorManifest {
+	t.Helper()
+
+	sum := sha256.Sum256(blob)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	uuid, err := ds.BlobsUploadCreate(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ds.BlobsUploadWrite(repo, uuid, bytes.NewReader(blob), -1); err != nil {
+		t.Fatal(err)
+	}
+	if err := ds.BlobsUploadCommit(repo, uuid, digest); err != nil {
+		t.Fatal(err)
+	}
+
+	return registry.DescriptorManifest{
+		MediaType: "application/octet-stream",
+		Digest:    digest,
+		Size:      int64(len(blob)),
+	}
+}
+
+// putImage stores an image with a config and a layer, tagged as tag.
+func putImage(t *testing.T, ds data.DataStorage, repo, tag string) string {
+	t.Helper()
+
+	cfg := putBlob(t, ds, repo, []byte(`{"tag":"`+tag+`"}`))
+	cfg.MediaType = registry.MediaTypeOCIImageConfig
+	layer := putBlob(t, ds, repo, []byte("layer of "+tag))
+
+	manifest, err := json.Marshal(registry.ImageManifest{
+		SchemaVersion: 2,
+		MediaType:     registry.MediaTypeOCIImageManifest,
+		Config:        cfg,
+		Layers:        []registry.DescriptorManifest{layer},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest, err := ds.ManifestPut(repo, tag, bytes.NewReader(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return digest
+}
+
+func newTarget(url string) replication.Target {
+	return replication.Target{
+		Name:     "site-b",
+		Url:      url,
+		Timeout:  5 * time.Second,
+		Username: testUser,
+		Password: testPwd,
+		Scopes:   []regexp.Regexp{*regexp.MustCompile("^team/.+$")},
+	}
+}
+
+func waitStatus(t *testing.T, r *replication.Replicator, fn func(s replication.Status) bool) replication.Status {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s := r.Status()[0]
+		if fn(s) {
+			return s
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected status %+v", s)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestReplicate(t *testing.T) {
+	srv, downstream := newDownstream(t)
+	primary := newMemoryConfig(t)
+
+	r, err := replication.NewReplicator(primary.Data, []replication.Target{newTarget(srv.URL)}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Run(ctx)
+
+	digest := putImage(t, primary.Data, "team/app", "v1")
+	if err := r.Enqueue("team/app", "v1", digest); err != nil {
+		t.Fatal(err)
+	}
+	// Repositories out of scope are not replicated.
+	other := putImage(t, primary.Data, "other/app", "v1")
+	if err := r.Enqueue("other/app", "v1", other); err != nil {
+		t.Fatal(err)
+	}
+
+	s := waitStatus(t, r, func(s replication.Status) bool {
+		return s.Replicated == 1 && s.Pending == 0
+	})
+	if s.LastSuccess.IsZero() || s.Failures != 0 {
+		t.Errorf("unexpected status %+v", s)
+	}
+
+	rc, _, got, err := downstream.ManifestGet("team/app", "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc.Close()
+	if got != digest {
+		t.Errorf("expected digest %s, got %s", digest, got)
+	}
+	if _, _, _, err := downstream.ManifestGet("other/app", "v1"); err == nil {
+		t.Error("expected repository out of scope not replicated")
+	}
+}
+
+func TestReplicateRetry(t *testing.T) {
+	failing := atomic.Bool{}
+	failing.Store(true)
+	srv, _ := newDownstream(t)
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		srv.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer flaky.Close()
+
+	primary := newMemoryConfig(t)
+	dir := t.TempDir()
+
+	r, err := replication.NewReplicator(primary.Data, []replication.Target{newTarget(flaky.URL)}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.MinRetryDelay = 10 * time.Millisecond
+	r.MaxRetryDelay = 10 * time.Millisecond
+
+	digest := putImage(t, primary.Data, "team/app", "v1")
+	if err := r.Enqueue("team/app", "v1", digest); err != nil {
+		t.Fatal(err)
+	}
+	// A newer push of the same tag supersedes the pending one.
+	digest = putImage(t, primary.Data, "team/app", "v1")
+	if err := r.Enqueue("team/app", "v1", digest); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+	s := waitStatus(t, r, func(s replication.Status) bool {
+		return s.Failures > 0
+	})
+	cancel()
+	<-done
+	if s.LastError == "" || s.LastErrorAt.IsZero() || s.Pending != 1 {
+		t.Errorf("unexpected status %+v", s)
+	}
+
+	// Pending replications survive restarts.
+	r, err = replication.NewReplicator(primary.Data, []replication.Target{newTarget(flaky.URL)}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.MinRetryDelay = 10 * time.Millisecond
+	r.MaxRetryDelay = 10 * time.Millisecond
+	if s := r.Status()[0]; s.Pending != 1 {
+		t.Fatalf("expected 1 pending replication, got %+v", s)
+	}
+
+	failing.Store(false)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go r.Run(ctx)
+	waitStatus(t, r, func(s replication.Status) bool {
+		return s.Replicated == 1 && s.Pending == 0
+	})
+}
+
+func TestNewReplicatorDuplicatedTarget(t *testing.T) {
+	primary := newMemoryConfig(t)
+	target := newTarget("http://localhost")
+
+	_, err := replication.NewReplicator(primary.Data, []replication.Target{target, target}, "")
+	if err == nil {
+		t.Fatal("expected error for duplicated targets")
+	}
+}
+
+func TestReplicateCachesTokens(t *testing.T) {
+	srv, _ := newDownstream(t)
+	tokens := atomic.Int32{}
+	counting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/v2/") {
+			tokens.Add(1)
+		}
+		srv.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer counting.Close()
+
+	primary := newMemoryConfig(t)
+	r, err := replication.NewReplicator(primary.Data, []replication.Target{newTarget(counting.URL)}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Run(ctx)
+
+	for _, image := range []struct{ repo, tag string }{
+		{"team/app", "v1"},
+		{"team/web", "v1"},
+		{"team/app", "v2"},
+	} {
+		digest := putImage(t, primary.Data, image.repo, image.tag)
+		if err := r.Enqueue(image.repo, image.tag, digest); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitStatus(t, r, func(s replication.Status) bool {
+		return s.Replicated == 3 && s.Pending == 0
+	})
+
+	// A token per repository, reused by all of its requests.
+	if got := tokens.Load(); got != 2 {
+		t.Errorf("expected 2 tokens, got %d", got)
+	}
+}
+
+func TestReplicateTokenTimeout(t *testing.T) {
+	release := make(chan struct{})
+	realm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer realm.Close()
+	defer close(release)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="`+realm.URL+`",service="registry"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	primary := newMemoryConfig(t)
+	target := newTarget(srv.URL)
+	target.Timeout = 50 * time.Millisecond
+	r, err := replication.NewReplicator(primary.Data, []replication.Target{target}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.MinRetryDelay = time.Minute
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Run(ctx)
+
+	digest := putImage(t, primary.Data, "team/app", "v1")
+	if err := r.Enqueue("team/app", "v1", digest); err != nil {
+		t.Fatal(err)
+	}
+
+	// The token request honours the timeout of the target.
+	waitStatus(t, r, func(s replication.Status) bool {
+		return s.Failures == 1
+	})
+}
+
+func TestNewReplicatorInvalidTarget(t *testing.T) {
+	primary := newMemoryConfig(t)
+	target := newTarget("http://localhost")
+	target.CAFile = filepath.Join(t.TempDir(), "missing.pem")
+
+	_, err := replication.NewReplicator(primary.Data, []replication.Target{target}, "")
+	if err == nil {
+		t.Fatal("expected error for an invalid target")
+	}
+}
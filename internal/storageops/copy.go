@@ -0,0 +1,110 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageops
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/internal/gc"
+)
+
+// readManifest returns the payload of the manifest, and its digest.
+func readManifest(ds data.DataStorage, repo, reference string) ([]byte, string, error) {
+	r, _, digest, err := ds.ManifestGet(repo, reference)
+	if err != nil {
+		return nil, "", err
+	}
+	defer r.Close()
+
+	payload, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", err
+	}
+	return payload, digest, nil
+}
+
+// copyManifests links into dst the blobs referenced by the manifest digests
+// of src, including image indexes and referrers, and puts those manifests, so
+// no blob content is transferred.
+//
+// Only the content stored in the registry is copied, so upstreams of
+// pull-through caches are not reached.
+func copyManifests(ds data.DataStorage, src, dst string, digests []string) error {
+	local := gc.Local(ds)
+
+	manifests, blobs, err := gc.Referenced(local, src, digests)
+	if err != nil {
+		return err
+	}
+
+	for digest := range blobs {
+		if manifests.Contains(digest) {
+			continue
+		}
+		if err := ds.BlobsLink(dst, digest); err != nil {
+			return err
+		}
+	}
+
+	for digest := range manifests {
+		payload, _, err := readManifest(local, src, digest)
+		if err != nil {
+			return err
+		}
+		if _, err := ds.ManifestPut(dst, digest, bytes.NewReader(payload)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyTag points the tag of dst to the same manifest than the reference of
+// src, which must be already copied.
+func copyTag(ds data.DataStorage, src, reference, dst, tag string) (string, error) {
+	payload, _, err := readManifest(gc.Local(ds), src, reference)
+	if err != nil {
+		return "", err
+	}
+	return ds.ManifestPut(dst, tag, bytes.NewReader(payload))
+}
+
+// CopyManifest copies the manifest reference of src, with the manifests and
+// blobs it references and its referrers, into dst by linking them. The copy
+// is tagged as tag, unless it is empty. The digest of the manifest is
+// returned.
+//
+// Quotas and immutable tags apply to dst as if it were pushed.
+func CopyManifest(cfg config.Config, src, reference, dst, tag string) (digest string, err error) {
+	_, digest, err = readManifest(gc.Local(cfg.Data), src, reference)
+	if err != nil {
+		return "", err
+	}
+
+	if err := copyManifests(cfg.Data, src, dst, []string{digest}); err != nil {
+		return "", err
+	}
+
+	if tag != "" {
+		if _, err := copyTag(cfg.Data, src, digest, dst, tag); err != nil {
+			return "", err
+		}
+	}
+
+	return digest, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2026 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageops_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/jlsalvador/simple-registry/internal/storageops"
+)
+
+func TestCopyManifest(t *testing.T) {
+	cfg := newMemoryConfig(t)
+	ds := cfg.Data
+
+	layer := putBlob(t, ds, "src", []byte("layer"))
+	manifest, _ := putImage(t, ds, "src", "v1", layer)
+
+	digest, err := storageops.CopyManifest(*cfg, "src", "v1", "dst", "latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != manifest {
+		t.Errorf("expected digest %s, got %s", manifest, digest)
+	}
+
+	r, _, got, err := ds.ManifestGet("dst", "latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	if got != manifest {
+		t.Errorf("expected digest %s, got %s", manifest, got)
+	}
+
+	// Blobs are linked, not uploaded again.
+	r, _, err = ds.BlobsGet("dst", layer.Digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(r); string(b) != "layer" {
+		t.Errorf("expected blob %q, got %q", "layer", b)
+	}
+	r.Close()
+
+	// The source is kept.
+	if _, err := ds.TagsList("src"); err != nil {
+		t.Errorf("expected source kept, got %v", err)
+	}
+}
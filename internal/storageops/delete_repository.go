@@ -0,0 +1,108 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageops
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/gc"
+	"github.com/jlsalvador/simple-registry/pkg/mapset"
+)
+
+// candidates returns the manifests and blobs referenced by the repository,
+// which could become unreferenced once it is deleted.
+func candidates(cfg config.Config, repo string) (mapset.MapSet[string], error) {
+	ds := gc.Local(cfg.Data)
+
+	var roots []string
+	digests, err := ds.ManifestsList(repo)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if digests != nil {
+		roots = slices.Collect(digests)
+	}
+
+	manifests, blobs, err := gc.Referenced(ds, repo, roots)
+	if err != nil {
+		return nil, err
+	}
+	for digest := range blobs {
+		manifests.Add(digest)
+	}
+	return manifests, nil
+}
+
+// DeleteRepository deletes the tags, manifests, referrers, layer links and
+// upload sessions of the repository.
+//
+// If collect, the manifests and blobs referenced by the repository are
+// garbage collected right away once no other repository references nor links
+// them, regardless of their last access. The deleted ones are returned.
+//
+// The garbage collection runs through the collector, so it returns
+// [gc.ErrRunning], before deleting anything, if there is another one running.
+// A nil collector uses its own one, for commands.
+func DeleteRepository(cfg config.Config, collector *gc.Collector, repo string, collect bool) (
+	deletedBlobs mapset.MapSet[string],
+	err error,
+) {
+	deletedBlobs = mapset.NewMapSet[string]()
+
+	if !collect {
+		return deletedBlobs, cfg.Data.RepositoryDelete(repo)
+	}
+
+	if collector == nil {
+		collector = gc.NewCollector(cfg)
+	}
+
+	run, err := collector.Collect(gc.Options{}, func(opts *gc.Options) error {
+		only, err := candidates(cfg, repo)
+		if err != nil {
+			return err
+		}
+
+		if err := cfg.Data.RepositoryDelete(repo); err != nil {
+			return err
+		}
+
+		// Blobs linked by other repositories, like the ones being pushed by a
+		// client, are kept.
+		ds := gc.Local(cfg.Data)
+		for digest := range only {
+			repos, err := ds.BlobRepositories(digest)
+			if err != nil {
+				return err
+			}
+			if len(repos) > 0 {
+				delete(only, digest)
+			}
+		}
+
+		opts.OnlyBlobs = only
+		opts.OnlyManifests = mapset.NewMapSet[gc.ManifestRef]()
+		opts.OnlyTags = mapset.NewMapSet[gc.TagRef]()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deletedBlobs.Add(run.Result.DeletedBlobs...), nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2026 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageops_test
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/jlsalvador/simple-registry/internal/data/guard"
+	"github.com/jlsalvador/simple-registry/internal/gc"
+	"github.com/jlsalvador/simple-registry/internal/storageops"
+	"github.com/jlsalvador/simple-registry/pkg/mapset"
+)
+
+func TestDeleteRepository(t *testing.T) {
+	cfg := newMemoryConfig(t)
+	ds := cfg.Data
+
+	shared := putBlob(t, ds, "app", []byte("shared"))
+	exclusive := putBlob(t, ds, "app", []byte("exclusive"))
+	manifest, _ := putImage(t, ds, "app", "latest", shared, exclusive)
+	putImage(t, ds, "other", "latest", putBlob(t, ds, "other", []byte("shared")))
+
+	deleted, err := storageops.DeleteRepository(*cfg, nil, "app", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 0 {
+		t.Errorf("expected no deleted blobs, got %v", deleted)
+	}
+
+	repos, err := ds.RepositoriesList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 1 || repos[0] != "other" {
+		t.Errorf("expected [other], got %v", repos)
+	}
+
+	// Blobs are kept until garbage collected.
+	for _, digest := range []string{exclusive.Digest, manifest} {
+		if _, _, err := ds.BlobsGet("", digest); err != nil {
+			t.Errorf("expected blob %s kept, got %v", digest, err)
+		}
+	}
+
+	if _, err := storageops.DeleteRepository(*cfg, nil, "app", false); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestDeleteRepository_Collect(t *testing.T) {
+	cfg := newMemoryConfig(t)
+	ds := cfg.Data
+
+	shared := putBlob(t, ds, "app", []byte("shared"))
+	exclusive := putBlob(t, ds, "app", []byte("exclusive"))
+	manifest, _ := putImage(t, ds, "app", "latest", shared, exclusive)
+	putImage(t, ds, "other", "latest", putBlob(t, ds, "other", []byte("shared")))
+
+	// Linked by another repository, like a push in progress.
+	linked := putBlob(t, ds, "app", []byte("linked"))
+	putBlob(t, ds, "pushing", []byte("linked"))
+	putImage(t, ds, "app", "latest", shared, exclusive, linked)
+
+	deleted, err := storageops.DeleteRepository(*cfg, nil, "app", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, digest := range []string{exclusive.Digest, manifest} {
+		if !deleted.Contains(digest) {
+			t.Errorf("expected %s deleted", digest)
+		}
+		if _, _, err := ds.BlobsGet("", digest); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected fs.ErrNotExist, got %v", err)
+		}
+	}
+	for _, digest := range []string{shared.Digest, linked.Digest} {
+		if deleted.Contains(digest) {
+			t.Errorf("expected %s kept", digest)
+		}
+	}
+
+	if _, _, _, err := ds.ManifestGet("other", "latest"); err != nil {
+		t.Errorf("expected other repository kept, got %v", err)
+	}
+}
+
+func TestDeleteRepository_CollectorRunning(t *testing.T) {
+	cfg := newMemoryConfig(t)
+	ds := cfg.Data
+	putImage(t, ds, "app", "latest", putBlob(t, ds, "app", []byte("layer")))
+
+	c := gc.NewCollector(*cfg)
+
+	// Block the sweep of the running garbage collection.
+	g := ds.(*guard.GuardDataStorage)
+	err := g.Exclusive(nil, func(mapset.MapSet[string]) error {
+		if _, err := c.Start(false, time.Nanosecond, false); err != nil {
+			return err
+		}
+
+		if _, err := storageops.DeleteRepository(*cfg, c, "app", true); !errors.Is(err, gc.ErrRunning) {
+			t.Errorf("expected ErrRunning, got %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, _, err := ds.ManifestGet("app", "latest"); err != nil {
+		t.Errorf("expected repository kept, got %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageops
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/internal/data/quota"
+	"github.com/jlsalvador/simple-registry/internal/gc"
+	"github.com/jlsalvador/simple-registry/pkg/mapset"
+)
+
+// tagsList returns the tags of the repository, none if it does not exist.
+func tagsList(ds data.DataStorage, repo string) ([]string, error) {
+	tags, err := ds.TagsList(repo)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// checkMutable returns [data.ErrTagImmutable] if any of the tags is
+// immutable, as they could not be deleted.
+func checkMutable(ds data.DataStorage, repo string, tags []string) error {
+	for _, tag := range tags {
+		if gc.IsImmutable(ds, repo, tag) {
+			return data.ErrTagImmutable
+		}
+	}
+	return nil
+}
+
+// copyRepository copies the manifest digests and tags of src into dst,
+// returning the digest of every tag copied. Tags deleted meanwhile are
+// skipped.
+func copyRepository(ds data.DataStorage, src, dst string, digests, tags []string) (
+	tagDigests map[string]string,
+	err error,
+) {
+	if err := copyManifests(ds, src, dst, digests); err != nil {
+		return nil, err
+	}
+
+	tagDigests = make(map[string]string, len(tags))
+	for _, tag := range tags {
+		digest, err := copyTag(ds, src, tag, dst, tag)
+		if errors.Is(err, fs.ErrNotExist) {
+			// Deleted while copying.
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		tagDigests[tag] = digest
+	}
+
+	return tagDigests, nil
+}
+
+// copyChanges copies into dst the manifests and tags changed in src since
+// they were copied, and deletes the tags of dst deleted from src.
+func copyChanges(
+	ds, local data.DataStorage,
+	src, dst string,
+	copied mapset.MapSet[string],
+	tagDigests map[string]string,
+) error {
+	var added []string
+	if seq, err := local.ManifestsList(src); err == nil {
+		for digest := range seq {
+			if !copied.Contains(digest) {
+				added = append(added, digest)
+			}
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if len(added) > 0 {
+		if err := copyManifests(local, src, dst, added); err != nil {
+			return err
+		}
+		copied.Add(added...)
+	}
+
+	tags, err := tagsList(local, src)
+	if err != nil {
+		return err
+	}
+	if err := checkMutable(ds, src, tags); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		_, digest, err := readManifest(local, src, tag)
+		if err != nil {
+			return err
+		}
+		if tagDigests[tag] == digest {
+			continue
+		}
+		if tagDigests[tag], err = copyTag(local, src, tag, dst, tag); err != nil {
+			return err
+		}
+	}
+
+	for tag := range tagDigests {
+		if slices.Contains(tags, tag) {
+			continue
+		}
+		if err := local.ManifestDelete(dst, tag); err != nil {
+			return err
+		}
+		delete(tagDigests, tag)
+	}
+
+	return nil
+}
+
+// resetQuota recomputes the quotas, which do not see the changes made under
+// them.
+func resetQuota(ds data.DataStorage) {
+	if q, ok := ds.(*quota.QuotaDataStorage); ok {
+		q.Reset()
+	}
+}
+
+// deleteCopy deletes what a failed copy put into dst, so the source is left
+// untouched, and returns the error of the copy along with its own, if any.
+// dst did not exist before, so its copied tags are deleted even if they are
+// immutable.
+func deleteCopy(ds data.DataStorage, dst string, copyErr error) error {
+	defer resetQuota(ds)
+
+	if err := gc.Local(ds).RepositoryDelete(dst); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errors.Join(copyErr, err)
+	}
+	return copyErr
+}
+
+// RenameRepository moves every manifest and tag of src to dst, which must not
+// exist, by linking their blobs. Then src is deleted. If the copy fails, dst
+// is deleted, so src is left untouched.
+//
+// It returns [ErrRepoUnknown] if src has no manifests, [ErrRepoExists] if dst
+// exists, and [data.ErrTagImmutable] if a tag of src is immutable.
+//
+// The manifests and tags pushed to src while it is copied are moved too: they
+// are copied while the clients are blocked, right before deleting src. The
+// amount of manifests moved, and the digest of every tag moved, are returned.
+func RenameRepository(cfg config.Config, src, dst string) (
+	manifests int,
+	tagDigests map[string]string,
+	err error,
+) {
+	local := gc.Local(cfg.Data)
+
+	var digests []string
+	if seq, err := local.ManifestsList(src); err == nil {
+		digests = slices.Collect(seq)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return 0, nil, err
+	}
+	if len(digests) == 0 {
+		return 0, nil, ErrRepoUnknown
+	}
+
+	// The new repository must not exist, not even with only blobs or upload
+	// sessions, so a failed copy could be removed as a whole.
+	repos, err := local.RepositoriesList()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return 0, nil, err
+	}
+	if slices.Contains(repos, dst) {
+		return 0, nil, ErrRepoExists
+	}
+
+	// Immutable tags could not be deleted, so refuse before copying.
+	tags, err := tagsList(local, src)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := checkMutable(cfg.Data, src, tags); err != nil {
+		return 0, nil, err
+	}
+
+	tagDigests, err = copyRepository(cfg.Data, src, dst, digests, tags)
+	if err != nil {
+		return 0, nil, deleteCopy(cfg.Data, dst, err)
+	}
+
+	copied := mapset.NewMapSet[string]().Add(digests...)
+	deleting := false
+	err = gc.Exclusive(cfg.Data, func(local data.DataStorage) error {
+		if err := copyChanges(cfg.Data, local, src, dst, copied, tagDigests); err != nil {
+			return err
+		}
+		deleting = true
+		return local.RepositoryDelete(src)
+	})
+	resetQuota(cfg.Data)
+	if err != nil {
+		// src could be partially deleted, so its copy is kept.
+		if !deleting {
+			err = deleteCopy(cfg.Data, dst, err)
+		}
+		return 0, nil, err
+	}
+
+	return len(copied), tagDigests, nil
+}
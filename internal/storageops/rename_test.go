@@ -0,0 +1,128 @@
+// Copyright 2026 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageops_test
+
+import (
+	"errors"
+	"io"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/internal/storageops"
+)
+
+// pushingStorage calls push the first time a manifest is put into another
+// repository than src, like a client pushing to src while it is copied.
+type pushingStorage struct {
+	data.DataStorage
+	src  string
+	push func()
+}
+
+func (s *pushingStorage) ManifestPut(repo, reference string, r io.Reader) (string, error) {
+	if repo != s.src && s.push != nil {
+		push := s.push
+		s.push = nil
+		push()
+	}
+	return s.DataStorage.ManifestPut(repo, reference, r)
+}
+
+func TestRenameRepository(t *testing.T) {
+	cfg := newMemoryConfig(t)
+	ds := cfg.Data
+
+	v1, _ := putImage(t, ds, "src", "v1", putBlob(t, ds, "src", []byte("v1")))
+	putImage(t, ds, "taken", "v1", putBlob(t, ds, "taken", []byte("taken")))
+
+	if _, _, err := storageops.RenameRepository(*cfg, "missing", "dst"); !errors.Is(err, storageops.ErrRepoUnknown) {
+		t.Errorf("expected ErrRepoUnknown, got %v", err)
+	}
+	if _, _, err := storageops.RenameRepository(*cfg, "src", "taken"); !errors.Is(err, storageops.ErrRepoExists) {
+		t.Errorf("expected ErrRepoExists, got %v", err)
+	}
+
+	manifests, tags, err := storageops.RenameRepository(*cfg, "src", "dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifests != 1 || len(tags) != 1 || tags["v1"] != v1 {
+		t.Errorf("expected 1 manifest and v1 at %s, got %d and %v", v1, manifests, tags)
+	}
+
+	repos, err := ds.RepositoriesList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"dst", "taken"}; !slices.Equal(repos, want) {
+		t.Errorf("expected %v, got %v", want, repos)
+	}
+}
+
+func TestRenameRepository_PushedWhileCopying(t *testing.T) {
+	cfg := newMemoryConfig(t)
+	inner := cfg.Data
+
+	putImage(t, inner, "src", "v1", putBlob(t, inner, "src", []byte("v1")))
+	putImage(t, inner, "src", "old", putBlob(t, inner, "src", []byte("old")))
+
+	var v1, v2 string
+	cfg.Data = &pushingStorage{DataStorage: inner, src: "src", push: func() {
+		v1, _ = putImage(t, inner, "src", "v1", putBlob(t, inner, "src", []byte("v1 again")))
+		v2, _ = putImage(t, inner, "src", "v2", putBlob(t, inner, "src", []byte("v2")))
+		if err := inner.ManifestDelete("src", "old"); err != nil {
+			t.Fatal(err)
+		}
+	}}
+
+	manifests, tags, err := storageops.RenameRepository(*cfg, "src", "dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifests != 4 {
+		t.Errorf("expected 4 manifests, got %d", manifests)
+	}
+	if want := map[string]string{"v1": v1, "v2": v2}; !maps.Equal(tags, want) {
+		t.Errorf("expected tags %v, got %v", want, tags)
+	}
+
+	got, err := inner.TagsList("dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"v1", "v2"}; !slices.Equal(got, want) {
+		t.Errorf("expected tags %v, got %v", want, got)
+	}
+	for tag, digest := range tags {
+		r, _, got, err := inner.ManifestGet("dst", tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Close()
+		if got != digest {
+			t.Errorf("expected %s at %s, got %s", tag, digest, got)
+		}
+	}
+
+	repos, err := inner.RepositoriesList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"dst"}; !slices.Equal(repos, want) {
+		t.Errorf("expected %v, got %v", want, repos)
+	}
+}
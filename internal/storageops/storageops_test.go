@@ -0,0 +1,89 @@
+// Copyright 2026 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageops_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+func sha256Digest(b []byte) string {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func putBlob(t *testing.T, ds data.DataStorage, repo string, blob []byte) registry.DescriptorManifest {
+	t.Helper()
+
+	digest := sha256Digest(blob)
+	uuid, err := ds.BlobsUploadCreate(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ds.BlobsUploadWrite(repo, uuid, bytes.NewReader(blob), -1); err != nil {
+		t.Fatal(err)
+	}
+	if err := ds.BlobsUploadCommit(repo, uuid, digest); err != nil {
+		t.Fatal(err)
+	}
+	return registry.DescriptorManifest{Digest: digest, Size: int64(len(blob))}
+}
+
+// putImage pushes an image manifest, returning its digest and size.
+func putImage(t *testing.T, ds data.DataStorage, repo, tag string, layers ...registry.DescriptorManifest) (string, int64) {
+	t.Helper()
+
+	config := putBlob(t, ds, repo, []byte("{}"))
+	config.MediaType = registry.MediaTypeOCIImageConfig
+	for i := range layers {
+		layers[i].MediaType = "application/vnd.oci.image.layer.v1.tar"
+	}
+
+	payload, err := json.Marshal(registry.ImageManifest{
+		SchemaVersion: 2,
+		MediaType:     registry.MediaTypeOCIImageManifest,
+		Config:        config,
+		Layers:        layers,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := ds.ManifestPut(repo, tag, bytes.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return digest, int64(len(payload))
+}
+
+func newMemoryConfig(t *testing.T) *config.Config {
+	t.Helper()
+
+	cfg, err := config.New(
+		config.WithAdminName("test"),
+		config.WithAdminPwd([]byte("test")),
+		config.WithDataDir("mem://"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
@@ -0,0 +1,228 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package storageops are maintenance operations over a whole registry
+// storage, shared by the commands and the admin HTTP API.
+package storageops
+
+import (
+	"cmp"
+	"errors"
+	"io/fs"
+	"slices"
+
+	"github.com/jlsalvador/simple-registry/internal/data"
+	"github.com/jlsalvador/simple-registry/internal/gc"
+	"github.com/jlsalvador/simple-registry/pkg/mapset"
+)
+
+// RepositoryUsage is the storage used by a repository.
+type RepositoryUsage struct {
+	Name      string `json:"name"`
+	Manifests int    `json:"manifests"`
+	Blobs     int    `json:"blobs"`
+
+	// SharedBlobs is the amount of manifests and blobs also referenced by
+	// other repositories.
+	SharedBlobs int `json:"sharedBlobs"`
+
+	// LogicalBytes is the size of every manifest and blob referenced by the
+	// repository, as if they were not shared.
+	LogicalBytes int64 `json:"logicalBytes"`
+
+	// PhysicalBytes is the size of the manifests and blobs referenced by the
+	// repository, with the shared ones split evenly between the repositories
+	// referencing them.
+	PhysicalBytes int64 `json:"physicalBytes"`
+
+	// ReclaimableBytes is the size of the manifests and blobs only referenced
+	// by the repository, which the garbage collector would free after
+	// deleting it.
+	ReclaimableBytes int64 `json:"reclaimableBytes"`
+}
+
+// BlobUsage is a stored blob, or manifest, and the amount of repositories
+// referencing it.
+type BlobUsage struct {
+	Digest       string `json:"digest"`
+	Size         int64  `json:"size"`
+	Repositories int    `json:"repositories"`
+}
+
+// UsageReport is the storage used by the registry.
+type UsageReport struct {
+	Repositories []RepositoryUsage `json:"repositories"`
+
+	// LogicalBytes is the sum of the logical bytes of every repository.
+	LogicalBytes int64 `json:"logicalBytes"`
+
+	// PhysicalBytes is the size of every stored manifest and blob.
+	PhysicalBytes int64 `json:"physicalBytes"`
+
+	// UnreferencedBytes is the size of the stored manifests and blobs not
+	// referenced by any repository, pending of garbage collection.
+	UnreferencedBytes int64 `json:"unreferencedBytes"`
+
+	Blobs        int         `json:"blobs"`
+	SharedBlobs  int         `json:"sharedBlobs"`
+	LargestBlobs []BlobUsage `json:"largestBlobs"`
+}
+
+// blobSize returns the size of the stored blob, or -1 if it is not stored,
+// maybe because it belongs to a proxy.
+func blobSize(ds data.DataStorage, digest string, sizes map[string]int64) (int64, error) {
+	if size, ok := sizes[digest]; ok {
+		return size, nil
+	}
+
+	r, size, err := ds.BlobsGet("", digest)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return -1, err
+		}
+		size = -1
+	} else {
+		r.Close()
+	}
+
+	sizes[digest] = size
+	return size, nil
+}
+
+// Usage walks the manifests of every repository, and the blobs they
+// reference, to report the storage used by each repository and the top
+// largest blobs.
+//
+// Only the local storage is walked, so upstreams of pull-through caches are
+// not reached.
+func Usage(ds data.DataStorage, top int) (UsageReport, error) {
+	ds = gc.Local(ds)
+
+	repos, err := ds.RepositoriesList()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return UsageReport{}, err
+	}
+
+	// Digests referenced by each repository, and repositories referencing
+	// each digest.
+	referenced := make([]mapset.MapSet[string], len(repos))
+	refCount := map[string]int{}
+
+	report := UsageReport{
+		Repositories: make([]RepositoryUsage, len(repos)),
+		LargestBlobs: []BlobUsage{},
+	}
+
+	for i, repo := range repos {
+		report.Repositories[i].Name = repo
+
+		var roots []string
+		digests, err := ds.ManifestsList(repo)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return UsageReport{}, err
+		}
+		if digests != nil {
+			roots = slices.Collect(digests)
+		}
+
+		manifests, blobs, err := gc.Referenced(ds, repo, roots)
+		if err != nil {
+			return UsageReport{}, err
+		}
+
+		referenced[i] = mapset.NewMapSet[string]()
+		for digest := range manifests {
+			referenced[i].Add(digest)
+		}
+		for digest := range blobs {
+			if !manifests.Contains(digest) {
+				report.Repositories[i].Blobs++
+			}
+			referenced[i].Add(digest)
+		}
+		report.Repositories[i].Manifests = len(manifests)
+
+		for digest := range referenced[i] {
+			refCount[digest]++
+		}
+	}
+
+	sizes := map[string]int64{}
+
+	for i := range repos {
+		repo := &report.Repositories[i]
+		for digest := range referenced[i] {
+			size, err := blobSize(ds, digest, sizes)
+			if err != nil {
+				return UsageReport{}, err
+			}
+			if size < 0 {
+				continue
+			}
+
+			n := refCount[digest]
+			repo.LogicalBytes += size
+			repo.PhysicalBytes += size / int64(n)
+			if n > 1 {
+				repo.SharedBlobs++
+			} else {
+				repo.ReclaimableBytes += size
+			}
+		}
+		report.LogicalBytes += repo.LogicalBytes
+	}
+
+	stored, err := ds.BlobsList()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return UsageReport{}, err
+	}
+	if stored != nil {
+		for digest := range stored {
+			size, err := blobSize(ds, digest, sizes)
+			if err != nil {
+				return UsageReport{}, err
+			}
+			if size < 0 {
+				continue
+			}
+
+			n := refCount[digest]
+			report.Blobs++
+			report.PhysicalBytes += size
+			if n == 0 {
+				report.UnreferencedBytes += size
+			} else if n > 1 {
+				report.SharedBlobs++
+			}
+
+			report.LargestBlobs = append(report.LargestBlobs, BlobUsage{
+				Digest:       digest,
+				Size:         size,
+				Repositories: n,
+			})
+		}
+	}
+
+	slices.SortFunc(report.LargestBlobs, func(a, b BlobUsage) int {
+		if c := cmp.Compare(b.Size, a.Size); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Digest, b.Digest)
+	})
+	if top >= 0 && len(report.LargestBlobs) > top {
+		report.LargestBlobs = report.LargestBlobs[:top]
+	}
+
+	return report, nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2026 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageops_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jlsalvador/simple-registry/internal/config"
+	"github.com/jlsalvador/simple-registry/internal/storageops"
+)
+
+func TestUsage(t *testing.T) {
+	ds := newMemoryConfig(t).Data
+
+	shared := []byte(strings.Repeat("s", 100))
+	onlyA := []byte(strings.Repeat("a", 1000))
+	onlyB := []byte(strings.Repeat("b", 10))
+	orphan := []byte(strings.Repeat("o", 50))
+
+	_, manifestA := putImage(t, ds, "a", "latest", putBlob(t, ds, "a", shared), putBlob(t, ds, "a", onlyA))
+	_, manifestB := putImage(t, ds, "b", "latest", putBlob(t, ds, "b", shared), putBlob(t, ds, "b", onlyB))
+	putBlob(t, ds, "b", orphan)
+
+	report, err := storageops.Usage(ds, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Repositories) != 2 {
+		t.Fatalf("expected 2 repositories, got %d", len(report.Repositories))
+	}
+
+	// The config "{}" and the shared layer are shared by both repositories.
+	a := report.Repositories[0]
+	if a.Name != "a" || a.Manifests != 1 || a.Blobs != 3 || a.SharedBlobs != 2 {
+		t.Errorf("unexpected repository %+v", a)
+	}
+	if want := manifestA + 2 + 100 + 1000; a.LogicalBytes != want {
+		t.Errorf("expected logical bytes %d, got %d", want, a.LogicalBytes)
+	}
+	if want := manifestA + 1 + 50 + 1000; a.PhysicalBytes != want {
+		t.Errorf("expected physical bytes %d, got %d", want, a.PhysicalBytes)
+	}
+	if want := manifestA + 1000; a.ReclaimableBytes != want {
+		t.Errorf("expected reclaimable bytes %d, got %d", want, a.ReclaimableBytes)
+	}
+
+	b := report.Repositories[1]
+	if want := manifestB + 10; b.ReclaimableBytes != want {
+		t.Errorf("expected reclaimable bytes %d, got %d", want, b.ReclaimableBytes)
+	}
+
+	if want := a.LogicalBytes + b.LogicalBytes; report.LogicalBytes != want {
+		t.Errorf("expected logical bytes %d, got %d", want, report.LogicalBytes)
+	}
+	if want := manifestA + manifestB + 2 + 100 + 1000 + 10 + 50; report.PhysicalBytes != want {
+		t.Errorf("expected physical bytes %d, got %d", want, report.PhysicalBytes)
+	}
+	if report.UnreferencedBytes != 50 {
+		t.Errorf("expected unreferenced bytes 50, got %d", report.UnreferencedBytes)
+	}
+	if report.Blobs != 7 || report.SharedBlobs != 2 {
+		t.Errorf("expected 7 blobs and 2 shared, got %d and %d", report.Blobs, report.SharedBlobs)
+	}
+
+	if len(report.LargestBlobs) != 2 {
+		t.Fatalf("expected 2 largest blobs, got %d", len(report.LargestBlobs))
+	}
+	if got := report.LargestBlobs[0]; got.Digest != sha256Digest(onlyA) || got.Repositories != 1 {
+		t.Errorf("unexpected largest blob %+v", got)
+	}
+}
+
+func TestUsage_Empty(t *testing.T) {
+	cfg, err := config.New(
+		config.WithAdminName("test"),
+		config.WithAdminPwd([]byte("test")),
+		config.WithDataDir(t.TempDir()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := storageops.Usage(cfg.Data, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Repositories) != 0 || report.PhysicalBytes != 0 || len(report.LargestBlobs) != 0 {
+		t.Errorf("expected an empty report, got %+v", report)
+	}
+}
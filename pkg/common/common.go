@@ -18,6 +18,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func GetEnv(key, fallback string) string {
@@ -50,3 +51,20 @@ func GetInt64(val string) int64 {
 		return val
 	}
 }
+
+// ParseDuration parses a duration like [time.ParseDuration], also accepting a
+// number of days like "30d". An empty value is a zero duration.
+func ParseDuration(val string) (time.Duration, error) {
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return 0, nil
+	}
+
+	if days, ok := strings.CutSuffix(val, "d"); ok {
+		if n, err := strconv.ParseInt(days, 10, 64); err == nil {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+
+	return time.ParseDuration(val)
+}
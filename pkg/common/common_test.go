@@ -17,6 +17,7 @@ package common_test
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/jlsalvador/simple-registry/pkg/common"
 )
@@ -103,3 +104,28 @@ func TestGetInt64(t *testing.T) {
 		})
 	}
 }
+
+func TestParseDuration(t *testing.T) {
+	for _, tt := range []struct {
+		val     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"30d", 30 * 24 * time.Hour, false},
+		{" 1d ", 24 * time.Hour, false},
+		{"90s", 90 * time.Second, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"d", 0, true},
+		{"1.5d", 0, true},
+		{"forever", 0, true},
+	} {
+		got, err := common.ParseDuration(tt.val)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: unexpected error %v", tt.val, err)
+		}
+		if got != tt.want {
+			t.Errorf("%q: got %v, want %v", tt.val, got, tt.want)
+		}
+	}
+}
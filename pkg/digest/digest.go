@@ -22,6 +22,13 @@ import (
 	"github.com/jlsalvador/simple-registry/pkg/hasher"
 )
 
+// Canonical is the algorithm of the digests used by clients by far.
+//
+// The data storages keep a running hash of every blob upload with it, so its
+// commit does not read the upload again. Commits with other algorithms hash
+// the whole upload.
+const Canonical = "sha256"
+
 var (
 	ErrInvalidDigestFormat  = errors.New("invalid digest format")
 	ErrEmptyAlgorithm       = errors.New("empty algorithm")
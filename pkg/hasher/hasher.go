@@ -15,10 +15,18 @@
 // Package hasher provides a generic interface for hashing data as [io.Writer].
 package hasher
 
+import "encoding"
+
 // Hasher is an interface that defines methods for writing data and retrieving the hash.
 // Complies with the [io.Writer] interface.
+//
+// The hashing state could be saved and restored as binary, so hashing could
+// continue in another process.
 type Hasher interface {
 	Write(p []byte) (n int, err error)
 	GetHash() []byte
 	GetHashAsString() string
+
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
 }
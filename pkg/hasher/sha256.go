@@ -16,6 +16,7 @@ package hasher
 
 import (
 	"crypto/sha256"
+	"encoding"
 	"encoding/hex"
 	"hash"
 )
@@ -49,3 +50,21 @@ func (s *Sha256) GetHash() []byte {
 func (s *Sha256) GetHashAsString() string {
 	return hex.EncodeToString(s.GetHash())
 }
+
+// MarshalBinary returns the hashing state of the written data, so hashing
+// could continue later with [Sha256.UnmarshalBinary].
+func (s *Sha256) MarshalBinary() ([]byte, error) {
+	if s.h == nil {
+		s.h = sha256.New()
+	}
+	return s.h.(encoding.BinaryMarshaler).MarshalBinary()
+}
+
+// UnmarshalBinary restores a hashing state returned by
+// [Sha256.MarshalBinary].
+func (s *Sha256) UnmarshalBinary(state []byte) error {
+	if s.h == nil {
+		s.h = sha256.New()
+	}
+	return s.h.(encoding.BinaryUnmarshaler).UnmarshalBinary(state)
+}
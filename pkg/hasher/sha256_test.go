@@ -83,3 +83,27 @@ func TestSha256_EmptyStruct(t *testing.T) {
 		}
 	})
 }
+
+func TestSha256_MarshalBinary(t *testing.T) {
+	h1 := hasher.NewSha256()
+	h1.Write([]byte("Hello "))
+	state, err := h1.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h2 := hasher.Sha256{}
+	if err := h2.UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+	h2.Write([]byte("world"))
+
+	want := "64ec88ca00b268e5ba1a35678a1b5316d212f4f366b2477232534a8aeca37f3c"
+	if got := h2.GetHashAsString(); got != want {
+		t.Errorf("hash mismatch: expected %s, got %s", want, got)
+	}
+
+	if err := h2.UnmarshalBinary([]byte("invalid")); err == nil {
+		t.Error("expected error for invalid state")
+	}
+}
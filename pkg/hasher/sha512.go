@@ -16,6 +16,7 @@ package hasher
 
 import (
 	"crypto/sha512"
+	"encoding"
 	"encoding/hex"
 	"hash"
 )
@@ -49,3 +50,21 @@ func (s *Sha512) GetHash() []byte {
 func (s *Sha512) GetHashAsString() string {
 	return hex.EncodeToString(s.GetHash())
 }
+
+// MarshalBinary returns the hashing state of the written data, so hashing
+// could continue later with [Sha512.UnmarshalBinary].
+func (s *Sha512) MarshalBinary() ([]byte, error) {
+	if s.h == nil {
+		s.h = sha512.New()
+	}
+	return s.h.(encoding.BinaryMarshaler).MarshalBinary()
+}
+
+// UnmarshalBinary restores a hashing state returned by
+// [Sha512.MarshalBinary].
+func (s *Sha512) UnmarshalBinary(state []byte) error {
+	if s.h == nil {
+		s.h = sha512.New()
+	}
+	return s.h.(encoding.BinaryUnmarshaler).UnmarshalBinary(state)
+}
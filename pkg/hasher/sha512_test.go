@@ -83,3 +83,27 @@ func TestSha512_EmptyStruct(t *testing.T) {
 		}
 	})
 }
+
+func TestSha512_MarshalBinary(t *testing.T) {
+	h1 := hasher.NewSha512()
+	h1.Write([]byte("Hello "))
+	state, err := h1.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h2 := hasher.Sha512{}
+	if err := h2.UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+	h2.Write([]byte("world"))
+
+	want := "b7f783baed8297f0db917462184ff4f08e69c2d5e5f79a942600f9725f58ce1f29c18139bf80b06c0fff2bdd34738452ecf40c488c22a7e3d80cdf6f9c1c0d47"
+	if got := h2.GetHashAsString(); got != want {
+		t.Errorf("hash mismatch: expected %s, got %s", want, got)
+	}
+
+	if err := h2.UnmarshalBinary([]byte("invalid")); err == nil {
+		t.Error("expected error for invalid state")
+	}
+}
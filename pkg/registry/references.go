@@ -0,0 +1,54 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import "encoding/json"
+
+// manifestReferences holds the descriptors of every supported manifest
+// format, so the content referenced by any of them is decoded at once.
+type manifestReferences struct {
+	Config    *DescriptorManifest  `json:"config"`
+	Layers    []DescriptorManifest `json:"layers"`
+	Manifests []DescriptorManifest `json:"manifests"`
+	FSLayers  []struct {
+		BlobSum string `json:"blobSum"`
+	} `json:"fsLayers"`
+}
+
+// References returns the digests of the config, layers and child manifests
+// referenced by an image manifest, an image index or a Docker manifest V1.
+//
+// The subject is not included, as it is referenced the other way around.
+func References(payload []byte) ([]string, error) {
+	var m manifestReferences
+	if err := json.Unmarshal(payload, &m); err != nil {
+		return nil, err
+	}
+
+	var digests []string
+	if m.Config != nil && m.Config.Digest != "" {
+		digests = append(digests, m.Config.Digest)
+	}
+	for _, l := range m.Layers {
+		digests = append(digests, l.Digest)
+	}
+	for _, child := range m.Manifests {
+		digests = append(digests, child.Digest)
+	}
+	for _, l := range m.FSLayers {
+		digests = append(digests, l.BlobSum)
+	}
+	return digests, nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2026 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jlsalvador/simple-registry/pkg/registry"
+)
+
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    []string
+	}{
+		{
+			name:    "image manifest",
+			payload: `{"config":{"digest":"sha256:c"},"layers":[{"digest":"sha256:l1"},{"digest":"sha256:l2"}],"subject":{"digest":"sha256:s"}}`,
+			want:    []string{"sha256:c", "sha256:l1", "sha256:l2"},
+		},
+		{
+			name:    "image index",
+			payload: `{"manifests":[{"digest":"sha256:m1"},{"digest":"sha256:m2"}]}`,
+			want:    []string{"sha256:m1", "sha256:m2"},
+		},
+		{
+			name:    "docker manifest v1",
+			payload: `{"fsLayers":[{"blobSum":"sha256:b"}]}`,
+			want:    []string{"sha256:b"},
+		},
+		{
+			name:    "empty",
+			payload: `{}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := registry.References([]byte(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := registry.References([]byte("not json")); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// MinPartSize is the minimum size of every multipart upload part, except the
+// last one.
+const MinPartSize = 5 << 20
+
+// Part identifies an uploaded part of a multipart upload.
+type Part struct {
+	PartNumber int    `xml:"PartNumber" json:"partNumber"`
+	ETag       string `xml:"ETag" json:"etag"`
+}
+
+// CreateMultipartUpload starts a multipart upload for key, and returns its
+// upload ID.
+func (c *Client) CreateMultipartUpload(key string) (uploadID string, err error) {
+	q := url.Values{"uploads": {""}}
+	req, err := http.NewRequest(http.MethodPost, c.objectURL(key, q), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	return result.UploadID, nil
+}
+
+// UploadPart uploads data as the part number partNumber, starting from 1, and
+// returns its ETag.
+func (c *Client) UploadPart(
+	key string,
+	uploadID string,
+	partNumber int,
+	data []byte,
+) (etag string, err error) {
+	q := url.Values{
+		"partNumber": {strconv.Itoa(partNumber)},
+		"uploadId":   {uploadID},
+	}
+	req, err := http.NewRequest(http.MethodPut, c.objectURL(key, q), bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	return resp.Header.Get("ETag"), nil
+}
+
+// UploadPartCopy uploads the bytes first to last, both inclusive, from the
+// object src as the part number partNumber, and returns its ETag.
+func (c *Client) UploadPartCopy(
+	key string,
+	uploadID string,
+	partNumber int,
+	src string,
+	first int64,
+	last int64,
+) (etag string, err error) {
+	q := url.Values{
+		"partNumber": {strconv.Itoa(partNumber)},
+		"uploadId":   {uploadID},
+	}
+	req, err := http.NewRequest(http.MethodPut, c.objectURL(key, q), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Amz-Copy-Source", c.copySource(src))
+	req.Header.Set("X-Amz-Copy-Source-Range", rangeHeader(first, last))
+
+	resp, err := c.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		XMLName xml.Name
+		ETag    string `xml:"ETag"`
+	}
+	if err := xml.Unmarshal(b, &result); err != nil {
+		return "", err
+	}
+
+	// A copy could fail after the 200 status code was sent.
+	if result.XMLName.Local == "Error" {
+		return "", parseError(http.StatusInternalServerError, bytes.NewReader(b))
+	}
+
+	return result.ETag, nil
+}
+
+// CompleteMultipartUpload assembles the uploaded parts as the object key.
+func (c *Client) CompleteMultipartUpload(key, uploadID string, parts []Part) error {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []Part   `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+
+	q := url.Values{"uploadId": {uploadID}}
+	req, err := http.NewRequest(http.MethodPost, c.objectURL(key, q), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// A multipart upload could fail after the 200 status code was sent.
+	return checkBodyError(resp)
+}
+
+// AbortMultipartUpload cancels the multipart upload, and discards its parts.
+func (c *Client) AbortMultipartUpload(key, uploadID string) error {
+	q := url.Values{"uploadId": {uploadID}}
+	req, err := http.NewRequest(http.MethodDelete, c.objectURL(key, q), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
@@ -0,0 +1,256 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Object describes a stored object.
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// GetObject returns the object content and its size.
+//
+// The caller must close the returned reader.
+func (c *Client) GetObject(key string) (r io.ReadCloser, size int64, err error) {
+	req, err := http.NewRequest(http.MethodGet, c.objectURL(key, nil), nil)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	return resp.Body, resp.ContentLength, nil
+}
+
+// HeadObject returns the object metadata.
+func (c *Client) HeadObject(key string) (Object, error) {
+	req, err := http.NewRequest(http.MethodHead, c.objectURL(key, nil), nil)
+	if err != nil {
+		return Object{}, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return Object{}, err
+	}
+	resp.Body.Close()
+
+	lastModified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+
+	return Object{
+		Key:          key,
+		Size:         resp.ContentLength,
+		LastModified: lastModified,
+	}, nil
+}
+
+// PutObject stores data as the object key, replacing any existing object.
+func (c *Client) PutObject(key string, data []byte) error {
+	req, err := http.NewRequest(http.MethodPut, c.objectURL(key, nil), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// DeleteObject removes the object key.
+//
+// Deleting a missing object is not an error.
+func (c *Client) DeleteObject(key string) error {
+	req, err := http.NewRequest(http.MethodDelete, c.objectURL(key, nil), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+}
+
+// ListObjects returns every object whose key starts with prefix.
+//
+// If delimiter is not empty, the keys that contain the delimiter after the
+// prefix are grouped and returned as prefixes instead of objects.
+func (c *Client) ListObjects(prefix, delimiter string) (
+	objects []Object,
+	prefixes []string,
+	err error,
+) {
+	token := ""
+	for {
+		q := url.Values{}
+		q.Set("list-type", "2")
+		q.Set("prefix", prefix)
+		if delimiter != "" {
+			q.Set("delimiter", delimiter)
+		}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+
+		req, err := http.NewRequest(http.MethodGet, c.objectURL("", q), nil)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		resp, err := c.do(req)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, o := range result.Contents {
+			objects = append(objects, Object{
+				Key:          o.Key,
+				Size:         o.Size,
+				LastModified: o.LastModified,
+			})
+		}
+		for _, p := range result.CommonPrefixes {
+			prefixes = append(prefixes, p.Prefix)
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, prefixes, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// MaxCopySize is the biggest object that can be copied with a single request.
+// Bigger objects are copied by parts.
+const MaxCopySize int64 = 5 << 30
+
+// copyPartSize is the size of each part when copying big objects.
+const copyPartSize int64 = 1 << 30
+
+// copySource returns the "x-amz-copy-source" header value for key.
+func (c *Client) copySource(key string) string {
+	return "/" + uriEncode(c.Bucket, true) + "/" + uriEncode(key, false)
+}
+
+// CopyObject copies the object src, with the given size, as dst.
+func (c *Client) CopyObject(src, dst string, size int64) error {
+	if size > MaxCopySize {
+		return c.copyObjectByParts(src, dst, size)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, c.objectURL(dst, nil), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Amz-Copy-Source", c.copySource(src))
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// A copy could fail after the 200 status code was sent.
+	return checkBodyError(resp)
+}
+
+func (c *Client) copyObjectByParts(src, dst string, size int64) error {
+	uploadID, err := c.CreateMultipartUpload(dst)
+	if err != nil {
+		return err
+	}
+
+	var parts []Part
+	for first := int64(0); first < size; first += copyPartSize {
+		last := min(first+copyPartSize, size) - 1
+		etag, err := c.UploadPartCopy(dst, uploadID, len(parts)+1, src, first, last)
+		if err != nil {
+			_ = c.AbortMultipartUpload(dst, uploadID)
+			return err
+		}
+		parts = append(parts, Part{PartNumber: len(parts) + 1, ETag: etag})
+	}
+
+	if err := c.CompleteMultipartUpload(dst, uploadID, parts); err != nil {
+		_ = c.AbortMultipartUpload(dst, uploadID)
+		return err
+	}
+
+	return nil
+}
+
+// checkBodyError returns an [Error] if the response body is an error document.
+func checkBodyError(resp *http.Response) error {
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var root struct {
+		XMLName xml.Name
+	}
+	if xml.Unmarshal(b, &root) == nil && root.XMLName.Local == "Error" {
+		return parseError(http.StatusInternalServerError, bytes.NewReader(b))
+	}
+
+	return nil
+}
+
+// rangeHeader returns a "Range" like header value for bytes first to last,
+// both inclusive.
+func rangeHeader(first, last int64) string {
+	return "bytes=" + strconv.FormatInt(first, 10) + "-" + strconv.FormatInt(last, 10)
+}
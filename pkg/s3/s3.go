@@ -0,0 +1,134 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package s3 provides a minimal client for S3-compatible object storages,
+// such as AWS S3 or MinIO.
+//
+// Requests are signed with [AWS Signature Version 4] and always use path-style
+// URLs ("<endpoint>/<bucket>/<key>").
+//
+// [AWS Signature Version 4]: https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-authenticating-requests.html
+package s3
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client is a S3-compatible object storage client bound to a single bucket.
+type Client struct {
+	Endpoint        string // Base URL, like "https://s3.eu-west-1.amazonaws.com".
+	Region          string // Region used to sign requests, like "us-east-1".
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+
+	HTTPClient *http.Client
+
+	// Now returns the current time, used to sign requests.
+	// It can be replaced for testing purposes.
+	Now func() time.Time
+}
+
+// New returns a [Client] for the given bucket.
+//
+// If region is empty, "us-east-1" will be used.
+func New(endpoint, region, bucket, accessKeyID, secretAccessKey string) *Client {
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &Client{
+		Endpoint:        strings.TrimRight(endpoint, "/"),
+		Region:          region,
+		Bucket:          bucket,
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+		HTTPClient:      &http.Client{},
+		Now:             time.Now,
+	}
+}
+
+// Error is an error response returned by the object storage.
+//
+// Errors with the HTTP status code 404 wrap [fs.ErrNotExist].
+type Error struct {
+	StatusCode int    `xml:"-"`
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("s3: %s", http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("s3: %s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	if e.StatusCode == http.StatusNotFound {
+		return fs.ErrNotExist
+	}
+	return nil
+}
+
+// parseError builds an [Error] from a response body.
+func parseError(statusCode int, body io.Reader) error {
+	e := &Error{StatusCode: statusCode}
+	b, _ := io.ReadAll(io.LimitReader(body, 64*1024))
+	_ = xml.Unmarshal(b, e)
+	return e
+}
+
+// objectURL returns the path-style URL for the given key.
+func (c *Client) objectURL(key string, query url.Values) string {
+	u := c.Endpoint + "/" + uriEncode(c.Bucket, true)
+	if key != "" {
+		u += "/" + uriEncode(key, false)
+	}
+	if len(query) > 0 {
+		u += "?" + canonicalQuery(query)
+	}
+	return u
+}
+
+// do signs and sends the request.
+//
+// Any response with a status code outside of 2xx is returned as an [Error].
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	sign(req, c.AccessKeyID, c.SecretAccessKey, c.Region, c.Now().UTC())
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, parseError(resp.StatusCode, resp.Body)
+	}
+
+	return resp, nil
+}
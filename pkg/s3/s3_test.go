@@ -0,0 +1,235 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"testing"
+
+	"github.com/jlsalvador/simple-registry/pkg/s3"
+	"github.com/jlsalvador/simple-registry/pkg/s3/s3test"
+)
+
+func newTestClient(t *testing.T) (*s3.Client, *s3test.Server) {
+	t.Helper()
+
+	srv := s3test.NewServer()
+	t.Cleanup(srv.Close)
+
+	return s3.New(srv.URL, "", "bucket", "key", "secret"), srv
+}
+
+func TestObjects(t *testing.T) {
+	c, _ := newTestClient(t)
+
+	if err := c.PutObject("a/b:c", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	r, size, err := c.GetObject("a/b:c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(r)
+	r.Close()
+	if string(b) != "hello" || size != 5 {
+		t.Errorf("unexpected object %q with size %d", b, size)
+	}
+
+	o, err := c.HeadObject("a/b:c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Size != 5 || o.LastModified.IsZero() {
+		t.Errorf("unexpected object metadata %+v", o)
+	}
+
+	if err := c.CopyObject("a/b:c", "d", 5); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.HeadObject("d"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.DeleteObject("a/b:c"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.GetObject("a/b:c"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+	if _, err := c.HeadObject("a/b:c"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestListObjects(t *testing.T) {
+	c, srv := newTestClient(t)
+	srv.MaxKeys = 2 // Force pagination.
+
+	keys := []string{"p/a", "p/b/1", "p/b/2", "p/c", "p/d/1", "q/a"}
+	for _, k := range keys {
+		if err := c.PutObject(k, []byte(k)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	objects, prefixes, err := c.ListObjects("p/", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, o := range objects {
+		got = append(got, o.Key)
+	}
+	if want := keys[:5]; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if len(prefixes) != 0 {
+		t.Errorf("unexpected prefixes %v", prefixes)
+	}
+
+	objects, prefixes, err = c.ListObjects("p/", "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for _, o := range objects {
+		got = append(got, o.Key)
+	}
+	if want := []string{"p/a", "p/c"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if want := []string{"p/b/", "p/d/"}; !slices.Equal(prefixes, want) {
+		t.Errorf("got %v, want %v", prefixes, want)
+	}
+}
+
+func TestMultipartUpload(t *testing.T) {
+	c, srv := newTestClient(t)
+	srv.MinPartSize = 4
+
+	id, err := c.CreateMultipartUpload("big")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parts []s3.Part
+	for i, chunk := range []string{"1234", "5678", "9"} {
+		etag, err := c.UploadPart("big", id, i+1, []byte(chunk))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, s3.Part{PartNumber: i + 1, ETag: etag})
+	}
+
+	if err := c.CompleteMultipartUpload("big", id, parts); err != nil {
+		t.Fatal(err)
+	}
+
+	r, _, err := c.GetObject("big")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(r)
+	r.Close()
+	if string(b) != "123456789" {
+		t.Errorf("unexpected object %q", b)
+	}
+}
+
+func TestMultipartUploadTooSmall(t *testing.T) {
+	c, srv := newTestClient(t)
+
+	id, err := c.CreateMultipartUpload("big")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parts []s3.Part
+	for i := range 2 {
+		etag, err := c.UploadPart("big", id, i+1, []byte("tiny"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, s3.Part{PartNumber: i + 1, ETag: etag})
+	}
+
+	err = c.CompleteMultipartUpload("big", id, parts)
+	var s3Err *s3.Error
+	if !errors.As(err, &s3Err) || s3Err.Code != "EntityTooSmall" {
+		t.Fatalf("expected EntityTooSmall, got %v", err)
+	}
+
+	if err := c.AbortMultipartUpload("big", id); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.Uploads(); n != 0 {
+		t.Errorf("expected no uploads in progress, got %d", n)
+	}
+}
+
+func TestUploadPartCopy(t *testing.T) {
+	c, srv := newTestClient(t)
+	srv.MinPartSize = 1
+
+	data := bytes.Repeat([]byte("0123456789"), 3)
+	if err := c.PutObject("src", data); err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := c.CreateMultipartUpload("dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parts []s3.Part
+	for first := int64(0); first < int64(len(data)); first += 10 {
+		etag, err := c.UploadPartCopy("dst", id, len(parts)+1, "src", first, first+9)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, s3.Part{PartNumber: len(parts) + 1, ETag: etag})
+	}
+	if err := c.CompleteMultipartUpload("dst", id, parts); err != nil {
+		t.Fatal(err)
+	}
+
+	r, _, err := c.GetObject("dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(r)
+	r.Close()
+	if !bytes.Equal(b, data) {
+		t.Errorf("got %q, want %q", b, data)
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	err := &s3.Error{StatusCode: 403, Code: "AccessDenied", Message: "Access Denied"}
+	if got, want := err.Error(), "s3: AccessDenied: Access Denied"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	err = &s3.Error{StatusCode: 500}
+	if got, want := err.Error(), fmt.Sprintf("s3: %s", "Internal Server Error"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
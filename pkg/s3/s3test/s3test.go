@@ -0,0 +1,375 @@
+// Copyright 2025 José Luis Salvador Rufo <salvador.joseluis@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package s3test provides an in-memory S3-compatible server for testing, like
+// a local MinIO instance.
+//
+// Only path-style requests and the operations used by package s3 are
+// implemented.
+package s3test
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type object struct {
+	data         []byte
+	etag         string
+	lastModified time.Time
+}
+
+type upload struct {
+	key   string
+	parts map[int]object
+}
+
+// Server is an in-memory S3-compatible server.
+//
+// Buckets are created on demand.
+type Server struct {
+	*httptest.Server
+
+	// MaxKeys is the maximum number of keys returned by each list request.
+	MaxKeys int
+
+	// MinPartSize is the minimum size of each part, except the last one, of a
+	// multipart upload.
+	MinPartSize int
+
+	mu      sync.Mutex
+	objects map[string]object // "<bucket>/<key>" to object.
+	uploads map[string]*upload
+	nextID  int
+}
+
+// NewServer starts and returns a new [Server].
+//
+// The caller should call Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		MaxKeys:     1000,
+		MinPartSize: 5 << 20,
+		objects:     map[string]object{},
+		uploads:     map[string]*upload{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Keys returns the sorted keys stored in bucket.
+func (s *Server) Keys(bucket string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []string
+	for k := range s.objects {
+		if key, ok := strings.CutPrefix(k, bucket+"/"); ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Uploads returns how many multipart uploads are in progress.
+func (s *Server) Uploads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.uploads)
+}
+
+func newObject(data []byte) object {
+	sum := md5.Sum(data)
+	return object{
+		data:         data,
+		etag:         `"` + hex.EncodeToString(sum[:]) + `"`,
+		lastModified: time.Now().UTC(),
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+func writeXML(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	_ = xml.NewEncoder(w).Encode(v)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=") {
+		writeError(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	q := r.URL.Query()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		s.listObjects(w, bucket, q)
+	case r.Method == http.MethodPost && q.Has("uploads"):
+		s.createMultipartUpload(w, bucket+"/"+key)
+	case r.Method == http.MethodPost && q.Has("uploadId"):
+		s.completeMultipartUpload(w, r, q.Get("uploadId"))
+	case r.Method == http.MethodPut && q.Has("uploadId"):
+		s.uploadPart(w, r, bucket, q)
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		s.copyObject(w, r, bucket+"/"+key)
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		o := newObject(data)
+		s.objects[bucket+"/"+key] = o
+		w.Header().Set("ETag", o.etag)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodDelete && q.Has("uploadId"):
+		delete(s.uploads, q.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete:
+		delete(s.objects, bucket+"/"+key)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		s.getObject(w, r, bucket+"/"+key)
+	default:
+		writeError(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+func (s *Server) getObject(w http.ResponseWriter, r *http.Request, fullKey string) {
+	o, ok := s.objects[fullKey]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(o.data)))
+	w.Header().Set("ETag", o.etag)
+	w.Header().Set("Last-Modified", o.lastModified.Format(http.TimeFormat))
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(o.data)
+	}
+}
+
+func (s *Server) sourceObject(r *http.Request, header string) (object, bool) {
+	src, err := url.PathUnescape(strings.TrimPrefix(r.Header.Get(header), "/"))
+	if err != nil {
+		return object{}, false
+	}
+	o, ok := s.objects[src]
+	return o, ok
+}
+
+func (s *Server) copyObject(w http.ResponseWriter, r *http.Request, fullKey string) {
+	o, ok := s.sourceObject(r, "X-Amz-Copy-Source")
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+
+	copied := newObject(slices.Clone(o.data))
+	s.objects[fullKey] = copied
+
+	writeXML(w, struct {
+		XMLName xml.Name `xml:"CopyObjectResult"`
+		ETag    string   `xml:"ETag"`
+	}{ETag: copied.etag})
+}
+
+func (s *Server) createMultipartUpload(w http.ResponseWriter, fullKey string) {
+	s.nextID++
+	id := strconv.Itoa(s.nextID)
+	s.uploads[id] = &upload{key: fullKey, parts: map[int]object{}}
+
+	writeXML(w, struct {
+		XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+		UploadID string   `xml:"UploadId"`
+	}{UploadID: id})
+}
+
+func (s *Server) uploadPart(w http.ResponseWriter, r *http.Request, bucket string, q url.Values) {
+	u, ok := s.uploads[q.Get("uploadId")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+	n, err := strconv.Atoi(q.Get("partNumber"))
+	if err != nil || n < 1 {
+		writeError(w, http.StatusBadRequest, "InvalidArgument")
+		return
+	}
+
+	// Upload part copy.
+	if r.Header.Get("X-Amz-Copy-Source") != "" {
+		src, ok := s.sourceObject(r, "X-Amz-Copy-Source")
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		var first, last int
+		rng := r.Header.Get("X-Amz-Copy-Source-Range")
+		if _, err := fmt.Sscanf(rng, "bytes=%d-%d", &first, &last); err != nil || last >= len(src.data) {
+			writeError(w, http.StatusBadRequest, "InvalidRange")
+			return
+		}
+		part := newObject(slices.Clone(src.data[first : last+1]))
+		u.parts[n] = part
+		writeXML(w, struct {
+			XMLName xml.Name `xml:"CopyPartResult"`
+			ETag    string   `xml:"ETag"`
+		}{ETag: part.etag})
+		return
+	}
+
+	data, _ := io.ReadAll(r.Body)
+	part := newObject(data)
+	u.parts[n] = part
+	w.Header().Set("ETag", part.etag)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) completeMultipartUpload(w http.ResponseWriter, r *http.Request, id string) {
+	u, ok := s.uploads[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+
+	var req struct {
+		Parts []struct {
+			PartNumber int    `xml:"PartNumber"`
+			ETag       string `xml:"ETag"`
+		} `xml:"Part"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Parts) == 0 {
+		writeError(w, http.StatusBadRequest, "MalformedXML")
+		return
+	}
+
+	var data bytes.Buffer
+	for i, p := range req.Parts {
+		part, ok := u.parts[p.PartNumber]
+		if !ok || part.etag != p.ETag {
+			writeError(w, http.StatusBadRequest, "InvalidPart")
+			return
+		}
+		if i > 0 && req.Parts[i-1].PartNumber >= p.PartNumber {
+			writeError(w, http.StatusBadRequest, "InvalidPartOrder")
+			return
+		}
+		if i < len(req.Parts)-1 && len(part.data) < s.MinPartSize {
+			writeError(w, http.StatusBadRequest, "EntityTooSmall")
+			return
+		}
+		data.Write(part.data)
+	}
+
+	o := newObject(data.Bytes())
+	s.objects[u.key] = o
+	delete(s.uploads, id)
+
+	writeXML(w, struct {
+		XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+		ETag    string   `xml:"ETag"`
+	}{ETag: o.etag})
+}
+
+func (s *Server) listObjects(w http.ResponseWriter, bucket string, q url.Values) {
+	prefix := q.Get("prefix")
+	delimiter := q.Get("delimiter")
+	token := q.Get("continuation-token")
+
+	type content struct {
+		Key          string `xml:"Key"`
+		Size         int    `xml:"Size"`
+		LastModified string `xml:"LastModified"`
+	}
+	type commonPrefix struct {
+		Prefix string `xml:"Prefix"`
+	}
+	result := struct {
+		XMLName               xml.Name       `xml:"ListBucketResult"`
+		IsTruncated           bool           `xml:"IsTruncated"`
+		NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+		Contents              []content      `xml:"Contents"`
+		CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+	}{}
+
+	var keys []string
+	for k := range s.objects {
+		if key, ok := strings.CutPrefix(k, bucket+"/"); ok && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	seen := map[string]bool{}
+	n := 0
+	for _, key := range keys {
+		// Both, keys and common prefixes, are sorted, so the continuation
+		// token is the last returned one.
+		entry := key
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				entry = key[:len(prefix)+i+len(delimiter)]
+			}
+		}
+		if entry <= token || seen[entry] {
+			continue
+		}
+		if n == s.MaxKeys {
+			result.IsTruncated = true
+			break
+		}
+		seen[entry] = true
+		n++
+		result.NextContinuationToken = entry
+
+		if entry != key {
+			result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{entry})
+			continue
+		}
+		o := s.objects[bucket+"/"+key]
+		result.Contents = append(result.Contents, content{
+			Key:          key,
+			Size:         len(o.data),
+			LastModified: o.lastModified.Format("2006-01-02T15:04:05.000Z"),
+		})
+	}
+	if !result.IsTruncated {
+		result.NextContinuationToken = ""
+	}
+
+	writeXML(w, result)
+}